@@ -7,18 +7,73 @@ import (
 )
 
 type Config struct {
-	GINMode        string        `mapstructure:"GIN_MODE"`
-	LogLevel       string        `mapstructure:"LOG_LEVEL"`
-	AutoMigrate    bool          `mapstructure:"AUTO_MIGRATE"`
-	HTTPPort       string        `mapstructure:"HTTP_PORT"`
-	DBHost         string        `mapstructure:"DB_HOST"`
-	DBPort         string        `mapstructure:"DB_PORT"`
-	DBDatabaseName string        `mapstructure:"DB_NAME"`
-	DBUsername     string        `mapstructure:"DB_USERNAME"`
-	DBPassword     string        `mapstructure:"DB_PASSWORD"`
-	JWTSecret      string        `mapstructure:"JWT_SECRET"`
-	JWTIss         string        `mapstructure:"JWT_ISS"`
-	JWTExp         time.Duration `mapstructure:"JWT_EXP"`
+	GINMode           string        `mapstructure:"GIN_MODE"`
+	LogLevel          string        `mapstructure:"LOG_LEVEL"`
+	AutoMigrate       bool          `mapstructure:"AUTO_MIGRATE"`
+	HTTPPort          string        `mapstructure:"HTTP_PORT"`
+	HTTPStreamTimeout time.Duration `mapstructure:"HTTP_STREAM_TIMEOUT"`
+	HTTPUploadTimeout time.Duration `mapstructure:"HTTP_UPLOAD_TIMEOUT"`
+	DBHost            string        `mapstructure:"DB_HOST"`
+	DBPort            string        `mapstructure:"DB_PORT"`
+	DBDatabaseName    string        `mapstructure:"DB_NAME"`
+	DBUsername        string        `mapstructure:"DB_USERNAME"`
+	DBPassword        string        `mapstructure:"DB_PASSWORD"`
+	JWTSecret         string        `mapstructure:"JWT_SECRET"`
+	JWTIss            string        `mapstructure:"JWT_ISS"`
+	JWTExp            time.Duration `mapstructure:"JWT_EXP"`
+
+	StorageDriver    string `mapstructure:"STORAGE_DRIVER"`
+	StorageLocalPath string `mapstructure:"STORAGE_LOCAL_PATH"`
+	StorageBaseURL   string `mapstructure:"STORAGE_BASE_URL"`
+	S3Endpoint       string `mapstructure:"S3_ENDPOINT"`
+	S3Bucket         string `mapstructure:"S3_BUCKET"`
+	S3Region         string `mapstructure:"S3_REGION"`
+	S3AccessKey      string `mapstructure:"S3_ACCESS_KEY"`
+	S3SecretKey      string `mapstructure:"S3_SECRET_KEY"`
+
+	MediaMaxSize       int64 `mapstructure:"MEDIA_MAX_SIZE"`
+	MediaThumbnailSize int   `mapstructure:"MEDIA_THUMBNAIL_SIZE"`
+
+	DownloadLocalPath string        `mapstructure:"DOWNLOAD_LOCAL_PATH"`
+	DownloadS3Bucket  string        `mapstructure:"DOWNLOAD_S3_BUCKET"`
+	DownloadBaseURL   string        `mapstructure:"DOWNLOAD_BASE_URL"`
+	DownloadSecret    string        `mapstructure:"DOWNLOAD_SECRET"`
+	DownloadTTL       time.Duration `mapstructure:"DOWNLOAD_TTL"`
+	DownloadMaxCount  int           `mapstructure:"DOWNLOAD_MAX_COUNT"`
+	DownloadMaxSize   int64         `mapstructure:"DOWNLOAD_MAX_SIZE"`
+
+	DefaultCurrency string `mapstructure:"DEFAULT_CURRENCY"`
+
+	DefaultLocale string `mapstructure:"DEFAULT_LOCALE"`
+	Locales       string `mapstructure:"LOCALES"`
+
+	TrashRetention     time.Duration `mapstructure:"TRASH_RETENTION"`
+	TrashPurgeInterval time.Duration `mapstructure:"TRASH_PURGE_INTERVAL"`
+
+	ProductPublishInterval time.Duration `mapstructure:"PRODUCT_PUBLISH_INTERVAL"`
+
+	ReservationTTL           time.Duration `mapstructure:"RESERVATION_TTL"`
+	ReservationSweepInterval time.Duration `mapstructure:"RESERVATION_SWEEP_INTERVAL"`
+
+	StockAlertInterval time.Duration `mapstructure:"STOCK_ALERT_INTERVAL"`
+
+	BackInStockInterval time.Duration `mapstructure:"BACK_IN_STOCK_INTERVAL"`
+
+	LotExpiryInterval time.Duration `mapstructure:"LOT_EXPIRY_INTERVAL"`
+
+	RecommendationInterval time.Duration `mapstructure:"RECOMMENDATION_INTERVAL"`
+	RecommendationMinCount int           `mapstructure:"RECOMMENDATION_MIN_COUNT"`
+	RecommendationLimit    int           `mapstructure:"RECOMMENDATION_LIMIT"`
+
+	NotifierDrivers     string `mapstructure:"NOTIFIER_DRIVERS"`
+	SMTPHost            string `mapstructure:"SMTP_HOST"`
+	SMTPPort            string `mapstructure:"SMTP_PORT"`
+	SMTPUsername        string `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword        string `mapstructure:"SMTP_PASSWORD"`
+	SMTPFrom            string `mapstructure:"SMTP_FROM"`
+	NotifyEmailTo       string `mapstructure:"NOTIFY_EMAIL_TO"`
+	NotifyWebhookURL    string `mapstructure:"NOTIFY_WEBHOOK_URL"`
+	NotifyWebhookSecret string `mapstructure:"NOTIFY_WEBHOOK_SECRET"`
 }
 
 // Load reads configuration from environment variables.
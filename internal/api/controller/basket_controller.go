@@ -6,18 +6,21 @@ import (
 	"github.com/bestetufan/beste-store/internal/domain/entity"
 	"github.com/bestetufan/beste-store/internal/service"
 	"github.com/bestetufan/beste-store/pkg/logger"
+	"github.com/bestetufan/beste-store/pkg/measure"
+	"github.com/bestetufan/beste-store/pkg/money"
 	"github.com/gin-gonic/gin"
 )
 
 type (
 	Basket struct {
-		storeService service.StoreService
-		logger       logger.Logger
+		storeService    service.StoreService
+		exchangeService service.ExchangeService
+		logger          logger.Logger
 	}
 
 	newBasketItemRequest struct {
-		ProductId uint32 `json:"product_id"`
-		Quantity  int    `json:"quantity"`
+		ProductId uint32           `json:"product_id"`
+		Quantity  measure.Quantity `json:"quantity" swaggertype:"number"`
 	}
 
 	removeBasketItemRequest struct {
@@ -25,13 +28,15 @@ type (
 	}
 
 	basketResponse struct {
-		ID    string               `json:"id"`
-		Items []*entity.BasketItem `json:"items"`
+		ID       string               `json:"id"`
+		Items    []*entity.BasketItem `json:"items"`
+		Total    money.Money          `json:"total" swaggertype:"number"`
+		Currency string               `json:"currency"`
 	}
 )
 
-func NewBasket(cs service.StoreService, l logger.Logger) *Basket {
-	return &Basket{cs, l}
+func NewBasket(cs service.StoreService, es service.ExchangeService, l logger.Logger) *Basket {
+	return &Basket{cs, es, l}
 }
 
 // getBasket godoc
@@ -39,6 +44,8 @@ func NewBasket(cs service.StoreService, l logger.Logger) *Basket {
 // @Tags         Basket
 // @Accept       json
 // @Produce      json
+// @Param currency query string false "Currency of prices"
+// @Param X-Currency header string false "Currency of prices"
 // @Success 200 {object} basketResponse
 // @Failure 400 {object} response
 // @Failure 404 {object} response
@@ -57,11 +64,24 @@ func (c *Basket) GetBasket(g *gin.Context) {
 		return
 	}
 
-	g.JSON(http.StatusOK, basketResponse{ID: basket.ID, Items: basket.Items})
+	if err := c.exchangeService.ConvertBasket(basket, requestCurrency(g)); err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	total, err := basket.Total()
+	if err != nil {
+		c.logger.Error(err, "http - v1 - getBasket")
+		errorResponse(g, http.StatusInternalServerError, "basket contains products of different currencies")
+		return
+	}
+
+	g.JSON(http.StatusOK, basketResponse{ID: basket.ID, Items: basket.Items, Total: total, Currency: total.Currency})
 }
 
 // addBasketItem godoc
-// @Description  Adds an item to basket.
+// @Description  Adds an item to basket. Products without enough stock can be saved to the wishlist and
+// @Description  subscribed to for a notification when they are back in stock.
 // @Tags         Basket
 // @Accept       json
 // @Produce      json
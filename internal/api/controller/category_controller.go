@@ -2,38 +2,49 @@ package controller
 
 import (
 	"net/http"
+	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/bestetufan/beste-store/internal/domain/entity"
 	"github.com/bestetufan/beste-store/internal/service"
 	"github.com/bestetufan/beste-store/pkg/logger"
 	"github.com/bestetufan/beste-store/pkg/pagination"
+	"github.com/bestetufan/beste-store/pkg/spreadsheet"
 	"github.com/gin-gonic/gin"
 )
 
 type (
 	Category struct {
-		storeService service.StoreService
-		logger       logger.Logger
+		storeService        service.StoreService
+		localizationService service.LocalizationService
+		logger              logger.Logger
 	}
 
 	createCategoryRequest struct {
-		Name string `json:"name" binding:"required"`
+		Name       string `json:"name" binding:"required"`
+		TracksLots bool   `json:"tracks_lots"`
 	}
 
 	categoryResponse struct {
-		ID   uint32 `json:"id"`
-		Name string `json:"name"`
+		ID         uint32 `json:"id"`
+		Name       string `json:"name"`
+		TracksLots bool   `json:"tracks_lots"`
 	}
 
-	createBulkCategoryResponse struct {
-		Added    int `json:"added_count"`
-		Existing int `json:"existing_count"`
+	createAttributeRequest struct {
+		Code         string   `json:"code" binding:"required"`
+		Name         string   `json:"name" binding:"required"`
+		Type         string   `json:"type" binding:"required" enums:"text,number,enum,boolean"`
+		Unit         string   `json:"unit"`
+		Options      []string `json:"options"`
+		IsRequired   bool     `json:"is_required"`
+		IsFilterable bool     `json:"is_filterable"`
 	}
 )
 
-func NewCategory(cs service.StoreService, l logger.Logger) *Category {
-	return &Category{cs, l}
+func NewCategory(cs service.StoreService, ls service.LocalizationService, l logger.Logger) *Category {
+	return &Category{cs, ls, l}
 }
 
 // getAllCategories godoc
@@ -43,6 +54,8 @@ func NewCategory(cs service.StoreService, l logger.Logger) *Category {
 // @Produce      json
 // @Param page query int false "Page Index"
 // @Param pageSize query int false "Page Size"
+// @Param lang query string false "Locale of names"
+// @Param Accept-Language header string false "Locales of names by preference"
 // @Success 200 {object} pagination.Pages
 // @Failure 400 {object} response
 // @Failure 500 {object} response
@@ -51,6 +64,7 @@ func NewCategory(cs service.StoreService, l logger.Logger) *Category {
 func (c *Category) GetAllCategories(g *gin.Context) {
 	pageIndex, pageSize := pagination.GetPaginationParametersFromRequest(g)
 	items, count := c.storeService.GetAllCategories(pageIndex, pageSize, true)
+	c.localizationService.TranslateCategories(items, requestLocale(g, c.localizationService))
 	paginatedResult := pagination.NewFromGinRequest(g, count)
 	paginatedResult.Items = items
 
@@ -63,6 +77,8 @@ func (c *Category) GetAllCategories(g *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Param        id path int true "Category ID"
+// @Param lang query string false "Locale of names"
+// @Param Accept-Language header string false "Locales of names by preference"
 // @Success 200 {object} categoryResponse
 // @Failure 400 {object} response
 // @Failure 404 {object} response
@@ -80,12 +96,14 @@ func (c *Category) GetCategory(g *gin.Context) {
 		errorResponse(g, http.StatusNotFound, "no record found")
 		return
 	}
+	c.localizationService.TranslateCategory(category, requestLocale(g, c.localizationService))
 
-	g.JSON(http.StatusOK, categoryResponse{ID: category.ID, Name: category.Name})
+	g.JSON(http.StatusOK, categoryResponse{ID: category.ID, Name: category.Name, TracksLots: category.TracksLots})
 }
 
 // createCategory godoc
-// @Description  Creates a new category.
+// @Description  Creates a new category. Stock of products in categories tracking lots is kept in lots with an
+// @Description  expiry date.
 // @Tags         Category
 // @Accept       json
 // @Produce      json
@@ -104,6 +122,7 @@ func (c *Category) CreateCategory(g *gin.Context) {
 	}
 
 	category := entity.NewCategory(req.Name, true)
+	category.TracksLots = req.TracksLots
 	err := c.storeService.CreateCategory(category)
 	if err != nil {
 		c.logger.Error(err, "http - v1 - createCategory")
@@ -111,54 +130,188 @@ func (c *Category) CreateCategory(g *gin.Context) {
 		return
 	}
 
-	g.JSON(http.StatusOK, categoryResponse{ID: category.ID, Name: category.Name})
+	g.JSON(http.StatusOK, categoryResponse{ID: category.ID, Name: category.Name, TracksLots: category.TracksLots})
 }
 
 // createBulkCategory godoc
-// @Description  Creates new categories in bulk.
+// @Description  Creates new categories in bulk from a CSV or XLSX file and reports the outcome of every row.
+// @Description  Delimiter, header row and encoding (UTF-8, UTF-16 or Windows-1254) of CSV files are detected.
 // @Tags         Category
-// @Accept       json
+// @Accept       multipart/form-data
 // @Produce      json
 // @Param   	 file formData file true "Category CSV"
-// @Success 200 {object} nil
-// @Failure 400 {object} nil
-// @Failure 500 {object} nil
+// @Param   	 update_existing formData bool false "Update active flag of existing categories"
+// @Param   	 dry_run formData bool false "Validate only"
+// @Param   	 atomic formData bool false "Reject the whole file if any row is invalid"
+// @Success 200 {object} service.ImportReport
+// @Failure 400 {object} response
+// @Failure 500 {object} response
 // @Router /category/bulk [post]
 // @Security Bearer
 func (c *Category) CreateBulkCategory(g *gin.Context) {
-
 	file, fileHead, err := g.Request.FormFile("file")
 	if err != nil {
 		c.logger.Error(err, "http - v1 - createBulkCategory")
 		errorResponse(g, http.StatusBadRequest, err.Error())
-
 		return
 	}
+	defer file.Close()
 
-	contentType := fileHead.Header.Values("Content-Type")
-	if contentType[0] != "text/csv" {
+	ext := strings.ToLower(filepath.Ext(fileHead.Filename))
+	if ext != ".csv" && ext != ".txt" && ext != ".xlsx" {
 		errorResponse(g, http.StatusBadRequest, "invalid file type")
+		return
+	}
+
+	updateExisting, _ := strconv.ParseBool(g.PostForm("update_existing"))
+	dryRun, _ := strconv.ParseBool(g.PostForm("dry_run"))
+	atomic, _ := strconv.ParseBool(g.PostForm("atomic"))
+
+	rows, err := spreadsheet.Read(file, fileHead.Filename)
+	if err != nil {
+		c.logger.Error(err, "http - v1 - createBulkCategory")
+		errorResponse(g, http.StatusBadRequest, "unable to read file")
+		return
+	}
+
+	report, err := c.storeService.CreateBulkCategory(rows, updateExisting, dryRun, atomic)
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	g.JSON(http.StatusOK, report)
+}
+
+// getCategoryAttributes godoc
+// @Description  Returns the attribute schema of a category.
+// @Tags         Category
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Category ID"
+// @Success 200 {array} entity.Attribute
+// @Failure 400 {object} response
+// @Failure 404 {object} response
+// @Router /category/{id}/attribute [get]
+// @Security Bearer
+func (c *Category) GetCategoryAttributes(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get parameters")
+		return
+	}
+
+	category := c.storeService.GetCategory(uint32(id))
+	if category == nil {
+		errorResponse(g, http.StatusNotFound, "no record found")
+		return
+	}
+
+	g.JSON(http.StatusOK, c.storeService.GetCategoryAttributes(category.ID))
+}
+
+// createAttribute godoc
+// @Description  Adds an attribute definition to the schema of a category.
+// @Tags         Category
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Category ID"
+// @Param data body createAttributeRequest true "Create Attribute Model"
+// @Success 200 {object} entity.Attribute
+// @Failure 400 {object} response
+// @Failure 500 {object} response
+// @Router /category/{id}/attribute [post]
+// @Security Bearer
+func (c *Category) CreateAttribute(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get parameters")
+		return
+	}
+
+	var req createAttributeRequest
+	if err := g.ShouldBind(&req); err != nil {
+		c.logger.Error(err, "http - v1 - createAttribute")
+		errorResponse(g, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	attribute, err := entity.NewAttribute(uint32(id), req.Code, req.Name, req.Type, req.Unit,
+		req.Options, req.IsRequired, req.IsFilterable)
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = c.storeService.CreateAttribute(attribute)
+	if err != nil {
+		c.logger.Error(err, "http - v1 - createAttribute")
+		errorResponse(g, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	g.JSON(http.StatusOK, attribute)
+}
+
+// deleteAttribute godoc
+// @Description  Removes an attribute definition and its product values.
+// @Tags         Category
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Category ID"
+// @Param        attributeId path int true "Attribute ID"
+// @Success 200 {object} response
+// @Failure 400 {object} response
+// @Failure 500 {object} response
+// @Router /category/{id}/attribute/{attributeId} [delete]
+// @Security Bearer
+func (c *Category) DeleteAttribute(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get parameters")
+		return
 	}
 
-	added, existing, err := c.storeService.CreateBulkCategory(file)
+	attributeId, err := strconv.Atoi(g.Param("attributeId"))
 	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get parameters")
+		return
+	}
+
+	err = c.storeService.DeleteAttribute(uint32(id), uint32(attributeId))
+	if err != nil {
+		c.logger.Error(err, "http - v1 - deleteAttribute")
 		errorResponse(g, http.StatusInternalServerError, err.Error())
+		return
 	}
 
-	g.JSON(http.StatusOK, createBulkCategoryResponse{Added: added, Existing: existing})
+	successResponse(g, http.StatusOK, "Operation completed successfully.")
 }
 
 // deleteCategory godoc
-// @Description  Deletes a category.
+// @Description  Moves a category to the trash. Categories with products can not be deleted.
 // @Tags         Category
 // @Accept       json
 // @Produce      json
 // @Param        id path int true "Category ID"
-// @Success 200 {object} nil
-// @Failure 400 {object} nil
-// @Failure 500 {object} nil
-// @Router /category [delete]
+// @Success 200 {object} response
+// @Failure 400 {object} response
+// @Failure 500 {object} response
+// @Router /category/{id} [delete]
 // @Security Bearer
 func (c *Category) DeleteCategory(g *gin.Context) {
-	c.logger.Fatal("unimplemented exception")
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get id")
+		return
+	}
+
+	err = c.storeService.DeleteCategory(uint32(id))
+	if err != nil {
+		c.logger.Error(err, "http - v1 - deleteCategory")
+		errorResponse(g, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	successResponse(g, http.StatusOK, "Operation completed successfully.")
 }
@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+
+	"github.com/bestetufan/beste-store/internal/service"
+	"github.com/bestetufan/beste-store/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+type Download struct {
+	downloadService service.DownloadService
+	logger          logger.Logger
+}
+
+func NewDownload(ds service.DownloadService, l logger.Logger) *Download {
+	return &Download{ds, l}
+}
+
+// downloadFile godoc
+// @Description  Downloads the file of a digital product through the signed link of an order.
+// @Description  Links expire and can only be used a limited number of times.
+// @Tags         Download
+// @Produce      octet-stream
+// @Param        id path string true "Download ID"
+// @Param        expires query int true "Expiry of the link in Unix time"
+// @Param        signature query string true "Signature of the link"
+// @Success 200 {file} file
+// @Failure 403 {object} response
+// @Failure 404 {object} response
+// @Failure 410 {object} response
+// @Router /download/{id} [get]
+func (c *Download) DownloadFile(g *gin.Context) {
+	file, content, err := c.downloadService.Open(g.Param("id"), g.Query("expires"), g.Query("signature"))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrDownloadInvalid), errors.Is(err, service.ErrDownloadLimit):
+			errorResponse(g, http.StatusForbidden, err.Error())
+		case errors.Is(err, service.ErrDownloadExpired):
+			errorResponse(g, http.StatusGone, err.Error())
+		default:
+			c.logger.Error(err, "http - v1 - downloadFile")
+			errorResponse(g, http.StatusNotFound, err.Error())
+		}
+		return
+	}
+	defer content.Close()
+
+	g.DataFromReader(http.StatusOK, file.Size, file.ContentType, content, map[string]string{
+		"Content-Disposition": mime.FormatMediaType("attachment", map[string]string{"filename": file.FileName}),
+	})
+}
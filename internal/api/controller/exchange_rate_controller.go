@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/bestetufan/beste-store/internal/service"
+	"github.com/bestetufan/beste-store/pkg/logger"
+	"github.com/bestetufan/beste-store/pkg/spreadsheet"
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	ExchangeRate struct {
+		exchangeService service.ExchangeService
+		logger          logger.Logger
+	}
+
+	setExchangeRateRequest struct {
+		Rate string `json:"rate" binding:"required" example:"35.2"`
+	}
+)
+
+func NewExchangeRate(es service.ExchangeService, l logger.Logger) *ExchangeRate {
+	return &ExchangeRate{es, l}
+}
+
+// getExchangeRates godoc
+// @Description  Returns the exchange rates, each the value of one unit of the currency in the default currency.
+// @Tags         ExchangeRate
+// @Accept       json
+// @Produce      json
+// @Success 200 {array} entity.ExchangeRate
+// @Router /exchange-rate [get]
+// @Security Bearer
+func (c *ExchangeRate) GetExchangeRates(g *gin.Context) {
+	g.JSON(http.StatusOK, c.exchangeService.GetRates())
+}
+
+// setExchangeRate godoc
+// @Description  Creates or replaces the exchange rate of a currency.
+// @Tags         ExchangeRate
+// @Accept       json
+// @Produce      json
+// @Param        currency path string true "ISO 4217 Currency Code"
+// @Param data body setExchangeRateRequest true "Exchange Rate Model"
+// @Success 200 {object} entity.ExchangeRate
+// @Failure 400 {object} response
+// @Failure 500 {object} response
+// @Router /exchange-rate/{currency} [put]
+// @Security Bearer
+func (c *ExchangeRate) SetExchangeRate(g *gin.Context) {
+	var req setExchangeRateRequest
+	if err := g.ShouldBind(&req); err != nil {
+		c.logger.Error(err, "http - v1 - setExchangeRate")
+		errorResponse(g, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	rate, err := c.exchangeService.SetRate(g.Param("currency"), req.Rate, g.GetString("Email"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	g.JSON(http.StatusOK, rate)
+}
+
+// deleteExchangeRate godoc
+// @Description  Removes the exchange rate of a currency. Prices can no longer be asked in it.
+// @Tags         ExchangeRate
+// @Accept       json
+// @Produce      json
+// @Param        currency path string true "ISO 4217 Currency Code"
+// @Success 200 {object} response
+// @Failure 404 {object} response
+// @Router /exchange-rate/{currency} [delete]
+// @Security Bearer
+func (c *ExchangeRate) DeleteExchangeRate(g *gin.Context) {
+	err := c.exchangeService.DeleteRate(g.Param("currency"))
+	if err != nil {
+		errorResponse(g, http.StatusNotFound, err.Error())
+		return
+	}
+
+	successResponse(g, http.StatusOK, "Operation completed successfully.")
+}
+
+// importExchangeRates godoc
+// @Description  Creates or replaces exchange rates from a CSV or XLSX file with currency and rate columns.
+// @Tags         ExchangeRate
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param   	 file formData file true "Exchange Rate CSV or XLSX"
+// @Param   	 dry_run formData bool false "Validate only"
+// @Param   	 atomic formData bool false "Reject the whole file if any row is invalid"
+// @Success 200 {object} service.ImportReport
+// @Failure 400 {object} response
+// @Router /exchange-rate/bulk [post]
+// @Security Bearer
+func (c *ExchangeRate) ImportExchangeRates(g *gin.Context) {
+	file, fileHead, err := g.Request.FormFile("file")
+	if err != nil {
+		c.logger.Error(err, "http - v1 - importExchangeRates")
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer file.Close()
+
+	dryRun, _ := strconv.ParseBool(g.PostForm("dry_run"))
+	atomic, _ := strconv.ParseBool(g.PostForm("atomic"))
+
+	rows, err := spreadsheet.Read(file, fileHead.Filename)
+	if err != nil {
+		c.logger.Error(err, "http - v1 - importExchangeRates")
+		errorResponse(g, http.StatusBadRequest, "unable to read file")
+		return
+	}
+
+	report, err := c.exchangeService.ImportRates(rows, dryRun, atomic, g.GetString("Email"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	g.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"github.com/bestetufan/beste-store/internal/service"
+	"github.com/bestetufan/beste-store/pkg/logger"
+	"github.com/bestetufan/beste-store/pkg/spreadsheet"
+	"github.com/gin-gonic/gin"
+)
+
+type Export struct {
+	storeService service.StoreService
+	logger       logger.Logger
+}
+
+func NewExport(cs service.StoreService, l logger.Logger) *Export {
+	return &Export{cs, l}
+}
+
+// exportProducts godoc
+// @Description  Streams all products matching the filters as a file.
+// @Description  Attribute filters are given as attr[code]=value1,value2 or attr[code]=min..max for numbers.
+// @Description  Attribute values can be exported with attr.<code> columns.
+// @Tags         Export
+// @Produce      text/csv,application/x-ndjson,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "File Format" Enums(csv, ndjson, xlsx)
+// @Param columns query string false "Comma separated column names"
+// @Param category_id query int false "Category ID"
+// @Param attr query object false "Attribute Filters"
+// @Param status query string false "Comma separated statuses"
+// @Success 200 {file} file
+// @Failure 400 {object} response
+// @Router /export/product [get]
+// @Security Bearer
+func (c *Export) ExportProducts(g *gin.Context) {
+	var filter entity.ProductFilter
+	if categoryId := g.Query("category_id"); len(categoryId) > 0 {
+		id, err := strconv.Atoi(categoryId)
+		if err != nil {
+			errorResponse(g, http.StatusBadRequest, "unable to get parameters")
+			return
+		}
+		filter.CategoryID = uint32(id)
+	}
+	for code, value := range g.QueryMap("attr") {
+		filter.Attributes = append(filter.Attributes, entity.NewAttributeFilter(code, value))
+	}
+	statuses, err := productStatuses(g)
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter.Statuses = statuses
+
+	c.export(g, "products", func(w spreadsheet.Writer, columns []string) error {
+		return c.storeService.ExportProducts(w, filter, columns)
+	})
+}
+
+// exportCategories godoc
+// @Description  Streams all categories as a file.
+// @Tags         Export
+// @Produce      text/csv,application/x-ndjson,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "File Format" Enums(csv, ndjson, xlsx)
+// @Param columns query string false "Comma separated column names"
+// @Param active query bool false "Only Active Categories"
+// @Success 200 {file} file
+// @Failure 400 {object} response
+// @Router /export/category [get]
+// @Security Bearer
+func (c *Export) ExportCategories(g *gin.Context) {
+	onlyActives, err := strconv.ParseBool(g.DefaultQuery("active", "false"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get parameters")
+		return
+	}
+
+	c.export(g, "categories", func(w spreadsheet.Writer, columns []string) error {
+		return c.storeService.ExportCategories(w, onlyActives, columns)
+	})
+}
+
+// exportOrders godoc
+// @Description  Streams all orders matching the filters as a file. Payment details are never exported.
+// @Tags         Export
+// @Produce      text/csv,application/x-ndjson,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "File Format" Enums(csv, ndjson, xlsx)
+// @Param columns query string false "Comma separated column names"
+// @Param user_name query string false "User Name"
+// @Param status query string false "Order Status"
+// @Success 200 {file} file
+// @Failure 400 {object} response
+// @Router /export/order [get]
+// @Security Bearer
+func (c *Export) ExportOrders(g *gin.Context) {
+	userName, status := g.Query("user_name"), g.Query("status")
+
+	c.export(g, "orders", func(w spreadsheet.Writer, columns []string) error {
+		return c.storeService.ExportOrders(w, userName, status, columns)
+	})
+}
+
+// export sets the download headers and runs fn against the response. Errors
+// raised before the first byte is written (e.g. unknown columns) are
+// reported as JSON; later ones can only end the stream early.
+func (c *Export) export(g *gin.Context, name string, fn func(w spreadsheet.Writer, columns []string) error) {
+	w, err := spreadsheet.NewWriter(g.DefaultQuery("format", spreadsheet.FormatCSV), g.Writer)
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var columns []string
+	if value := g.Query("columns"); len(value) > 0 {
+		for _, column := range strings.Split(value, ",") {
+			columns = append(columns, strings.TrimSpace(column))
+		}
+	}
+
+	fileName := name + "-" + time.Now().Format("20060102-150405") + w.Extension()
+	g.Header("Content-Type", w.ContentType())
+	g.Header("Content-Disposition", `attachment; filename="`+fileName+`"`)
+
+	if err := fn(w, columns); err != nil {
+		c.logger.Error(err, "http - v1 - export")
+		if !g.Writer.Written() {
+			g.Writer.Header().Del("Content-Type")
+			g.Writer.Header().Del("Content-Disposition")
+			errorResponse(g, http.StatusBadRequest, err.Error())
+		}
+	}
+}
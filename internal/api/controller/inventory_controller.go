@@ -0,0 +1,314 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"github.com/bestetufan/beste-store/internal/service"
+	"github.com/bestetufan/beste-store/pkg/logger"
+	"github.com/bestetufan/beste-store/pkg/measure"
+	"github.com/bestetufan/beste-store/pkg/pagination"
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	Inventory struct {
+		inventoryService  service.InventoryService
+		stockAlertService service.StockAlertService
+		logger            logger.Logger
+	}
+
+	createWarehouseRequest struct {
+		Code string `json:"code" binding:"required" example:"IST"`
+		Name string `json:"name" binding:"required" example:"Istanbul"`
+	}
+
+	createStockAdjustmentRequest struct {
+		ProductID   uint32           `json:"product_id" binding:"required"`
+		WarehouseID uint32           `json:"warehouse_id"`
+		Quantity    measure.Quantity `json:"quantity" binding:"required" example:"-2" swaggertype:"number"`
+		LotNumber   string           `json:"lot_number" example:"L2410-07"`
+		ExpiresAt   string           `json:"expires_at" example:"2026-12-31"`
+		Serials     []string         `json:"serials"`
+		Note        string           `json:"note" example:"damaged in storage"`
+	}
+)
+
+func NewInventory(is service.InventoryService, as service.StockAlertService, l logger.Logger) *Inventory {
+	return &Inventory{is, as, l}
+}
+
+// getWarehouses godoc
+// @Description  Returns all warehouses.
+// @Tags         Inventory
+// @Accept       json
+// @Produce      json
+// @Success 200 {array} entity.Warehouse
+// @Router /warehouse [get]
+// @Security Bearer
+func (c *Inventory) GetWarehouses(g *gin.Context) {
+	g.JSON(http.StatusOK, c.inventoryService.GetWarehouses())
+}
+
+// createWarehouse godoc
+// @Description  Creates a warehouse. The first warehouse becomes the default one.
+// @Tags         Inventory
+// @Accept       json
+// @Produce      json
+// @Param data body createWarehouseRequest true "Create Warehouse Model"
+// @Success 200 {object} entity.Warehouse
+// @Failure 400 {object} response
+// @Router /warehouse [post]
+// @Security Bearer
+func (c *Inventory) CreateWarehouse(g *gin.Context) {
+	var req createWarehouseRequest
+	if err := g.ShouldBind(&req); err != nil {
+		c.logger.Error(err, "http - v1 - createWarehouse")
+		errorResponse(g, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	warehouse, err := entity.NewWarehouse(req.Code, req.Name)
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := c.inventoryService.CreateWarehouse(warehouse); err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	g.JSON(http.StatusOK, warehouse)
+}
+
+// setDefaultWarehouse godoc
+// @Description  Makes a warehouse the default one, which receives stock given without a warehouse.
+// @Tags         Inventory
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Warehouse ID"
+// @Success 200 {object} response
+// @Failure 400 {object} response
+// @Failure 404 {object} response
+// @Router /warehouse/{id}/default [patch]
+// @Security Bearer
+func (c *Inventory) SetDefaultWarehouse(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get id")
+		return
+	}
+
+	if err := c.inventoryService.SetDefaultWarehouse(uint32(id)); err != nil {
+		errorResponse(g, http.StatusNotFound, err.Error())
+		return
+	}
+
+	successResponse(g, http.StatusOK, "Operation completed successfully.")
+}
+
+// getStockLevels godoc
+// @Description  Returns the on-hand quantity of a product in each warehouse.
+// @Tags         Inventory
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Product ID"
+// @Success 200 {array} entity.StockLevel
+// @Failure 400 {object} response
+// @Router /product/{id}/stock [get]
+// @Security Bearer
+func (c *Inventory) GetStockLevels(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get parameters")
+		return
+	}
+
+	g.JSON(http.StatusOK, c.inventoryService.GetStockLevels(uint32(id)))
+}
+
+// getStockLots godoc
+// @Description  Returns the lots of a product with stock left, by warehouse and expiry date.
+// @Tags         Inventory
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Product ID"
+// @Success 200 {array} entity.StockLot
+// @Failure 400 {object} response
+// @Router /product/{id}/lot [get]
+// @Security Bearer
+func (c *Inventory) GetStockLots(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get parameters")
+		return
+	}
+
+	g.JSON(http.StatusOK, c.inventoryService.GetLots(uint32(id)))
+}
+
+// getSerialNumbers godoc
+// @Description  Returns the serial numbers of a product with pagination, newest first.
+// @Tags         Inventory
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Product ID"
+// @Param page query int false "Page Index"
+// @Param pageSize query int false "Page Size"
+// @Param status query string false "Status" Enums(in_stock, sold, removed)
+// @Success 200 {object} pagination.Pages
+// @Failure 400 {object} response
+// @Router /product/{id}/serial [get]
+// @Security Bearer
+func (c *Inventory) GetSerialNumbers(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get parameters")
+		return
+	}
+
+	pageIndex, pageSize := pagination.GetPaginationParametersFromRequest(g)
+	items, count := c.inventoryService.GetSerials(uint32(id), g.Query("status"), pageIndex, pageSize)
+	paginatedResult := pagination.NewFromGinRequest(g, count)
+	paginatedResult.Items = items
+
+	g.JSON(http.StatusOK, paginatedResult)
+}
+
+// getStockMovements godoc
+// @Description  Returns the stock ledger with pagination, newest first.
+// @Tags         Inventory
+// @Accept       json
+// @Produce      json
+// @Param page query int false "Page Index"
+// @Param pageSize query int false "Page Size"
+// @Param product_id query int false "Product ID"
+// @Param warehouse_id query int false "Warehouse ID"
+// @Param type query string false "Movement Type" Enums(receipt, sale, return, adjustment, expiry, reservation)
+// @Param reference query string false "Reference, e.g. order:<id>"
+// @Success 200 {object} pagination.Pages
+// @Failure 400 {object} response
+// @Router /stock/movement [get]
+// @Security Bearer
+func (c *Inventory) GetStockMovements(g *gin.Context) {
+	filter := entity.StockMovementFilter{Type: g.Query("type"), Reference: g.Query("reference")}
+	if len(filter.Type) > 0 && !entity.IsStockMovementType(filter.Type) {
+		errorResponse(g, http.StatusBadRequest, "unknown movement type")
+		return
+	}
+	if productId := g.Query("product_id"); len(productId) > 0 {
+		id, err := strconv.Atoi(productId)
+		if err != nil {
+			errorResponse(g, http.StatusBadRequest, "unable to get parameters")
+			return
+		}
+		filter.ProductID = uint32(id)
+	}
+	if warehouseId := g.Query("warehouse_id"); len(warehouseId) > 0 {
+		id, err := strconv.Atoi(warehouseId)
+		if err != nil {
+			errorResponse(g, http.StatusBadRequest, "unable to get parameters")
+			return
+		}
+		filter.WarehouseID = uint32(id)
+	}
+
+	pageIndex, pageSize := pagination.GetPaginationParametersFromRequest(g)
+	items, count := c.inventoryService.GetMovements(filter, pageIndex, pageSize)
+	paginatedResult := pagination.NewFromGinRequest(g, count)
+	paginatedResult.Items = items
+
+	g.JSON(http.StatusOK, paginatedResult)
+}
+
+// getLowStock godoc
+// @Description  Returns the products that are out of stock or at or below their reorder point with pagination,
+// @Description  emptiest first. Archived products are left out.
+// @Tags         Inventory
+// @Accept       json
+// @Produce      json
+// @Param page query int false "Page Index"
+// @Param pageSize query int false "Page Size"
+// @Success 200 {object} pagination.Pages
+// @Router /stock/low [get]
+// @Security Bearer
+func (c *Inventory) GetLowStock(g *gin.Context) {
+	pageIndex, pageSize := pagination.GetPaginationParametersFromRequest(g)
+	items, count := c.stockAlertService.GetLowStock(pageIndex, pageSize)
+	paginatedResult := pagination.NewFromGinRequest(g, count)
+	paginatedResult.Items = items
+
+	g.JSON(http.StatusOK, paginatedResult)
+}
+
+// getExpiringLots godoc
+// @Description  Returns the lots with stock left that expire within the given number of days with pagination,
+// @Description  first expiring first. Lots expire when their expiry date is over; their stock is written off.
+// @Tags         Inventory
+// @Accept       json
+// @Produce      json
+// @Param page query int false "Page Index"
+// @Param pageSize query int false "Page Size"
+// @Param days query int false "Days, 30 by default"
+// @Success 200 {object} pagination.Pages
+// @Failure 400 {object} response
+// @Router /stock/lot/expiring [get]
+// @Security Bearer
+func (c *Inventory) GetExpiringLots(g *gin.Context) {
+	days := 30
+	if value := g.Query("days"); len(value) > 0 {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			errorResponse(g, http.StatusBadRequest, "unable to get parameters")
+			return
+		}
+		days = n
+	}
+
+	pageIndex, pageSize := pagination.GetPaginationParametersFromRequest(g)
+	items, count := c.inventoryService.GetExpiringLots(days, pageIndex, pageSize)
+	paginatedResult := pagination.NewFromGinRequest(g, count)
+	paginatedResult.Items = items
+
+	g.JSON(http.StatusOK, paginatedResult)
+}
+
+// createStockAdjustment godoc
+// @Description  Adds (positive quantity) or removes (negative quantity) stock of a product in a warehouse,
+// @Description  or in the default warehouse when no warehouse is given. Stock added to products of categories
+// @Description  tracking lots needs a lot number, and an expiry date when the lot is new; stock removed without a
+// @Description  lot number is taken from the units not in any lot. Units of serialized products added or removed are
+// @Description  given by their serial numbers.
+// @Tags         Inventory
+// @Accept       json
+// @Produce      json
+// @Param data body createStockAdjustmentRequest true "Stock Adjustment Model"
+// @Success 200 {object} entity.StockMovement
+// @Failure 400 {object} response
+// @Router /stock/adjustment [post]
+// @Security Bearer
+func (c *Inventory) CreateStockAdjustment(g *gin.Context) {
+	var req createStockAdjustmentRequest
+	if err := g.ShouldBind(&req); err != nil {
+		c.logger.Error(err, "http - v1 - createStockAdjustment")
+		errorResponse(g, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	expiresAt, err := expiryDate(req.ExpiresAt)
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	movement, err := c.inventoryService.Adjust(req.ProductID, req.WarehouseID, req.Quantity, req.LotNumber,
+		expiresAt, req.Serials, req.Note, g.GetString("Email"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	g.JSON(http.StatusOK, movement)
+}
@@ -11,34 +11,48 @@ import (
 
 type (
 	Order struct {
-		storeService service.StoreService
-		logger       logger.Logger
+		storeService    service.StoreService
+		exchangeService service.ExchangeService
+		logger          logger.Logger
 	}
 
 	newOrderRequest struct {
 		Name        string `json:"name" binding:"required"`
-		Address     string `json:"address" binding:"required"`
+		Address     string `json:"address"`
 		PhoneNumber string `json:"phone_number" binding:"required"`
 		CardNumber  string `json:"card_number" binding:"required"`
 		CardExp     string `json:"card_exp" binding:"required"`
 		CardCVV     int    `json:"card_cvv" binding:"required"`
 	}
 
+	fulfillOrderItemRequest struct {
+		ProductID uint32   `json:"product_id" binding:"required"`
+		Serials   []string `json:"serials" example:"490154203237518"`
+	}
+
+	fulfillOrderRequest struct {
+		Items []fulfillOrderItemRequest `json:"items"`
+	}
+
 	orderResponse struct {
 		ID    string              `json:"id"`
 		Items []*entity.OrderItem `json:"items"`
 	}
 )
 
-func NewOrder(cs service.StoreService, l logger.Logger) *Order {
-	return &Order{cs, l}
+func NewOrder(cs service.StoreService, es service.ExchangeService, l logger.Logger) *Order {
+	return &Order{cs, es, l}
 }
 
 // getAllOrders godoc
 // @Description  Returns user's orders.
+// @Description  Item prices are in the currency the order was charged in, at the exchange_rate used when it was placed;
+// @Description  the currency asked for only applies to the current prices of the products.
 // @Tags         Order
 // @Accept       json
 // @Produce      json
+// @Param currency query string false "Currency of prices"
+// @Param X-Currency header string false "Currency of prices"
 // @Success 200 {object} orderResponse
 // @Failure 400 {object} response
 // @Failure 404 {object} response
@@ -52,14 +66,21 @@ func (c *Order) GetAllOrders(g *gin.Context) {
 	}
 
 	orders := c.storeService.GetAllOrders(userName)
+	if err := c.exchangeService.ConvertOrders(orders, requestCurrency(g)); err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
 	g.JSON(http.StatusOK, orders)
 }
 
 // createOrder godoc
-// @Description  Creates an order with basket items.
+// @Description  Creates an order with basket items, charged in the currency asked for or in the currency of the products.
+// @Description  The address is required unless all items are digital; orders of digital products only are delivered at once and come with download links.
 // @Tags         Order
 // @Accept       json
 // @Produce      json
+// @Param currency query string false "Currency of prices"
+// @Param X-Currency header string false "Currency of prices"
 // @Param data body newOrderRequest true "New Order Model"
 // @Success 200 {object} response
 // @Failure 400 {object} response
@@ -81,7 +102,7 @@ func (c *Order) CreateOrder(g *gin.Context) {
 	}
 
 	err := c.storeService.CreateOrder(userName, req.Name, req.Address, req.PhoneNumber,
-		req.CardNumber, req.CardExp, req.CardCVV)
+		req.CardNumber, req.CardExp, req.CardCVV, requestCurrency(g))
 	if err != nil {
 		errorResponse(g, http.StatusNotFound, err.Error())
 		return
@@ -91,7 +112,8 @@ func (c *Order) CreateOrder(g *gin.Context) {
 }
 
 // cancelOrder godoc
-// @Description  Cancels an order.
+// @Description  Cancels an order within 14 days unless it is fulfilled or delivered; orders of digital products only
+// @Description  are delivered at once and can not be canceled.
 // @Tags         Order
 // @Accept       json
 // @Produce      json
@@ -122,3 +144,80 @@ func (c *Order) CancelOrder(g *gin.Context) {
 
 	successResponse(g, http.StatusOK, "Operation completed successfully.")
 }
+
+// fulfillOrder godoc
+// @Description  Fulfills an order, assigning units of its serialized products to its items by serial number. Every
+// @Description  item of a serialized product needs the serial numbers of all its units; IMEIs are checked.
+// @Tags         Order
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Order ID"
+// @Param data body fulfillOrderRequest false "Fulfill Order Model"
+// @Success 200 {object} entity.Order
+// @Failure 400 {object} response
+// @Router /order/{id}/fulfill [patch]
+// @Security Bearer
+func (c *Order) FulfillOrder(g *gin.Context) {
+	var req fulfillOrderRequest
+	if g.Request.ContentLength != 0 {
+		if err := g.ShouldBind(&req); err != nil {
+			c.logger.Error(err, "http - v1 - fulfillOrder")
+			errorResponse(g, http.StatusBadRequest, "invalid request body")
+			return
+		}
+	}
+
+	serials := make(map[uint32][]string, len(req.Items))
+	for _, item := range req.Items {
+		serials[item.ProductID] = append(serials[item.ProductID], item.Serials...)
+	}
+
+	order, err := c.storeService.FulfillOrder(g.Param("id"), serials)
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	g.JSON(http.StatusOK, order)
+}
+
+// deliverOrder godoc
+// @Description  Marks a fulfilled order delivered once the customer has received it. Products are reviewed after the
+// @Description  orders containing them are delivered.
+// @Tags         Order
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Order ID"
+// @Success 200 {object} entity.Order
+// @Failure 400 {object} response
+// @Router /order/{id}/deliver [patch]
+// @Security Bearer
+func (c *Order) DeliverOrder(g *gin.Context) {
+	order, err := c.storeService.DeliverOrder(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	g.JSON(http.StatusOK, order)
+}
+
+// getOrderBySerial godoc
+// @Description  Returns the order a unit was sold for last by its serial number or IMEI, for after-sales support.
+// @Tags         Order
+// @Accept       json
+// @Produce      json
+// @Param        serial path string true "Serial Number or IMEI"
+// @Success 200 {object} entity.Order
+// @Failure 404 {object} response
+// @Router /order/serial/{serial} [get]
+// @Security Bearer
+func (c *Order) GetOrderBySerial(g *gin.Context) {
+	order := c.storeService.GetOrderBySerial(g.Param("serial"))
+	if order == nil {
+		errorResponse(g, http.StatusNotFound, "no record found")
+		return
+	}
+
+	g.JSON(http.StatusOK, order)
+}
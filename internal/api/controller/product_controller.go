@@ -1,83 +1,267 @@
 package controller
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/bestetufan/beste-store/internal/domain/entity"
 	"github.com/bestetufan/beste-store/internal/service"
+	"github.com/bestetufan/beste-store/pkg/barcode"
 	"github.com/bestetufan/beste-store/pkg/logger"
+	"github.com/bestetufan/beste-store/pkg/measure"
+	"github.com/bestetufan/beste-store/pkg/money"
 	"github.com/bestetufan/beste-store/pkg/pagination"
+	"github.com/bestetufan/beste-store/pkg/spreadsheet"
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	// maxLabelProducts limits the products labels are printed for in one request.
+	maxLabelProducts = 100
+	// maxLabelCopies limits the labels printed per product in one request.
+	maxLabelCopies = 100
+)
+
 type (
 	Product struct {
-		storeService service.StoreService
-		logger       logger.Logger
+		storeService          service.StoreService
+		mediaService          service.MediaService
+		downloadService       service.DownloadService
+		recommendationService service.RecommendationService
+		exchangeService       service.ExchangeService
+		localizationService   service.LocalizationService
+		logger                logger.Logger
 	}
 
 	createProductRequest struct {
-		Name       string  `json:"name" binding:"required"`
-		Sku        string  `json:"sku" binding:"required"`
-		UnitPrice  float64 `json:"unit_price" binding:"required"`
-		Quantity   int     `json:"quantity" binding:"required"`
-		CategoryID uint32  `json:"category_id" binding:"required"`
+		Name            string            `json:"name" binding:"required"`
+		Sku             string            `json:"sku" binding:"required"`
+		GTIN            string            `json:"gtin" example:"4006381333931"`
+		UnitPrice       json.Number       `json:"unit_price" binding:"required" swaggertype:"number"`
+		Currency        string            `json:"currency"`
+		Unit            string            `json:"unit" enums:"piece,kg,g,l,m" example:"piece"`
+		QuantityStep    measure.Quantity  `json:"quantity_step" swaggertype:"number" example:"1"`
+		MinQuantity     measure.Quantity  `json:"min_quantity" swaggertype:"number" example:"1"`
+		Quantity        measure.Quantity  `json:"quantity" swaggertype:"number"`
+		ReorderPoint    measure.Quantity  `json:"reorder_point" swaggertype:"number"`
+		ReorderQuantity measure.Quantity  `json:"reorder_quantity" swaggertype:"number"`
+		SerialType      string            `json:"serial_type" enums:"serial,imei"`
+		IsDigital       bool              `json:"is_digital"`
+		CategoryID      uint32            `json:"category_id" binding:"required"`
+		Attributes      map[string]string `json:"attributes"`
+		Status          string            `json:"status" example:"draft"`
+		PublishAt       *time.Time        `json:"publish_at"`
+	}
+
+	setProductStatusRequest struct {
+		Status    string     `json:"status" binding:"required" example:"scheduled"`
+		PublishAt *time.Time `json:"publish_at"`
 	}
 
 	updateProductRequest struct {
-		Name      string  `json:"name" binding:"required"`
-		UnitPrice float64 `json:"unit_price" binding:"required"`
-		Quantity  int     `json:"quantity" binding:"required"`
+		Name            string            `json:"name" binding:"required"`
+		GTIN            *string           `json:"gtin" example:"4006381333931"`
+		UnitPrice       json.Number       `json:"unit_price" binding:"required" swaggertype:"number"`
+		Currency        string            `json:"currency"`
+		Unit            *string           `json:"unit" enums:"piece,kg,g,l,m"`
+		QuantityStep    *measure.Quantity `json:"quantity_step" swaggertype:"number"`
+		MinQuantity     *measure.Quantity `json:"min_quantity" swaggertype:"number"`
+		Quantity        *measure.Quantity `json:"quantity" swaggertype:"number"`
+		ReorderPoint    *measure.Quantity `json:"reorder_point" swaggertype:"number"`
+		ReorderQuantity *measure.Quantity `json:"reorder_quantity" swaggertype:"number"`
+		SerialType      *string           `json:"serial_type" enums:"serial,imei"`
+		IsDigital       *bool             `json:"is_digital"`
+		Attributes      map[string]string `json:"attributes"`
+	}
+
+	setProductBundleRequest struct {
+		Pricing    string                   `json:"pricing" binding:"required" enums:"fixed,discount"`
+		Discount   int                      `json:"discount" example:"10"`
+		Components []bundleComponentRequest `json:"components" binding:"required"`
+	}
+
+	bundleComponentRequest struct {
+		ProductID uint32           `json:"product_id" binding:"required"`
+		Quantity  measure.Quantity `json:"quantity" binding:"required" swaggertype:"number" example:"1"`
 	}
 
 	productResponse struct {
-		ID           uint32  `json:"id"`
-		Name         string  `json:"name"`
-		Sku          string  `json:"sku"`
-		UnitPrice    float64 `json:"unit_price"`
-		Quantity     int     `json:"quantity"`
-		CategoryID   uint32  `json:"category_id"`
-		CategoryName string  `json:"category_name"`
+		ID              uint32                     `json:"id"`
+		Name            string                     `json:"name"`
+		Sku             string                     `json:"sku"`
+		GTIN            *string                    `json:"gtin"`
+		UnitPrice       money.Money                `json:"unit_price" swaggertype:"number"`
+		SalePrice       *money.Money               `json:"sale_price" swaggertype:"number"`
+		SaleEndsAt      *time.Time                 `json:"sale_ends_at"`
+		Price           money.Money                `json:"price" swaggertype:"number"`
+		Currency        string                     `json:"currency"`
+		PriceUnit       string                     `json:"price_unit" example:"₺/kg"`
+		DisplayPrice    string                     `json:"display_price" example:"12.50 ₺/kg"`
+		Unit            string                     `json:"unit"`
+		QuantityStep    measure.Quantity           `json:"quantity_step" swaggertype:"number"`
+		MinQuantity     measure.Quantity           `json:"min_quantity" swaggertype:"number"`
+		Quantity        measure.Quantity           `json:"quantity" swaggertype:"number"`
+		Available       measure.Quantity           `json:"available" swaggertype:"number"`
+		ReorderPoint    measure.Quantity           `json:"reorder_point" swaggertype:"number"`
+		ReorderQuantity measure.Quantity           `json:"reorder_quantity" swaggertype:"number"`
+		SerialType      string                     `json:"serial_type"`
+		BundlePricing   string                     `json:"bundle_pricing"`
+		BundleDiscount  int                        `json:"bundle_discount"`
+		Components      []bundleComponentResponse  `json:"components,omitempty"`
+		IsDigital       bool                       `json:"is_digital"`
+		Rating          float64                    `json:"rating" example:"4.5"`
+		RatingCount     int                        `json:"rating_count"`
+		File            *productFileResponse       `json:"file,omitempty"`
+		CategoryID      uint32                     `json:"category_id"`
+		CategoryName    string                     `json:"category_name"`
+		Status          string                     `json:"status"`
+		PublishAt       *time.Time                 `json:"publish_at"`
+		Attributes      []productAttributeResponse `json:"attributes"`
+		Images          []productImageResponse     `json:"images"`
+	}
+
+	productImageResponse struct {
+		ID           uint32 `json:"id"`
+		URL          string `json:"url"`
+		ThumbnailURL string `json:"thumbnail_url"`
+		Width        int    `json:"width"`
+		Height       int    `json:"height"`
+		Position     int    `json:"position"`
+		IsPrimary    bool   `json:"is_primary"`
+	}
+
+	productFileResponse struct {
+		FileName    string    `json:"file_name"`
+		ContentType string    `json:"content_type"`
+		Size        int64     `json:"size"`
+		UpdatedAt   time.Time `json:"updated_at"`
+	}
+
+	createSalePriceRequest struct {
+		Price    json.Number `json:"price" binding:"required" swaggertype:"number"`
+		StartsAt time.Time   `json:"starts_at" binding:"required"`
+		EndsAt   time.Time   `json:"ends_at" binding:"required"`
+	}
+
+	reorderProductImagesRequest struct {
+		ImageIds []uint32 `json:"image_ids" binding:"required"`
+	}
+
+	bundleComponentResponse struct {
+		ProductID uint32           `json:"product_id"`
+		Sku       string           `json:"sku"`
+		Name      string           `json:"name"`
+		Quantity  measure.Quantity `json:"quantity" swaggertype:"number"`
+		Unit      string           `json:"unit"`
+		Available measure.Quantity `json:"available" swaggertype:"number"`
+	}
+
+	productAttributeResponse struct {
+		Code  string `json:"code"`
+		Name  string `json:"name"`
+		Type  string `json:"type"`
+		Value string `json:"value"`
+		Unit  string `json:"unit"`
+	}
+
+	productPagesResponse struct {
+		pagination.Pages
+		Facets []entity.Facet `json:"facets"`
+	}
+
+	relatedProductResponse struct {
+		Source  string          `json:"source" enums:"co_purchase,bestseller"`
+		Count   int             `json:"count"`
+		Product productResponse `json:"product"`
 	}
 
 	searchResponse struct {
-		Items []entity.Product `json:"items"`
-		Count int              `json:"count"`
+		Items []productResponse `json:"items"`
+		Count int               `json:"count"`
 	}
 )
 
-func NewProduct(cs service.StoreService, l logger.Logger) *Product {
-	return &Product{cs, l}
+func NewProduct(cs service.StoreService, ms service.MediaService, ds service.DownloadService,
+	rs service.RecommendationService, es service.ExchangeService, ls service.LocalizationService,
+	l logger.Logger) *Product {
+	return &Product{cs, ms, ds, rs, es, ls, l}
 }
 
 // getAllProducts godoc
-// @Description  Returns all products with pagination.
+// @Description  Returns all products with pagination and attribute facets.
+// @Description  Attribute filters are given as attr[code]=value1,value2 or attr[code]=min..max for numbers.
+// @Description  Only published products are listed, except for admins who can filter by status.
 // @Tags         Product
 // @Accept       json
 // @Produce      json
 // @Param page query int false "Page Index"
 // @Param pageSize query int false "Page Size"
-// @Success 200 {object} pagination.Pages
+// @Param category_id query int false "Category ID"
+// @Param attr query object false "Attribute Filters"
+// @Param status query string false "Comma separated statuses, admins only"
+// @Param sort query string false "Order of products, by id when not given" Enums(rating)
+// @Param currency query string false "Currency of prices"
+// @Param X-Currency header string false "Currency of prices"
+// @Param lang query string false "Locale of names"
+// @Param Accept-Language header string false "Locales of names by preference"
+// @Success 200 {object} productPagesResponse{items=[]productResponse}
 // @Failure 400 {object} response
 // @Failure 500 {object} response
 // @Router /product [get]
 // @Security Bearer
 func (c *Product) GetAllProducts(g *gin.Context) {
+	var filter entity.ProductFilter
+	if categoryId := g.Query("category_id"); len(categoryId) > 0 {
+		id, err := strconv.Atoi(categoryId)
+		if err != nil {
+			errorResponse(g, http.StatusBadRequest, "unable to get parameters")
+			return
+		}
+		filter.CategoryID = uint32(id)
+	}
+	for code, value := range g.QueryMap("attr") {
+		filter.Attributes = append(filter.Attributes, entity.NewAttributeFilter(code, value))
+	}
+	statuses, err := productStatuses(g)
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter.Statuses = statuses
+	filter.Sort = g.Query("sort")
+	if !entity.IsProductSort(filter.Sort) {
+		errorResponse(g, http.StatusBadRequest, "unknown sort")
+		return
+	}
+
 	pageIndex, pageSize := pagination.GetPaginationParametersFromRequest(g)
-	items, count := c.storeService.GetAllProducts(pageIndex, pageSize)
+	items, count, facets := c.storeService.GetAllProducts(pageIndex, pageSize, filter)
+	if err := c.exchangeService.ConvertProducts(items, requestCurrency(g)); err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+	c.localizationService.TranslateProducts(items, requestLocale(g, c.localizationService))
 	paginatedResult := pagination.NewFromGinRequest(g, count)
-	paginatedResult.Items = items
+	paginatedResult.Items = c.newProductResponses(items)
 
-	g.JSON(http.StatusOK, paginatedResult)
+	g.JSON(http.StatusOK, productPagesResponse{Pages: *paginatedResult, Facets: facets})
 }
 
 // getProduct godoc
-// @Description  Returns one product by id.
+// @Description  Returns one product by id. Products that are not published are found by admins only.
 // @Tags         Product
 // @Accept       json
 // @Produce      json
 // @Param        id path int true "Product ID"
+// @Param currency query string false "Currency of prices"
+// @Param X-Currency header string false "Currency of prices"
+// @Param lang query string false "Locale of names"
+// @Param Accept-Language header string false "Locales of names by preference"
 // @Success 200 {object} productResponse
 // @Failure 400 {object} response
 // @Failure 404 {object} response
@@ -91,23 +275,292 @@ func (c *Product) GetProduct(g *gin.Context) {
 	}
 
 	product := c.storeService.GetProduct(uint32(id))
-	if product == nil {
+	if product == nil || !canSeeProduct(g, product) {
+		errorResponse(g, http.StatusNotFound, "no record found")
+		return
+	}
+
+	if err := c.exchangeService.ConvertProduct(product, requestCurrency(g)); err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+	c.localizationService.TranslateProduct(product, requestLocale(g, c.localizationService))
+
+	g.JSON(http.StatusOK, c.newProductResponse(product))
+}
+
+// getProductByBarcode godoc
+// @Description  Returns the product with a scanned EAN-8, UPC-A or EAN-13 barcode. Products that are not published are found by admins only.
+// @Tags         Product
+// @Accept       json
+// @Produce      json
+// @Param        code path string true "Barcode"
+// @Param currency query string false "Currency of prices"
+// @Param X-Currency header string false "Currency of prices"
+// @Param lang query string false "Locale of names"
+// @Param Accept-Language header string false "Locales of names by preference"
+// @Success 200 {object} productResponse
+// @Failure 400 {object} response
+// @Failure 404 {object} response
+// @Router /product/barcode/{code} [get]
+// @Security Bearer
+func (c *Product) GetProductByBarcode(g *gin.Context) {
+	product, err := c.storeService.GetProductByBarcode(g.Param("code"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+	if product == nil || !canSeeProduct(g, product) {
 		errorResponse(g, http.StatusNotFound, "no record found")
 		return
 	}
 
-	g.JSON(http.StatusOK, productResponse{
-		ID: product.ID, Name: product.Name, Sku: product.Sku,
-		UnitPrice: product.UnitPrice, Quantity: product.Quantity,
-		CategoryName: product.Category.Name, CategoryID: product.Category.ID})
+	if err := c.exchangeService.ConvertProduct(product, requestCurrency(g)); err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+	c.localizationService.TranslateProduct(product, requestLocale(g, c.localizationService))
+
+	g.JSON(http.StatusOK, c.newProductResponse(product))
+}
+
+// getProductLabels godoc
+// @Description  Renders printable barcode labels with the name, SKU, price and GTIN of the given products.
+// @Description  PDF labels are laid out on A4 sheets of 3 x 8 labels of 70 x 37 mm; PNG labels on a single image, three to a row.
+// @Tags         Product
+// @Produce      application/pdf,image/png
+// @Param ids query string true "Comma separated product ids, at most 100"
+// @Param copies query int false "Labels per product, 1 by default"
+// @Param format query string false "pdf (default) or png"
+// @Success 200 {file} file
+// @Failure 400 {object} response
+// @Router /product/label [get]
+// @Security Bearer
+func (c *Product) GetProductLabels(g *gin.Context) {
+	var ids []uint32
+	for _, v := range strings.Split(g.Query("ids"), ",") {
+		if v = strings.TrimSpace(v); len(v) == 0 {
+			continue
+		}
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			errorResponse(g, http.StatusBadRequest, "unable to get parameters")
+			return
+		}
+		ids = append(ids, uint32(id))
+	}
+	if len(ids) > maxLabelProducts {
+		errorResponse(g, http.StatusBadRequest, fmt.Sprintf("labels can be printed for at most %d products", maxLabelProducts))
+		return
+	}
+
+	copies, err := strconv.Atoi(g.DefaultQuery("copies", "1"))
+	if err != nil || copies < 1 || copies > maxLabelCopies {
+		errorResponse(g, http.StatusBadRequest, fmt.Sprintf("copies must be between 1 and %d", maxLabelCopies))
+		return
+	}
+
+	labels, err := c.storeService.GetProductLabels(ids)
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+	sheet := make([]barcode.Label, 0, len(labels)*copies)
+	for _, label := range labels {
+		for i := 0; i < copies; i++ {
+			sheet = append(sheet, label)
+		}
+	}
+
+	var buf bytes.Buffer
+	contentType, fileName := "application/pdf", "labels.pdf"
+	switch g.DefaultQuery("format", "pdf") {
+	case "pdf":
+		err = barcode.WritePDF(&buf, sheet)
+	case "png":
+		contentType, fileName = "image/png", "labels.png"
+		err = barcode.WritePNG(&buf, sheet)
+	default:
+		errorResponse(g, http.StatusBadRequest, "format must be pdf or png")
+		return
+	}
+	if err != nil {
+		c.logger.Error(err, "http - v1 - getProductLabels")
+		errorResponse(g, http.StatusInternalServerError, "unable to render labels")
+		return
+	}
+
+	g.Header("Content-Disposition", `inline; filename="`+fileName+`"`)
+	g.Data(http.StatusOK, contentType, buf.Bytes())
+}
+
+// getProductHistory godoc
+// @Description  Returns the change history of a product with pagination, newest first.
+// @Tags         Product
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Product ID"
+// @Param page query int false "Page Index"
+// @Param pageSize query int false "Page Size"
+// @Success 200 {object} pagination.Pages
+// @Failure 400 {object} response
+// @Router /product/{id}/history [get]
+// @Security Bearer
+func (c *Product) GetProductHistory(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get parameters")
+		return
+	}
+
+	pageIndex, pageSize := pagination.GetPaginationParametersFromRequest(g)
+	items, count := c.storeService.GetProductHistory(uint32(id), pageIndex, pageSize)
+	paginatedResult := pagination.NewFromGinRequest(g, count)
+	paginatedResult.Items = items
+
+	g.JSON(http.StatusOK, paginatedResult)
+}
+
+// getPriceHistory godoc
+// @Description  Returns the prices of a product during the last days and the lowest price of the same number of days
+// @Description  before its active sale price, the one a discount is announced against.
+// @Tags         Product
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Product ID"
+// @Param days query int false "Number of days, 30 by default"
+// @Param currency query string false "Currency of prices"
+// @Param X-Currency header string false "Currency of prices"
+// @Success 200 {object} service.PriceHistory
+// @Failure 400 {object} response
+// @Failure 404 {object} response
+// @Router /product/{id}/price-history [get]
+// @Security Bearer
+func (c *Product) GetPriceHistory(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get parameters")
+		return
+	}
+
+	days, err := strconv.Atoi(g.DefaultQuery("days", "30"))
+	if err != nil || days <= 0 {
+		errorResponse(g, http.StatusBadRequest, "unable to get parameters")
+		return
+	}
+
+	if product := c.storeService.GetProduct(uint32(id)); product == nil || !canSeeProduct(g, product) {
+		errorResponse(g, http.StatusNotFound, "product not found")
+		return
+	}
+
+	history, err := c.storeService.GetPriceHistory(uint32(id), days)
+	if err != nil {
+		errorResponse(g, http.StatusNotFound, err.Error())
+		return
+	}
+
+	if err := c.exchangeService.ConvertPriceHistory(history, requestCurrency(g)); err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	g.JSON(http.StatusOK, history)
+}
+
+// getBoughtTogether godoc
+// @Description  Returns the products most often bought in the same orders as a product, computed periodically
+// @Description  from the order history. Bestsellers of its category fill in when there is not enough data.
+// @Tags         Product
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Product ID"
+// @Param limit query int false "Maximum number of products"
+// @Param currency query string false "Currency of prices"
+// @Param X-Currency header string false "Currency of prices"
+// @Param lang query string false "Locale of names"
+// @Param Accept-Language header string false "Locales of names by preference"
+// @Success 200 {array} relatedProductResponse
+// @Failure 400 {object} response
+// @Failure 404 {object} response
+// @Router /product/{id}/bought-together [get]
+// @Security Bearer
+func (c *Product) GetBoughtTogether(g *gin.Context) {
+	c.related(g, entity.RecommendationBoughtTogether)
+}
+
+// getAlsoBought godoc
+// @Description  Returns the products most often bought by the customers who bought a product, computed periodically
+// @Description  from the order history. Bestsellers of its category fill in when there is not enough data.
+// @Tags         Product
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Product ID"
+// @Param limit query int false "Maximum number of products"
+// @Param currency query string false "Currency of prices"
+// @Param X-Currency header string false "Currency of prices"
+// @Param lang query string false "Locale of names"
+// @Param Accept-Language header string false "Locales of names by preference"
+// @Success 200 {array} relatedProductResponse
+// @Failure 400 {object} response
+// @Failure 404 {object} response
+// @Router /product/{id}/also-bought [get]
+// @Security Bearer
+func (c *Product) GetAlsoBought(g *gin.Context) {
+	c.related(g, entity.RecommendationAlsoBought)
+}
+
+func (c *Product) related(g *gin.Context, kind string) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get parameters")
+		return
+	}
+	limit, err := strconv.Atoi(g.DefaultQuery("limit", "0"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get parameters")
+		return
+	}
+
+	product := c.storeService.GetProduct(uint32(id))
+	if product == nil || !canSeeProduct(g, product) {
+		errorResponse(g, http.StatusNotFound, "product not found")
+		return
+	}
+
+	related, err := c.recommendationService.GetRelated(product, kind, limit)
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	locale := requestLocale(g, c.localizationService)
+	response := make([]relatedProductResponse, 0, len(related))
+	for _, r := range related {
+		if err := c.exchangeService.ConvertProduct(r.Product, requestCurrency(g)); err != nil {
+			errorResponse(g, http.StatusBadRequest, err.Error())
+			return
+		}
+		c.localizationService.TranslateProduct(r.Product, locale)
+		response = append(response, relatedProductResponse{
+			Source: r.Source, Count: r.Count, Product: c.newProductResponse(r.Product)})
+	}
+
+	g.JSON(http.StatusOK, response)
 }
 
 // searchProducts godoc
-// @Description  Returns searched products.
+// @Description  Returns searched products. Only published products are found, except for admins who can filter by status.
+// @Description  Names are searched in the default locale and in the locale of the response.
 // @Tags         Product
 // @Accept       json
 // @Produce      json
 // @Param        query path string true "Search Query"
+// @Param status query string false "Comma separated statuses, admins only"
+// @Param currency query string false "Currency of prices"
+// @Param X-Currency header string false "Currency of prices"
+// @Param lang query string false "Locale of names"
+// @Param Accept-Language header string false "Locales of names by preference"
 // @Success 200 {object} searchResponse
 // @Failure 400 {object} response
 // @Failure 500 {object} response
@@ -120,12 +573,27 @@ func (c *Product) SearchProducts(g *gin.Context) {
 		return
 	}
 
-	products := c.storeService.SearchProducts(searchQuery)
-	g.JSON(http.StatusOK, searchResponse{Items: products, Count: len(products)})
+	statuses, err := productStatuses(g)
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	locale := requestLocale(g, c.localizationService)
+	products := c.storeService.SearchProducts(searchQuery, statuses, locale)
+	if err := c.exchangeService.ConvertProducts(products, requestCurrency(g)); err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+	c.localizationService.TranslateProducts(products, locale)
+	g.JSON(http.StatusOK, searchResponse{Items: c.newProductResponses(products), Count: len(products)})
 }
 
 // createProduct godoc
-// @Description  Creates a new product.
+// @Description  Creates a new product. Products are created as drafts unless another status is given.
+// @Description  Products are sold by the piece unless another unit is given; quantities are sold in multiples of quantity_step and at least min_quantity.
+// @Description  Digital products have no stock and are not shipped; customers download their file after ordering.
+// @Description  Serialized products and products of categories that track lots are created without stock; their units are added with their serial numbers or lot through POST /stock/adjustment.
 // @Tags         Product
 // @Accept       json
 // @Produce      json
@@ -143,22 +611,113 @@ func (c *Product) CreateProduct(g *gin.Context) {
 		return
 	}
 
-	product := entity.NewProduct(req.Name, req.Sku, req.UnitPrice, req.Quantity, req.CategoryID)
-	err := c.storeService.CreateProduct(product)
+	unitPrice, err := c.storeService.ParsePrice(req.UnitPrice.String(), req.Currency)
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	product := entity.NewProduct(req.Name, req.Sku, unitPrice, req.Quantity, req.CategoryID)
+	if len(req.Unit) > 0 || req.QuantityStep != 0 || req.MinQuantity != 0 {
+		unit := req.Unit
+		if len(unit) == 0 {
+			unit = entity.UnitPiece
+		}
+		if err := product.SetUnit(unit, req.QuantityStep, req.MinQuantity); err != nil {
+			errorResponse(g, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if err := product.SetReorder(req.ReorderPoint, req.ReorderQuantity); err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := product.SetSerialType(req.SerialType); err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := product.SetDigital(req.IsDigital); err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := product.SetGTIN(req.GTIN); err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(req.Status) > 0 && req.Status != product.Status {
+		if err := product.SetStatus(req.Status, req.PublishAt, time.Now()); err != nil {
+			errorResponse(g, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	err = c.storeService.CreateProduct(product, req.Attributes, g.GetString("Email"))
 	if err != nil {
 		c.logger.Error(err, "http - v1 - createProduct")
 		errorResponse(g, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	g.JSON(http.StatusOK, productResponse{
-		ID: product.ID, Name: product.Name, Sku: product.Sku,
-		UnitPrice: product.UnitPrice, Quantity: product.Quantity,
-		CategoryName: product.Category.Name, CategoryID: product.Category.ID})
+	g.JSON(http.StatusOK, c.newProductResponse(product))
+}
+
+// createBulkProduct godoc
+// @Description  Creates or updates products in bulk from a CSV or XLSX file, matching existing products by SKU.
+// @Description  Recognized columns are name, sku, gtin, unit_price, currency, unit, quantity_step, min_quantity, quantity, category (id or name), status, publish_at and attr.<code>.
+// @Description  New products are drafts unless a status is given; the status of existing products is changed through the status endpoint.
+// @Description  Products moved to another category get the attributes given in their row, which must satisfy the new category.
+// @Tags         Product
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param   	 file formData file true "Product CSV or XLSX"
+// @Param   	 mapping formData string false "Column mapping as JSON, e.g. {\"sku\":\"Stok Kodu\"}"
+// @Param   	 dry_run formData bool false "Validate only"
+// @Param   	 atomic formData bool false "Write all rows or none"
+// @Success 200 {object} service.ImportReport
+// @Failure 400 {object} response
+// @Failure 500 {object} response
+// @Router /product/bulk [post]
+// @Security Bearer
+func (c *Product) CreateBulkProduct(g *gin.Context) {
+	file, fileHead, err := g.Request.FormFile("file")
+	if err != nil {
+		c.logger.Error(err, "http - v1 - createBulkProduct")
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer file.Close()
+
+	var mapping map[string]string
+	if raw := g.PostForm("mapping"); len(raw) > 0 {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			errorResponse(g, http.StatusBadRequest, "invalid column mapping")
+			return
+		}
+	}
+	dryRun, _ := strconv.ParseBool(g.PostForm("dry_run"))
+	atomic, _ := strconv.ParseBool(g.PostForm("atomic"))
+
+	rows, err := spreadsheet.Read(file, fileHead.Filename)
+	if err != nil {
+		c.logger.Error(err, "http - v1 - createBulkProduct")
+		errorResponse(g, http.StatusBadRequest, "unable to read file")
+		return
+	}
+
+	report, err := c.storeService.ImportProducts(rows, mapping, dryRun, atomic, g.GetString("Email"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	g.JSON(http.StatusOK, report)
 }
 
 // updateProduct godoc
-// @Description  Updates a product.
+// @Description  Updates a product. The GTIN, the unit and quantities it is sold in, the stock quantity, the reorder point and quantity, the serial type and whether it is digital are kept when not given; an empty GTIN removes it.
+// @Description  Bundles priced by discount keep the price of their components.
+// @Description  The stock quantity of serialized products is changed with serial numbers through POST /stock/adjustment, and the serial type only while the product is out of stock.
+// @Description  Stock of products of categories that track lots is only added into a lot, through POST /stock/adjustment or a purchase order receipt.
 // @Tags         Product
 // @Accept       json
 // @Produce      json
@@ -184,24 +743,79 @@ func (c *Product) UpdateProduct(g *gin.Context) {
 	}
 
 	product := c.storeService.GetProduct(uint32(id))
+	if product == nil {
+		errorResponse(g, http.StatusNotFound, "no record found")
+		return
+	}
+
+	currency := req.Currency
+	if len(currency) == 0 {
+		currency = product.UnitPrice.Currency
+	}
+	unitPrice, err := c.storeService.ParsePrice(req.UnitPrice.String(), currency)
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	product.Name = req.Name
-	product.UnitPrice = req.UnitPrice
-	product.Quantity = req.Quantity
-	err = c.storeService.UpdateProduct(product)
+	product.UnitPrice = unitPrice
+	product.Currency = unitPrice.Currency
+	if req.Quantity != nil {
+		product.Quantity = *req.Quantity
+	}
+	if req.ReorderPoint != nil {
+		product.ReorderPoint = *req.ReorderPoint
+	}
+	if req.ReorderQuantity != nil {
+		product.ReorderQuantity = *req.ReorderQuantity
+	}
+	if req.SerialType != nil {
+		if err := product.SetSerialType(*req.SerialType); err != nil {
+			errorResponse(g, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if req.Unit != nil || req.QuantityStep != nil || req.MinQuantity != nil {
+		unit, step, min := product.Unit, product.QuantityStep, product.MinQuantity
+		if req.Unit != nil {
+			unit = *req.Unit
+		}
+		if req.QuantityStep != nil {
+			step = *req.QuantityStep
+		}
+		if req.MinQuantity != nil {
+			min = *req.MinQuantity
+		}
+		if err := product.SetUnit(unit, step, min); err != nil {
+			errorResponse(g, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if req.IsDigital != nil {
+		if err := product.SetDigital(*req.IsDigital); err != nil {
+			errorResponse(g, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if req.GTIN != nil {
+		if err := product.SetGTIN(*req.GTIN); err != nil {
+			errorResponse(g, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	err = c.storeService.UpdateProduct(product, req.Attributes, g.GetString("Email"))
 	if err != nil {
 		c.logger.Error(err, "http - v1 - updateProduct")
 		errorResponse(g, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	g.JSON(http.StatusOK, productResponse{
-		ID: product.ID, Name: product.Name, Sku: product.Sku,
-		UnitPrice: product.UnitPrice, Quantity: product.Quantity,
-		CategoryName: product.Category.Name, CategoryID: product.Category.ID})
+	g.JSON(http.StatusOK, c.newProductResponse(product))
 }
 
 // deleteProduct godoc
-// @Description  Deletes a product.
+// @Description  Moves a product to the trash. It can be restored until the trash is purged.
 // @Tags         Product
 // @Accept       json
 // @Produce      json
@@ -218,7 +832,7 @@ func (c *Product) DeleteProduct(g *gin.Context) {
 		return
 	}
 
-	err = c.storeService.DeleteProduct(uint32(id))
+	err = c.storeService.DeleteProduct(uint32(id), g.GetString("Email"))
 	if err != nil {
 		c.logger.Error(err, "http - v1 - deleteProduct")
 		errorResponse(g, http.StatusInternalServerError, err.Error())
@@ -227,3 +841,488 @@ func (c *Product) DeleteProduct(g *gin.Context) {
 
 	successResponse(g, http.StatusOK, "Operation completed successfully.")
 }
+
+// setProductBundle godoc
+// @Description  Makes a product without stock of its own a bundle of other products, or changes the components and pricing of a bundle.
+// @Description  The stock of a bundle is the number of whole bundles its components make up; ordering one takes its components out of stock.
+// @Description  Bundles are sold at their own unit price (fixed) or at the unit prices of their components less discount percent (discount).
+// @Tags         Product
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Product ID"
+// @Param data body setProductBundleRequest true "Product Bundle Model"
+// @Success 200 {object} productResponse
+// @Failure 400 {object} response
+// @Router /product/{id}/bundle [put]
+// @Security Bearer
+func (c *Product) SetProductBundle(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get id")
+		return
+	}
+
+	var req setProductBundleRequest
+	if err := g.ShouldBind(&req); err != nil {
+		c.logger.Error(err, "http - v1 - setProductBundle")
+		errorResponse(g, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	lines := make([]service.BundleLine, 0, len(req.Components))
+	for _, component := range req.Components {
+		lines = append(lines, service.BundleLine{ProductID: component.ProductID, Quantity: component.Quantity})
+	}
+	product, err := c.storeService.SetBundle(uint32(id), req.Pricing, req.Discount, lines, g.GetString("Email"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	g.JSON(http.StatusOK, c.newProductResponse(product))
+}
+
+// removeProductBundle godoc
+// @Description  Makes a bundle a regular product without stock again.
+// @Tags         Product
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Product ID"
+// @Success 200 {object} productResponse
+// @Failure 400 {object} response
+// @Router /product/{id}/bundle [delete]
+// @Security Bearer
+func (c *Product) RemoveProductBundle(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get id")
+		return
+	}
+
+	product, err := c.storeService.RemoveBundle(uint32(id), g.GetString("Email"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	g.JSON(http.StatusOK, c.newProductResponse(product))
+}
+
+// setProductStatus godoc
+// @Description  Moves a product through the publishing workflow: draft, scheduled, published and archived.
+// @Description  Scheduled products are published at publish_at. Products that are no longer published are removed from baskets.
+// @Tags         Product
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Product ID"
+// @Param data body setProductStatusRequest true "Product Status Model"
+// @Success 200 {object} productResponse
+// @Failure 400 {object} response
+// @Router /product/{id}/status [patch]
+// @Security Bearer
+func (c *Product) SetProductStatus(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get id")
+		return
+	}
+
+	var req setProductStatusRequest
+	if err := g.ShouldBind(&req); err != nil {
+		c.logger.Error(err, "http - v1 - setProductStatus")
+		errorResponse(g, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	product, err := c.storeService.SetProductStatus(uint32(id), req.Status, req.PublishAt, g.GetString("Email"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	g.JSON(http.StatusOK, c.newProductResponse(product))
+}
+
+// getSalePrices godoc
+// @Description  Returns the past, current and scheduled sale prices of a product.
+// @Tags         Product
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Product ID"
+// @Success 200 {array} entity.SalePrice
+// @Failure 400 {object} response
+// @Failure 404 {object} response
+// @Router /product/{id}/sale-price [get]
+// @Security Bearer
+func (c *Product) GetSalePrices(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get parameters")
+		return
+	}
+
+	if product := c.storeService.GetProduct(uint32(id)); product == nil || !canSeeProduct(g, product) {
+		errorResponse(g, http.StatusNotFound, "no record found")
+		return
+	}
+
+	g.JSON(http.StatusOK, c.storeService.GetSalePrices(uint32(id)))
+}
+
+// createSalePrice godoc
+// @Description  Schedules a sale price. It applies from starts_at until ends_at and must not overlap another one.
+// @Tags         Product
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Product ID"
+// @Param data body createSalePriceRequest true "Create Sale Price Model"
+// @Success 200 {object} entity.SalePrice
+// @Failure 400 {object} response
+// @Failure 500 {object} response
+// @Router /product/{id}/sale-price [post]
+// @Security Bearer
+func (c *Product) CreateSalePrice(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get parameters")
+		return
+	}
+
+	var req createSalePriceRequest
+	if err := g.ShouldBind(&req); err != nil {
+		c.logger.Error(err, "http - v1 - createSalePrice")
+		errorResponse(g, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	product := c.storeService.GetProduct(uint32(id))
+	if product == nil {
+		errorResponse(g, http.StatusNotFound, "no record found")
+		return
+	}
+
+	price, err := c.storeService.ParsePrice(req.Price.String(), product.UnitPrice.Currency)
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	salePrice, err := entity.NewSalePrice(uint32(id), price, req.StartsAt, req.EndsAt)
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = c.storeService.CreateSalePrice(salePrice)
+	if err != nil {
+		c.logger.Error(err, "http - v1 - createSalePrice")
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	g.JSON(http.StatusOK, salePrice)
+}
+
+// deleteSalePrice godoc
+// @Description  Removes a sale price, ending it immediately when it is in effect.
+// @Tags         Product
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Product ID"
+// @Param        salePriceId path int true "Sale Price ID"
+// @Success 200 {object} response
+// @Failure 400 {object} response
+// @Failure 500 {object} response
+// @Router /product/{id}/sale-price/{salePriceId} [delete]
+// @Security Bearer
+func (c *Product) DeleteSalePrice(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get parameters")
+		return
+	}
+
+	salePriceId, err := strconv.Atoi(g.Param("salePriceId"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get parameters")
+		return
+	}
+
+	err = c.storeService.DeleteSalePrice(uint32(id), uint32(salePriceId))
+	if err != nil {
+		c.logger.Error(err, "http - v1 - deleteSalePrice")
+		errorResponse(g, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	successResponse(g, http.StatusOK, "Operation completed successfully.")
+}
+
+// uploadProductImage godoc
+// @Description  Uploads an image to the product gallery. JPEG, PNG and GIF files are accepted.
+// @Tags         Product
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id path int true "Product ID"
+// @Param   	 file formData file true "Image File"
+// @Success 200 {object} productImageResponse
+// @Failure 400 {object} response
+// @Failure 500 {object} response
+// @Router /product/{id}/image [post]
+// @Security Bearer
+func (c *Product) UploadProductImage(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get id")
+		return
+	}
+
+	file, _, err := g.Request.FormFile("file")
+	if err != nil {
+		c.logger.Error(err, "http - v1 - uploadProductImage")
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer file.Close()
+
+	image, err := c.mediaService.UploadProductImage(uint32(id), file)
+	if err != nil {
+		c.logger.Error(err, "http - v1 - uploadProductImage")
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	g.JSON(http.StatusOK, c.newProductImageResponse(image))
+}
+
+// uploadProductFile godoc
+// @Description  Uploads the file customers download after buying a digital product, replacing the current one.
+// @Tags         Product
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id path int true "Product ID"
+// @Param   	 file formData file true "Product File"
+// @Success 200 {object} productFileResponse
+// @Failure 400 {object} response
+// @Failure 500 {object} response
+// @Router /product/{id}/file [post]
+// @Security Bearer
+func (c *Product) UploadProductFile(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get id")
+		return
+	}
+
+	file, fileHead, err := g.Request.FormFile("file")
+	if err != nil {
+		c.logger.Error(err, "http - v1 - uploadProductFile")
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer file.Close()
+
+	productFile, err := c.downloadService.UploadProductFile(uint32(id), file, fileHead.Filename, g.GetString("Email"))
+	if err != nil {
+		c.logger.Error(err, "http - v1 - uploadProductFile")
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	g.JSON(http.StatusOK, productFileResponse{
+		FileName: productFile.FileName, ContentType: productFile.ContentType,
+		Size: productFile.Size, UpdatedAt: productFile.UpdatedAt})
+}
+
+// reorderProductImages godoc
+// @Description  Sets the order of the product gallery.
+// @Tags         Product
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Product ID"
+// @Param 		 data body reorderProductImagesRequest true "Reorder Images Model"
+// @Success 200 {object} response
+// @Failure 400 {object} response
+// @Failure 500 {object} response
+// @Router /product/{id}/image/order [put]
+// @Security Bearer
+func (c *Product) ReorderProductImages(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get id")
+		return
+	}
+
+	var req reorderProductImagesRequest
+	if err := g.ShouldBind(&req); err != nil {
+		c.logger.Error(err, "http - v1 - reorderProductImages")
+		errorResponse(g, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	err = c.mediaService.ReorderProductImages(uint32(id), req.ImageIds)
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	successResponse(g, http.StatusOK, "Operation completed successfully.")
+}
+
+// setPrimaryProductImage godoc
+// @Description  Marks an image as the primary image of the product.
+// @Tags         Product
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Product ID"
+// @Param        imageId path int true "Image ID"
+// @Success 200 {object} response
+// @Failure 400 {object} response
+// @Failure 404 {object} response
+// @Router /product/{id}/image/{imageId}/primary [patch]
+// @Security Bearer
+func (c *Product) SetPrimaryProductImage(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get id")
+		return
+	}
+
+	imageId, err := strconv.Atoi(g.Param("imageId"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get id")
+		return
+	}
+
+	err = c.mediaService.SetPrimaryProductImage(uint32(id), uint32(imageId))
+	if err != nil {
+		errorResponse(g, http.StatusNotFound, err.Error())
+		return
+	}
+
+	successResponse(g, http.StatusOK, "Operation completed successfully.")
+}
+
+// deleteProductImage godoc
+// @Description  Removes an image from the product gallery.
+// @Tags         Product
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Product ID"
+// @Param        imageId path int true "Image ID"
+// @Success 200 {object} response
+// @Failure 400 {object} response
+// @Failure 404 {object} response
+// @Router /product/{id}/image/{imageId} [delete]
+// @Security Bearer
+func (c *Product) DeleteProductImage(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get id")
+		return
+	}
+
+	imageId, err := strconv.Atoi(g.Param("imageId"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get id")
+		return
+	}
+
+	err = c.mediaService.DeleteProductImage(uint32(id), uint32(imageId))
+	if err != nil {
+		errorResponse(g, http.StatusNotFound, err.Error())
+		return
+	}
+
+	successResponse(g, http.StatusOK, "Operation completed successfully.")
+}
+
+func (c *Product) newProductResponse(product *entity.Product) productResponse {
+	attributes := make([]productAttributeResponse, 0, len(product.Attributes))
+	for _, a := range product.Attributes {
+		if a.Attribute == nil {
+			continue
+		}
+		attributes = append(attributes, productAttributeResponse{
+			Code: a.Attribute.Code, Name: a.Attribute.Name, Type: a.Attribute.Type,
+			Value: a.Value, Unit: a.Attribute.Unit})
+	}
+
+	images := make([]productImageResponse, 0, len(product.Images))
+	for _, image := range product.Images {
+		images = append(images, c.newProductImageResponse(image))
+	}
+
+	var components []bundleComponentResponse
+	for _, component := range product.Components {
+		if component.Product == nil {
+			continue
+		}
+		components = append(components, bundleComponentResponse{
+			ProductID: component.ProductID, Sku: component.Product.Sku, Name: component.Product.Name,
+			Quantity: component.Quantity, Unit: component.Product.Unit, Available: component.Product.Available()})
+	}
+
+	response := productResponse{
+		ID: product.ID, Name: product.Name, Sku: product.Sku, GTIN: product.GTIN,
+		UnitPrice: product.UnitPrice, Price: product.EffectivePrice(), Currency: product.Currency,
+		PriceUnit: product.PriceUnit(product.Currency), Unit: product.Unit, QuantityStep: product.QuantityStep,
+		MinQuantity: product.MinQuantity, Quantity: product.OnHand(), Available: product.Available(),
+		ReorderPoint: product.ReorderPoint, ReorderQuantity: product.ReorderQuantity, SerialType: product.SerialType,
+		BundlePricing: product.BundlePricing, BundleDiscount: product.BundleDiscount, Components: components,
+		IsDigital: product.IsDigital, Rating: product.Rating, RatingCount: product.RatingCount,
+		CategoryName: product.Category.Name, CategoryID: product.CategoryID,
+		Status: product.Status, PublishAt: product.PublishAt, Attributes: attributes, Images: images}
+	if product.SalePrice != nil {
+		response.SalePrice = &product.SalePrice.Price
+		response.SaleEndsAt = &product.SalePrice.EndsAt
+	}
+	if product.File != nil {
+		response.File = &productFileResponse{
+			FileName: product.File.FileName, ContentType: product.File.ContentType,
+			Size: product.File.Size, UpdatedAt: product.File.UpdatedAt}
+	}
+	response.DisplayPrice = response.Price.String() + " " + response.PriceUnit
+
+	return response
+}
+
+func (c *Product) newProductResponses(products []entity.Product) []productResponse {
+	responses := make([]productResponse, 0, len(products))
+	for i := range products {
+		responses = append(responses, c.newProductResponse(&products[i]))
+	}
+	return responses
+}
+
+func (c *Product) newProductImageResponse(image *entity.ProductImage) productImageResponse {
+	return productImageResponse{
+		ID: image.ID, URL: c.mediaService.URL(image.Key), ThumbnailURL: c.mediaService.URL(image.ThumbnailKey),
+		Width: image.Width, Height: image.Height, Position: image.Position, IsPrimary: image.IsPrimary}
+}
+
+// productStatuses returns the product statuses a request may see: published
+// only for customers, and for admins the statuses in the status query
+// parameter, or all when it is empty.
+func productStatuses(g *gin.Context) ([]string, error) {
+	if !hasRole(g, "admin") {
+		return []string{entity.ProductStatusPublished}, nil
+	}
+
+	var statuses []string
+	for _, status := range strings.Split(g.Query("status"), ",") {
+		status = strings.ToLower(strings.TrimSpace(status))
+		if len(status) == 0 {
+			continue
+		}
+		if !entity.IsProductStatus(status) {
+			return nil, fmt.Errorf("unknown status: %q", status)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func canSeeProduct(g *gin.Context, product *entity.Product) bool {
+	return product.IsPublished() || hasRole(g, "admin")
+}
@@ -0,0 +1,337 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"github.com/bestetufan/beste-store/internal/service"
+	"github.com/bestetufan/beste-store/pkg/logger"
+	"github.com/bestetufan/beste-store/pkg/measure"
+	"github.com/bestetufan/beste-store/pkg/money"
+	"github.com/bestetufan/beste-store/pkg/pagination"
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	PurchaseOrder struct {
+		purchasingService service.PurchasingService
+		logger            logger.Logger
+	}
+
+	purchaseOrderItemRequest struct {
+		ProductID uint32           `json:"product_id" binding:"required"`
+		Quantity  measure.Quantity `json:"quantity" binding:"required" example:"10" swaggertype:"number"`
+		UnitCost  string           `json:"unit_cost" example:"12.40"`
+	}
+
+	createPurchaseOrderRequest struct {
+		SupplierID  uint32                     `json:"supplier_id" binding:"required"`
+		WarehouseID uint32                     `json:"warehouse_id"`
+		Note        string                     `json:"note"`
+		Items       []purchaseOrderItemRequest `json:"items"`
+	}
+
+	updatePurchaseOrderRequest struct {
+		WarehouseID uint32                     `json:"warehouse_id"`
+		Note        string                     `json:"note"`
+		Items       []purchaseOrderItemRequest `json:"items"`
+	}
+
+	draftPurchaseOrderRequest struct {
+		SupplierID  uint32 `json:"supplier_id" binding:"required"`
+		WarehouseID uint32 `json:"warehouse_id"`
+	}
+
+	receiveItemRequest struct {
+		ProductID uint32           `json:"product_id" binding:"required"`
+		Quantity  measure.Quantity `json:"quantity" example:"4" swaggertype:"number"`
+		LotNumber string           `json:"lot_number" example:"L2410-07"`
+		ExpiresAt string           `json:"expires_at" example:"2026-12-31"`
+		Serials   []string         `json:"serials"`
+	}
+
+	receivePurchaseOrderRequest struct {
+		Items []receiveItemRequest `json:"items"`
+	}
+
+	purchaseOrderResponse struct {
+		*entity.PurchaseOrder
+		Total money.Money `json:"total" swaggertype:"number"`
+	}
+)
+
+func NewPurchaseOrder(ps service.PurchasingService, l logger.Logger) *PurchaseOrder {
+	return &PurchaseOrder{ps, l}
+}
+
+// getPurchaseOrders godoc
+// @Description  Returns purchase orders with pagination, newest first, without their items.
+// @Tags         PurchaseOrder
+// @Accept       json
+// @Produce      json
+// @Param page query int false "Page Index"
+// @Param pageSize query int false "Page Size"
+// @Param supplier_id query int false "Supplier ID"
+// @Param status query string false "Status" Enums(draft, ordered, partially_received, received, canceled)
+// @Success 200 {object} pagination.Pages
+// @Failure 400 {object} response
+// @Router /purchase-order [get]
+// @Security Bearer
+func (c *PurchaseOrder) GetPurchaseOrders(g *gin.Context) {
+	filter := entity.PurchaseOrderFilter{Status: g.Query("status")}
+	if len(filter.Status) > 0 && !entity.IsPurchaseOrderStatus(filter.Status) {
+		errorResponse(g, http.StatusBadRequest, "unknown status")
+		return
+	}
+	if supplierId := g.Query("supplier_id"); len(supplierId) > 0 {
+		id, err := strconv.Atoi(supplierId)
+		if err != nil {
+			errorResponse(g, http.StatusBadRequest, "unable to get parameters")
+			return
+		}
+		filter.SupplierID = uint32(id)
+	}
+
+	pageIndex, pageSize := pagination.GetPaginationParametersFromRequest(g)
+	items, count := c.purchasingService.GetPurchaseOrders(filter, pageIndex, pageSize)
+	paginatedResult := pagination.NewFromGinRequest(g, count)
+	paginatedResult.Items = items
+
+	g.JSON(http.StatusOK, paginatedResult)
+}
+
+// getPurchaseOrder godoc
+// @Description  Returns a purchase order with its items and total cost.
+// @Tags         PurchaseOrder
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Purchase Order ID"
+// @Success 200 {object} purchaseOrderResponse
+// @Failure 400 {object} response
+// @Failure 404 {object} response
+// @Router /purchase-order/{id} [get]
+// @Security Bearer
+func (c *PurchaseOrder) GetPurchaseOrder(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get id")
+		return
+	}
+
+	order := c.purchasingService.GetPurchaseOrder(uint32(id))
+	if order == nil {
+		errorResponse(g, http.StatusNotFound, "no record found")
+		return
+	}
+
+	g.JSON(http.StatusOK, newPurchaseOrderResponse(order))
+}
+
+// createPurchaseOrder godoc
+// @Description  Creates a draft purchase order in the currency of the supplier, received into the given
+// @Description  warehouse or the default one. Items without unit cost take the cost price at the supplier.
+// @Tags         PurchaseOrder
+// @Accept       json
+// @Produce      json
+// @Param data body createPurchaseOrderRequest true "Create Purchase Order Model"
+// @Success 200 {object} purchaseOrderResponse
+// @Failure 400 {object} response
+// @Router /purchase-order [post]
+// @Security Bearer
+func (c *PurchaseOrder) CreatePurchaseOrder(g *gin.Context) {
+	var req createPurchaseOrderRequest
+	if err := g.ShouldBind(&req); err != nil {
+		c.logger.Error(err, "http - v1 - createPurchaseOrder")
+		errorResponse(g, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	order, err := c.purchasingService.CreatePurchaseOrder(req.SupplierID, req.WarehouseID, req.Note,
+		purchaseOrderLines(req.Items), g.GetString("Email"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	g.JSON(http.StatusOK, newPurchaseOrderResponse(order))
+}
+
+// draftPurchaseOrder godoc
+// @Description  Drafts a purchase order to a supplier for its products that are low on stock, each in its
+// @Description  reorder quantity less what is already on order.
+// @Tags         PurchaseOrder
+// @Accept       json
+// @Produce      json
+// @Param data body draftPurchaseOrderRequest true "Draft Purchase Order Model"
+// @Success 200 {object} purchaseOrderResponse
+// @Failure 400 {object} response
+// @Router /purchase-order/draft [post]
+// @Security Bearer
+func (c *PurchaseOrder) DraftPurchaseOrder(g *gin.Context) {
+	var req draftPurchaseOrderRequest
+	if err := g.ShouldBind(&req); err != nil {
+		c.logger.Error(err, "http - v1 - draftPurchaseOrder")
+		errorResponse(g, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	order, err := c.purchasingService.DraftPurchaseOrder(req.SupplierID, req.WarehouseID, g.GetString("Email"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	g.JSON(http.StatusOK, newPurchaseOrderResponse(order))
+}
+
+// updatePurchaseOrder godoc
+// @Description  Replaces the warehouse, note and items of a draft purchase order.
+// @Tags         PurchaseOrder
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Purchase Order ID"
+// @Param data body updatePurchaseOrderRequest true "Update Purchase Order Model"
+// @Success 200 {object} purchaseOrderResponse
+// @Failure 400 {object} response
+// @Router /purchase-order/{id} [put]
+// @Security Bearer
+func (c *PurchaseOrder) UpdatePurchaseOrder(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get id")
+		return
+	}
+
+	var req updatePurchaseOrderRequest
+	if err := g.ShouldBind(&req); err != nil {
+		c.logger.Error(err, "http - v1 - updatePurchaseOrder")
+		errorResponse(g, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	order, err := c.purchasingService.UpdatePurchaseOrder(uint32(id), req.WarehouseID, req.Note,
+		purchaseOrderLines(req.Items))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	g.JSON(http.StatusOK, newPurchaseOrderResponse(order))
+}
+
+// orderPurchaseOrder godoc
+// @Description  Marks a draft purchase order as sent to the supplier. Its items can be received from then on.
+// @Tags         PurchaseOrder
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Purchase Order ID"
+// @Success 200 {object} purchaseOrderResponse
+// @Failure 400 {object} response
+// @Router /purchase-order/{id}/order [patch]
+// @Security Bearer
+func (c *PurchaseOrder) OrderPurchaseOrder(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get id")
+		return
+	}
+
+	order, err := c.purchasingService.OrderPurchaseOrder(uint32(id))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	g.JSON(http.StatusOK, newPurchaseOrderResponse(order))
+}
+
+// cancelPurchaseOrder godoc
+// @Description  Cancels a purchase order, or the items of it not received yet. Received items stay in stock.
+// @Tags         PurchaseOrder
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Purchase Order ID"
+// @Success 200 {object} purchaseOrderResponse
+// @Failure 400 {object} response
+// @Router /purchase-order/{id}/cancel [patch]
+// @Security Bearer
+func (c *PurchaseOrder) CancelPurchaseOrder(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get id")
+		return
+	}
+
+	order, err := c.purchasingService.CancelPurchaseOrder(uint32(id))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	g.JSON(http.StatusOK, newPurchaseOrderResponse(order))
+}
+
+// receivePurchaseOrder godoc
+// @Description  Receives items of an ordered purchase order into its warehouse as stock receipts referencing
+// @Description  purchase_order:<id>. Without items, everything outstanding is received. Products of categories
+// @Description  tracking lots are received in lots: items need a lot number, and an expiry date when the lot is
+// @Description  new. A product can be received in several lots with an item per lot. Serialized products are received
+// @Description  with the serial numbers of their units.
+// @Tags         PurchaseOrder
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Purchase Order ID"
+// @Param data body receivePurchaseOrderRequest false "Receive Purchase Order Model"
+// @Success 200 {object} purchaseOrderResponse
+// @Failure 400 {object} response
+// @Router /purchase-order/{id}/receive [post]
+// @Security Bearer
+func (c *PurchaseOrder) ReceivePurchaseOrder(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get id")
+		return
+	}
+
+	var req receivePurchaseOrderRequest
+	if g.Request.ContentLength != 0 {
+		if err := g.ShouldBind(&req); err != nil {
+			c.logger.Error(err, "http - v1 - receivePurchaseOrder")
+			errorResponse(g, http.StatusBadRequest, "invalid request body")
+			return
+		}
+	}
+
+	receipts := make([]service.StockReceipt, 0, len(req.Items))
+	for _, item := range req.Items {
+		expiresAt, err := expiryDate(item.ExpiresAt)
+		if err != nil {
+			errorResponse(g, http.StatusBadRequest, err.Error())
+			return
+		}
+		receipts = append(receipts, service.StockReceipt{ProductID: item.ProductID, Quantity: item.Quantity,
+			LotNumber: item.LotNumber, ExpiresAt: expiresAt, Serials: item.Serials})
+	}
+
+	order, err := c.purchasingService.ReceivePurchaseOrder(uint32(id), receipts, g.GetString("Email"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	g.JSON(http.StatusOK, newPurchaseOrderResponse(order))
+}
+
+func newPurchaseOrderResponse(order *entity.PurchaseOrder) purchaseOrderResponse {
+	return purchaseOrderResponse{PurchaseOrder: order, Total: order.Total()}
+}
+
+func purchaseOrderLines(items []purchaseOrderItemRequest) []service.PurchaseOrderLine {
+	lines := make([]service.PurchaseOrderLine, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, service.PurchaseOrderLine{ProductID: item.ProductID, Quantity: item.Quantity,
+			UnitCost: item.UnitCost})
+	}
+	return lines
+}
@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"github.com/bestetufan/beste-store/internal/service"
+	"github.com/bestetufan/beste-store/pkg/logger"
+	"github.com/bestetufan/beste-store/pkg/pagination"
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	Review struct {
+		reviewService service.ReviewService
+		logger        logger.Logger
+	}
+
+	createReviewRequest struct {
+		Rating int    `json:"rating" binding:"required" example:"5"`
+		Title  string `json:"title" binding:"required" example:"Great read"`
+		Text   string `json:"text"`
+	}
+)
+
+func NewReview(rs service.ReviewService, l logger.Logger) *Review {
+	return &Review{rs, l}
+}
+
+// getProductReviews godoc
+// @Description  Returns the approved reviews of a product with pagination, newest first.
+// @Tags         Review
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Product ID"
+// @Param page query int false "Page Index"
+// @Param pageSize query int false "Page Size"
+// @Success 200 {object} pagination.Pages
+// @Failure 400 {object} response
+// @Router /product/{id}/review [get]
+// @Security Bearer
+func (c *Review) GetProductReviews(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get id")
+		return
+	}
+
+	filter := entity.ReviewFilter{ProductID: uint32(id), Status: entity.ReviewStatusApproved}
+	pageIndex, pageSize := pagination.GetPaginationParametersFromRequest(g)
+	items, count := c.reviewService.GetReviews(filter, pageIndex, pageSize)
+	paginatedResult := pagination.NewFromGinRequest(g, count)
+	paginatedResult.Items = items
+
+	g.JSON(http.StatusOK, paginatedResult)
+}
+
+// createReview godoc
+// @Description  Reviews a product with a rating from 1 to 5. Products are reviewed once per user, after an order
+// @Description  containing them has been delivered. Reviews are shown once approved by an admin.
+// @Tags         Review
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Product ID"
+// @Param data body createReviewRequest true "Create Review Model"
+// @Success 200 {object} entity.Review
+// @Failure 400 {object} response
+// @Router /product/{id}/review [post]
+// @Security Bearer
+func (c *Review) CreateReview(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get id")
+		return
+	}
+
+	var req createReviewRequest
+	if err := g.ShouldBind(&req); err != nil {
+		c.logger.Error(err, "http - v1 - createReview")
+		errorResponse(g, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	review, err := c.reviewService.CreateReview(uint32(id), g.GetString("Email"), req.Rating, req.Title, req.Text)
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	g.JSON(http.StatusOK, review)
+}
+
+// getReviews godoc
+// @Description  Returns reviews with pagination, newest first. Without a status, the reviews waiting for
+// @Description  moderation are returned.
+// @Tags         Review
+// @Accept       json
+// @Produce      json
+// @Param page query int false "Page Index"
+// @Param pageSize query int false "Page Size"
+// @Param product_id query int false "Product ID"
+// @Param status query string false "Status" Enums(pending, approved, rejected)
+// @Success 200 {object} pagination.Pages
+// @Failure 400 {object} response
+// @Router /review [get]
+// @Security Bearer
+func (c *Review) GetReviews(g *gin.Context) {
+	filter := entity.ReviewFilter{Status: g.DefaultQuery("status", entity.ReviewStatusPending)}
+	if !entity.IsReviewStatus(filter.Status) {
+		errorResponse(g, http.StatusBadRequest, "unknown status")
+		return
+	}
+	if productId := g.Query("product_id"); len(productId) > 0 {
+		id, err := strconv.Atoi(productId)
+		if err != nil {
+			errorResponse(g, http.StatusBadRequest, "unable to get parameters")
+			return
+		}
+		filter.ProductID = uint32(id)
+	}
+
+	pageIndex, pageSize := pagination.GetPaginationParametersFromRequest(g)
+	items, count := c.reviewService.GetReviews(filter, pageIndex, pageSize)
+	paginatedResult := pagination.NewFromGinRequest(g, count)
+	paginatedResult.Items = items
+
+	g.JSON(http.StatusOK, paginatedResult)
+}
+
+// approveReview godoc
+// @Description  Approves a review, showing it and counting it towards the rating of its product.
+// @Tags         Review
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Review ID"
+// @Success 200 {object} entity.Review
+// @Failure 400 {object} response
+// @Router /review/{id}/approve [patch]
+// @Security Bearer
+func (c *Review) ApproveReview(g *gin.Context) {
+	c.moderate(g, true)
+}
+
+// rejectReview godoc
+// @Description  Rejects a review, also an approved one, taking it out of the rating of its product.
+// @Tags         Review
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Review ID"
+// @Success 200 {object} entity.Review
+// @Failure 400 {object} response
+// @Router /review/{id}/reject [patch]
+// @Security Bearer
+func (c *Review) RejectReview(g *gin.Context) {
+	c.moderate(g, false)
+}
+
+func (c *Review) moderate(g *gin.Context, approved bool) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get id")
+		return
+	}
+
+	review, err := c.reviewService.ModerateReview(uint32(id), approved, g.GetString("Email"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	g.JSON(http.StatusOK, review)
+}
@@ -0,0 +1,299 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"github.com/bestetufan/beste-store/internal/service"
+	"github.com/bestetufan/beste-store/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	Supplier struct {
+		purchasingService service.PurchasingService
+		logger            logger.Logger
+	}
+
+	supplierRequest struct {
+		Name     string `json:"name" binding:"required" example:"Acme Distribution"`
+		Email    string `json:"email" example:"orders@acme.com"`
+		Phone    string `json:"phone"`
+		Currency string `json:"currency" binding:"required" example:"USD"`
+	}
+
+	setSupplierProductRequest struct {
+		SupplierSku string `json:"supplier_sku" example:"AC-1001"`
+		CostPrice   string `json:"cost_price" binding:"required" example:"12.40"`
+		Currency    string `json:"currency"`
+	}
+)
+
+func NewSupplier(ps service.PurchasingService, l logger.Logger) *Supplier {
+	return &Supplier{ps, l}
+}
+
+// getSuppliers godoc
+// @Description  Returns all suppliers.
+// @Tags         Supplier
+// @Accept       json
+// @Produce      json
+// @Success 200 {array} entity.Supplier
+// @Router /supplier [get]
+// @Security Bearer
+func (c *Supplier) GetSuppliers(g *gin.Context) {
+	g.JSON(http.StatusOK, c.purchasingService.GetSuppliers())
+}
+
+// getSupplier godoc
+// @Description  Returns a supplier.
+// @Tags         Supplier
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Supplier ID"
+// @Success 200 {object} entity.Supplier
+// @Failure 400 {object} response
+// @Failure 404 {object} response
+// @Router /supplier/{id} [get]
+// @Security Bearer
+func (c *Supplier) GetSupplier(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get id")
+		return
+	}
+
+	supplier := c.purchasingService.GetSupplier(uint32(id))
+	if supplier == nil {
+		errorResponse(g, http.StatusNotFound, "no record found")
+		return
+	}
+
+	g.JSON(http.StatusOK, supplier)
+}
+
+// createSupplier godoc
+// @Description  Creates a supplier. Its cost prices and purchase orders are in its currency.
+// @Tags         Supplier
+// @Accept       json
+// @Produce      json
+// @Param data body supplierRequest true "Supplier Model"
+// @Success 200 {object} entity.Supplier
+// @Failure 400 {object} response
+// @Router /supplier [post]
+// @Security Bearer
+func (c *Supplier) CreateSupplier(g *gin.Context) {
+	var req supplierRequest
+	if err := g.ShouldBind(&req); err != nil {
+		c.logger.Error(err, "http - v1 - createSupplier")
+		errorResponse(g, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	supplier, err := entity.NewSupplier(req.Name, req.Email, req.Phone, req.Currency)
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := c.purchasingService.CreateSupplier(supplier); err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	g.JSON(http.StatusOK, supplier)
+}
+
+// updateSupplier godoc
+// @Description  Updates a supplier. Existing purchase orders keep their currency.
+// @Tags         Supplier
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Supplier ID"
+// @Param data body supplierRequest true "Supplier Model"
+// @Success 200 {object} entity.Supplier
+// @Failure 400 {object} response
+// @Failure 404 {object} response
+// @Router /supplier/{id} [put]
+// @Security Bearer
+func (c *Supplier) UpdateSupplier(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get id")
+		return
+	}
+
+	var req supplierRequest
+	if err := g.ShouldBind(&req); err != nil {
+		c.logger.Error(err, "http - v1 - updateSupplier")
+		errorResponse(g, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	supplier := c.purchasingService.GetSupplier(uint32(id))
+	if supplier == nil {
+		errorResponse(g, http.StatusNotFound, "no record found")
+		return
+	}
+
+	updated, err := entity.NewSupplier(req.Name, req.Email, req.Phone, req.Currency)
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+	updated.ID, updated.CreatedAt = supplier.ID, supplier.CreatedAt
+
+	if err := c.purchasingService.UpdateSupplier(updated); err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	g.JSON(http.StatusOK, updated)
+}
+
+// deleteSupplier godoc
+// @Description  Deletes a supplier with its cost prices. Suppliers with purchase orders can not be deleted.
+// @Tags         Supplier
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Supplier ID"
+// @Success 200 {object} response
+// @Failure 400 {object} response
+// @Router /supplier/{id} [delete]
+// @Security Bearer
+func (c *Supplier) DeleteSupplier(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get id")
+		return
+	}
+
+	if err := c.purchasingService.DeleteSupplier(uint32(id)); err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	successResponse(g, http.StatusOK, "Operation completed successfully.")
+}
+
+// getSupplierProducts godoc
+// @Description  Returns the products of a supplier with their cost prices.
+// @Tags         Supplier
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Supplier ID"
+// @Success 200 {array} entity.SupplierProduct
+// @Failure 400 {object} response
+// @Router /supplier/{id}/product [get]
+// @Security Bearer
+func (c *Supplier) GetSupplierProducts(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get id")
+		return
+	}
+
+	g.JSON(http.StatusOK, c.purchasingService.GetSupplierProducts(uint32(id)))
+}
+
+// setSupplierProduct godoc
+// @Description  Sets the cost price of a product at a supplier, in the currency of the supplier unless given.
+// @Tags         Supplier
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Supplier ID"
+// @Param        productId path int true "Product ID"
+// @Param data body setSupplierProductRequest true "Supplier Product Model"
+// @Success 200 {object} entity.SupplierProduct
+// @Failure 400 {object} response
+// @Router /supplier/{id}/product/{productId} [put]
+// @Security Bearer
+func (c *Supplier) SetSupplierProduct(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get id")
+		return
+	}
+	productId, err := strconv.Atoi(g.Param("productId"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get product id")
+		return
+	}
+
+	var req setSupplierProductRequest
+	if err := g.ShouldBind(&req); err != nil {
+		c.logger.Error(err, "http - v1 - setSupplierProduct")
+		errorResponse(g, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	supplierProduct, err := c.purchasingService.SetSupplierProduct(uint32(id), uint32(productId), req.SupplierSku,
+		req.CostPrice, req.Currency)
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	g.JSON(http.StatusOK, supplierProduct)
+}
+
+// deleteSupplierProduct godoc
+// @Description  Removes a product from a supplier.
+// @Tags         Supplier
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Supplier ID"
+// @Param        productId path int true "Product ID"
+// @Success 200 {object} response
+// @Failure 400 {object} response
+// @Failure 404 {object} response
+// @Router /supplier/{id}/product/{productId} [delete]
+// @Security Bearer
+func (c *Supplier) DeleteSupplierProduct(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get id")
+		return
+	}
+	productId, err := strconv.Atoi(g.Param("productId"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get product id")
+		return
+	}
+
+	if err := c.purchasingService.DeleteSupplierProduct(uint32(id), uint32(productId)); err != nil {
+		errorResponse(g, http.StatusNotFound, err.Error())
+		return
+	}
+
+	successResponse(g, http.StatusOK, "Operation completed successfully.")
+}
+
+// getProductSuppliers godoc
+// @Description  Returns the suppliers of a product with their cost prices and the margin of the product
+// @Description  at its current price, in the currency of the product.
+// @Tags         Supplier
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Product ID"
+// @Success 200 {array} service.ProductSupplier
+// @Failure 400 {object} response
+// @Failure 404 {object} response
+// @Router /product/{id}/supplier [get]
+// @Security Bearer
+func (c *Supplier) GetProductSuppliers(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get id")
+		return
+	}
+
+	suppliers, err := c.purchasingService.GetProductSuppliers(uint32(id))
+	if err != nil {
+		errorResponse(g, http.StatusNotFound, err.Error())
+		return
+	}
+
+	g.JSON(http.StatusOK, suppliers)
+}
@@ -0,0 +1,215 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"github.com/bestetufan/beste-store/internal/service"
+	"github.com/bestetufan/beste-store/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	Translation struct {
+		localizationService service.LocalizationService
+		logger              logger.Logger
+	}
+
+	translationRequest struct {
+		Fields map[string]string `json:"fields" binding:"required"`
+	}
+
+	translationResponse struct {
+		Locale    string            `json:"locale" example:"en"`
+		Fields    map[string]string `json:"fields"`
+		UserName  string            `json:"user_name"`
+		UpdatedAt time.Time         `json:"updated_at"`
+	}
+
+	localesResponse struct {
+		Default string   `json:"default" example:"tr"`
+		Locales []string `json:"locales"`
+	}
+)
+
+func NewTranslation(ls service.LocalizationService, l logger.Logger) *Translation {
+	return &Translation{ls, l}
+}
+
+// getLocales godoc
+// @Description  Returns the locales catalog content is translated in, the default one first.
+// @Tags         Translation
+// @Accept       json
+// @Produce      json
+// @Success 200 {object} localesResponse
+// @Router /locale [get]
+func (c *Translation) GetLocales(g *gin.Context) {
+	locales := c.localizationService.Locales()
+	g.JSON(http.StatusOK, localesResponse{Default: locales[0], Locales: locales})
+}
+
+// getProductTranslations godoc
+// @Description  Returns the translations of a product by locale.
+// @Tags         Translation
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Product ID"
+// @Success 200 {array} translationResponse
+// @Failure 400 {object} response
+// @Router /product/{id}/translation [get]
+// @Security Bearer
+func (c *Translation) GetProductTranslations(g *gin.Context) {
+	c.get(g, entity.TranslationProduct)
+}
+
+// setProductTranslation godoc
+// @Description  Replaces the translations of a product in a locale other than the default one. Only the name is
+// @Description  translatable; products are shown in the default locale where they have no translation.
+// @Tags         Translation
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Product ID"
+// @Param        locale path string true "Locale" example(en)
+// @Param data body translationRequest true "Translation Model"
+// @Success 200 {object} translationResponse
+// @Failure 400 {object} response
+// @Router /product/{id}/translation/{locale} [put]
+// @Security Bearer
+func (c *Translation) SetProductTranslation(g *gin.Context) {
+	c.set(g, entity.TranslationProduct)
+}
+
+// deleteProductTranslation godoc
+// @Description  Removes the translations of a product in a locale.
+// @Tags         Translation
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Product ID"
+// @Param        locale path string true "Locale" example(en)
+// @Success 200 {object} response
+// @Failure 400 {object} response
+// @Router /product/{id}/translation/{locale} [delete]
+// @Security Bearer
+func (c *Translation) DeleteProductTranslation(g *gin.Context) {
+	c.delete(g, entity.TranslationProduct)
+}
+
+// getCategoryTranslations godoc
+// @Description  Returns the translations of a category by locale.
+// @Tags         Translation
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Category ID"
+// @Success 200 {array} translationResponse
+// @Failure 400 {object} response
+// @Router /category/{id}/translation [get]
+// @Security Bearer
+func (c *Translation) GetCategoryTranslations(g *gin.Context) {
+	c.get(g, entity.TranslationCategory)
+}
+
+// setCategoryTranslation godoc
+// @Description  Replaces the translations of a category in a locale other than the default one. Only the name is
+// @Description  translatable; categories are shown in the default locale where they have no translation.
+// @Tags         Translation
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Category ID"
+// @Param        locale path string true "Locale" example(en)
+// @Param data body translationRequest true "Translation Model"
+// @Success 200 {object} translationResponse
+// @Failure 400 {object} response
+// @Router /category/{id}/translation/{locale} [put]
+// @Security Bearer
+func (c *Translation) SetCategoryTranslation(g *gin.Context) {
+	c.set(g, entity.TranslationCategory)
+}
+
+// deleteCategoryTranslation godoc
+// @Description  Removes the translations of a category in a locale.
+// @Tags         Translation
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Category ID"
+// @Param        locale path string true "Locale" example(en)
+// @Success 200 {object} response
+// @Failure 400 {object} response
+// @Router /category/{id}/translation/{locale} [delete]
+// @Security Bearer
+func (c *Translation) DeleteCategoryTranslation(g *gin.Context) {
+	c.delete(g, entity.TranslationCategory)
+}
+
+func (c *Translation) get(g *gin.Context, entityType string) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get id")
+		return
+	}
+
+	translations, err := c.localizationService.GetTranslations(entityType, uint32(id))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	g.JSON(http.StatusOK, newTranslationResponses(translations))
+}
+
+func (c *Translation) set(g *gin.Context, entityType string) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get id")
+		return
+	}
+
+	var req translationRequest
+	if err := g.ShouldBind(&req); err != nil {
+		c.logger.Error(err, "http - v1 - setTranslation")
+		errorResponse(g, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	translations, err := c.localizationService.SetTranslation(entityType, uint32(id), g.Param("locale"), req.Fields,
+		g.GetString("Email"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	g.JSON(http.StatusOK, newTranslationResponses(translations)[0])
+}
+
+func (c *Translation) delete(g *gin.Context, entityType string) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get id")
+		return
+	}
+
+	if err := c.localizationService.DeleteTranslation(entityType, uint32(id), g.Param("locale")); err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	successResponse(g, http.StatusOK, "Operation completed successfully.")
+}
+
+// newTranslationResponses groups translations ordered by locale into one
+// response per locale.
+func newTranslationResponses(translations []entity.Translation) []translationResponse {
+	responses := []translationResponse{}
+	for _, t := range translations {
+		if len(responses) == 0 || responses[len(responses)-1].Locale != t.Locale {
+			responses = append(responses, translationResponse{Locale: t.Locale, Fields: map[string]string{}})
+		}
+		last := &responses[len(responses)-1]
+		last.Fields[t.Field] = t.Value
+		if !t.UpdatedAt.Before(last.UpdatedAt) {
+			last.UserName, last.UpdatedAt = t.UserName, t.UpdatedAt
+		}
+	}
+	return responses
+}
@@ -0,0 +1,161 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/bestetufan/beste-store/internal/service"
+	"github.com/bestetufan/beste-store/pkg/logger"
+	"github.com/bestetufan/beste-store/pkg/pagination"
+	"github.com/gin-gonic/gin"
+)
+
+type Trash struct {
+	trashService service.TrashService
+	logger       logger.Logger
+}
+
+func NewTrash(ts service.TrashService, l logger.Logger) *Trash {
+	return &Trash{ts, l}
+}
+
+// getDeletedProducts godoc
+// @Description  Returns deleted products with pagination, most recently deleted first.
+// @Tags         Trash
+// @Accept       json
+// @Produce      json
+// @Param page query int false "Page Index"
+// @Param pageSize query int false "Page Size"
+// @Success 200 {object} pagination.Pages
+// @Failure 500 {object} response
+// @Router /trash/product [get]
+// @Security Bearer
+func (c *Trash) GetDeletedProducts(g *gin.Context) {
+	pageIndex, pageSize := pagination.GetPaginationParametersFromRequest(g)
+	items, count := c.trashService.GetDeletedProducts(pageIndex, pageSize)
+	paginatedResult := pagination.NewFromGinRequest(g, count)
+	paginatedResult.Items = items
+
+	g.JSON(http.StatusOK, paginatedResult)
+}
+
+// getDeletedCategories godoc
+// @Description  Returns deleted categories with pagination, most recently deleted first.
+// @Tags         Trash
+// @Accept       json
+// @Produce      json
+// @Param page query int false "Page Index"
+// @Param pageSize query int false "Page Size"
+// @Success 200 {object} pagination.Pages
+// @Failure 500 {object} response
+// @Router /trash/category [get]
+// @Security Bearer
+func (c *Trash) GetDeletedCategories(g *gin.Context) {
+	pageIndex, pageSize := pagination.GetPaginationParametersFromRequest(g)
+	items, count := c.trashService.GetDeletedCategories(pageIndex, pageSize)
+	paginatedResult := pagination.NewFromGinRequest(g, count)
+	paginatedResult.Items = items
+
+	g.JSON(http.StatusOK, paginatedResult)
+}
+
+// getDeletedUsers godoc
+// @Description  Returns deleted users with pagination, most recently deleted first.
+// @Tags         Trash
+// @Accept       json
+// @Produce      json
+// @Param page query int false "Page Index"
+// @Param pageSize query int false "Page Size"
+// @Success 200 {object} pagination.Pages
+// @Failure 500 {object} response
+// @Router /trash/user [get]
+// @Security Bearer
+func (c *Trash) GetDeletedUsers(g *gin.Context) {
+	pageIndex, pageSize := pagination.GetPaginationParametersFromRequest(g)
+	items, count := c.trashService.GetDeletedUsers(pageIndex, pageSize)
+	paginatedResult := pagination.NewFromGinRequest(g, count)
+	paginatedResult.Items = items
+
+	g.JSON(http.StatusOK, paginatedResult)
+}
+
+// restoreProduct godoc
+// @Description  Restores a deleted product. Its category must not be in the trash.
+// @Tags         Trash
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Product ID"
+// @Success 200 {object} response
+// @Failure 400 {object} response
+// @Failure 404 {object} response
+// @Router /trash/product/{id}/restore [patch]
+// @Security Bearer
+func (c *Trash) RestoreProduct(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get id")
+		return
+	}
+
+	err = c.trashService.RestoreProduct(uint32(id), g.GetString("Email"))
+	if err != nil {
+		errorResponse(g, http.StatusNotFound, err.Error())
+		return
+	}
+
+	successResponse(g, http.StatusOK, "Operation completed successfully.")
+}
+
+// restoreCategory godoc
+// @Description  Restores a deleted category.
+// @Tags         Trash
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Category ID"
+// @Success 200 {object} response
+// @Failure 400 {object} response
+// @Failure 404 {object} response
+// @Router /trash/category/{id}/restore [patch]
+// @Security Bearer
+func (c *Trash) RestoreCategory(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get id")
+		return
+	}
+
+	err = c.trashService.RestoreCategory(uint32(id))
+	if err != nil {
+		errorResponse(g, http.StatusNotFound, err.Error())
+		return
+	}
+
+	successResponse(g, http.StatusOK, "Operation completed successfully.")
+}
+
+// restoreUser godoc
+// @Description  Restores a deleted user.
+// @Tags         Trash
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "User ID"
+// @Success 200 {object} response
+// @Failure 400 {object} response
+// @Failure 404 {object} response
+// @Router /trash/user/{id}/restore [patch]
+// @Security Bearer
+func (c *Trash) RestoreUser(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get id")
+		return
+	}
+
+	err = c.trashService.RestoreUser(id)
+	if err != nil {
+		errorResponse(g, http.StatusNotFound, err.Error())
+		return
+	}
+
+	successResponse(g, http.StatusOK, "Operation completed successfully.")
+}
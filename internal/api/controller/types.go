@@ -1,6 +1,11 @@
 package controller
 
 import (
+	"strings"
+	"time"
+
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"github.com/bestetufan/beste-store/internal/service"
 	"github.com/gin-gonic/gin"
 )
 
@@ -15,3 +20,45 @@ func errorResponse(c *gin.Context, code int, msg string) {
 func successResponse(c *gin.Context, code int, msg string) {
 	c.JSON(code, response{msg})
 }
+
+// requestCurrency returns the currency prices are asked in, from the currency
+// query parameter or the X-Currency header. It is empty when prices are to be
+// returned in their own currency.
+func requestCurrency(c *gin.Context) string {
+	if currency := c.Query("currency"); len(currency) > 0 {
+		return strings.ToUpper(currency)
+	}
+	return strings.ToUpper(c.GetHeader("X-Currency"))
+}
+
+// requestLocale returns the locale of the response, negotiated from the lang
+// query parameter or the Accept-Language header, and sets the
+// Content-Language header to it.
+func requestLocale(c *gin.Context, s service.LocalizationService) string {
+	language := c.Query("lang")
+	if len(language) == 0 {
+		language = c.GetHeader("Accept-Language")
+	}
+	locale := s.Negotiate(language)
+	c.Header("Content-Language", locale)
+	c.Header("Vary", "Accept-Language")
+	return locale
+}
+
+// hasRole reports whether the user is in role, as recorded by the
+// IdentifyRole middleware.
+func hasRole(c *gin.Context, role string) bool {
+	return c.GetBool("Role:" + role)
+}
+
+// expiryDate reads an optional expiry date of a lot such as "2026-12-31".
+func expiryDate(date string) (*time.Time, error) {
+	if len(date) == 0 {
+		return nil, nil
+	}
+	expiresAt, err := entity.ParseExpiryDate(date)
+	if err != nil {
+		return nil, err
+	}
+	return &expiresAt, nil
+}
@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/bestetufan/beste-store/internal/service"
+	"github.com/bestetufan/beste-store/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+type User struct {
+	userService service.UserService
+	logger      logger.Logger
+}
+
+func NewUser(us service.UserService, l logger.Logger) *User {
+	return &User{us, l}
+}
+
+// deleteUser godoc
+// @Description  Moves a user to the trash. Deleted users can not log in until restored.
+// @Tags         User
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "User ID"
+// @Success 200 {object} response
+// @Failure 400 {object} response
+// @Failure 500 {object} response
+// @Router /user/{id} [delete]
+// @Security Bearer
+func (c *User) DeleteUser(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get id")
+		return
+	}
+
+	if id == g.GetInt("UserId") {
+		errorResponse(g, http.StatusBadRequest, "unable to delete own account")
+		return
+	}
+
+	err = c.userService.DeleteUser(id)
+	if err != nil {
+		c.logger.Error(err, "http - v1 - deleteUser")
+		errorResponse(g, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	successResponse(g, http.StatusOK, "Operation completed successfully.")
+}
@@ -0,0 +1,207 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"github.com/bestetufan/beste-store/internal/service"
+	"github.com/bestetufan/beste-store/pkg/logger"
+	"github.com/bestetufan/beste-store/pkg/measure"
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	Wishlist struct {
+		wishlistService service.WishlistService
+		exchangeService service.ExchangeService
+		logger          logger.Logger
+	}
+
+	wishlistItemRequest struct {
+		ProductId uint32 `json:"product_id" binding:"required"`
+	}
+
+	moveWishlistItemRequest struct {
+		ProductId uint32           `json:"product_id" binding:"required"`
+		Quantity  measure.Quantity `json:"quantity" swaggertype:"number"`
+	}
+
+	wishlistItemResponse struct {
+		ProductID uint32           `json:"product_id"`
+		Product   *entity.Product  `json:"product"`
+		Available measure.Quantity `json:"available" swaggertype:"number"`
+		CreatedAt time.Time        `json:"created_at"`
+	}
+)
+
+func NewWishlist(ws service.WishlistService, es service.ExchangeService, l logger.Logger) *Wishlist {
+	return &Wishlist{ws, es, l}
+}
+
+// getWishlist godoc
+// @Description  Returns user's wishlist, last added first, with the quantity of each product available.
+// @Tags         Wishlist
+// @Accept       json
+// @Produce      json
+// @Param currency query string false "Currency of prices"
+// @Param X-Currency header string false "Currency of prices"
+// @Success 200 {array} wishlistItemResponse
+// @Failure 400 {object} response
+// @Router /wishlist [get]
+// @Security Bearer
+func (c *Wishlist) GetWishlist(g *gin.Context) {
+	userName := g.GetString("Email")
+	if len(userName) == 0 {
+		errorResponse(g, http.StatusBadRequest, "unable to get parameters")
+		return
+	}
+
+	items := c.wishlistService.GetWishlist(userName)
+	response := make([]wishlistItemResponse, 0, len(items))
+	for _, item := range items {
+		if item.Product == nil {
+			continue
+		}
+		if err := c.exchangeService.ConvertProduct(item.Product, requestCurrency(g)); err != nil {
+			errorResponse(g, http.StatusBadRequest, err.Error())
+			return
+		}
+		response = append(response, wishlistItemResponse{
+			ProductID: item.ProductID, Product: item.Product, Available: item.Product.Available(),
+			CreatedAt: item.CreatedAt})
+	}
+
+	g.JSON(http.StatusOK, response)
+}
+
+// addWishlistItem godoc
+// @Description  Adds a product to wishlist, also when it is out of stock.
+// @Tags         Wishlist
+// @Accept       json
+// @Produce      json
+// @Param data body wishlistItemRequest true "Wishlist Item Model"
+// @Success 200 {object} response
+// @Failure 400 {object} response
+// @Router /wishlist [post]
+// @Security Bearer
+func (c *Wishlist) AddWishlistItem(g *gin.Context) {
+	var req wishlistItemRequest
+	if err := g.ShouldBind(&req); err != nil {
+		c.logger.Error(err, "http - v1 - addWishlistItem")
+		errorResponse(g, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := c.wishlistService.AddItem(g.GetString("Email"), req.ProductId); err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	successResponse(g, http.StatusOK, "Operation completed successfully.")
+}
+
+// removeWishlistItem godoc
+// @Description  Removes a product from wishlist.
+// @Tags         Wishlist
+// @Accept       json
+// @Produce      json
+// @Param data body wishlistItemRequest true "Wishlist Item Model"
+// @Success 200 {object} response
+// @Failure 400 {object} response
+// @Router /wishlist [delete]
+// @Security Bearer
+func (c *Wishlist) RemoveWishlistItem(g *gin.Context) {
+	var req wishlistItemRequest
+	if err := g.ShouldBind(&req); err != nil {
+		c.logger.Error(err, "http - v1 - removeWishlistItem")
+		errorResponse(g, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := c.wishlistService.RemoveItem(g.GetString("Email"), req.ProductId); err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	successResponse(g, http.StatusOK, "Operation completed successfully.")
+}
+
+// moveWishlistItemToBasket godoc
+// @Description  Adds a product of wishlist to basket and removes it from wishlist. It stays in wishlist when it
+// @Description  can not be added, e.g. when there is not enough stock.
+// @Tags         Wishlist
+// @Accept       json
+// @Produce      json
+// @Param data body moveWishlistItemRequest true "Move Wishlist Item Model"
+// @Success 200 {object} response
+// @Failure 400 {object} response
+// @Router /wishlist/basket [post]
+// @Security Bearer
+func (c *Wishlist) MoveWishlistItemToBasket(g *gin.Context) {
+	var req moveWishlistItemRequest
+	if err := g.ShouldBind(&req); err != nil {
+		c.logger.Error(err, "http - v1 - moveWishlistItemToBasket")
+		errorResponse(g, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	err := c.wishlistService.MoveToBasket(g.GetString("Email"), req.ProductId, req.Quantity)
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	successResponse(g, http.StatusOK, "Operation completed successfully.")
+}
+
+// subscribeBackInStock godoc
+// @Description  Asks for a notification when a product that is out of stock is available again.
+// @Tags         Wishlist
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Product ID"
+// @Success 200 {object} response
+// @Failure 400 {object} response
+// @Router /product/{id}/back-in-stock [post]
+// @Security Bearer
+func (c *Wishlist) SubscribeBackInStock(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get id")
+		return
+	}
+
+	if err := c.wishlistService.Subscribe(g.GetString("Email"), uint32(id)); err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	successResponse(g, http.StatusOK, "Operation completed successfully.")
+}
+
+// unsubscribeBackInStock godoc
+// @Description  Cancels the back in stock notification of a product.
+// @Tags         Wishlist
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Product ID"
+// @Success 200 {object} response
+// @Failure 400 {object} response
+// @Router /product/{id}/back-in-stock [delete]
+// @Security Bearer
+func (c *Wishlist) UnsubscribeBackInStock(g *gin.Context) {
+	id, err := strconv.Atoi(g.Param("id"))
+	if err != nil {
+		errorResponse(g, http.StatusBadRequest, "unable to get id")
+		return
+	}
+
+	if err := c.wishlistService.Unsubscribe(g.GetString("Email"), uint32(id)); err != nil {
+		errorResponse(g, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	successResponse(g, http.StatusOK, "Operation completed successfully.")
+}
@@ -48,6 +48,7 @@ func (m *JWTAuthMiddleware) CheckRole(role string) gin.HandlerFunc {
 		userId := c.GetInt("UserId")
 		userIsInRole := m.userService.UserHasRole(userId, role)
 		if userIsInRole {
+			c.Set("Role:"+role, true)
 			c.Next()
 			return
 		}
@@ -57,3 +58,13 @@ func (m *JWTAuthMiddleware) CheckRole(role string) gin.HandlerFunc {
 		c.Abort()
 	}
 }
+
+// IdentifyRole records in the context whether the user is in role, as
+// "Role:<role>" like CheckRole does, without rejecting anyone. It is for endpoints that show
+// more to some roles.
+func (m *JWTAuthMiddleware) IdentifyRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("Role:"+role, m.userService.UserHasRole(c.GetInt("UserId"), role))
+		c.Next()
+	}
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/bestetufan/beste-store/pkg/httpserver"
+	"github.com/gin-gonic/gin"
+)
+
+// ReadTimeout lets the requests of a route take up to timeout to send their
+// body instead of the read timeout of the server, for file uploads. A zero
+// timeout keeps the one of the server.
+func ReadTimeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout > 0 {
+			_ = httpserver.SetReadDeadline(c.Request, time.Now().Add(timeout))
+		}
+		c.Next()
+	}
+}
+
+// WriteTimeout lets the requests of a route take up to timeout to write
+// their response instead of the write timeout of the server, for streamed
+// exports and downloads. A zero timeout keeps the one of the server.
+func WriteTimeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout > 0 {
+			_ = httpserver.SetWriteDeadline(c.Request, time.Now().Add(timeout))
+		}
+		c.Next()
+	}
+}
@@ -1,6 +1,7 @@
 package router
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -16,6 +17,9 @@ import (
 	"github.com/bestetufan/beste-store/internal/domain/repo"
 	"github.com/bestetufan/beste-store/internal/service"
 	"github.com/bestetufan/beste-store/pkg/logger"
+	"github.com/bestetufan/beste-store/pkg/notifier"
+	"github.com/bestetufan/beste-store/pkg/scheduler"
+	"github.com/bestetufan/beste-store/pkg/storage"
 )
 
 // NewRouter -.
@@ -28,7 +32,8 @@ import (
 // @securityDefinitions.apikey Bearer
 // @in header
 // @name Authorization
-func NewRouter(handler *gin.Engine, l *logger.Logger, c *config.Config, db *gorm.DB) {
+func NewRouter(handler *gin.Engine, l *logger.Logger, c *config.Config, db *gorm.DB, st storage.Storage,
+	files storage.Storage, n notifier.Notifier, sch *scheduler.Scheduler) {
 	// Options
 	handler.Use(gin.Logger())
 	handler.Use(gin.Recovery())
@@ -40,27 +45,128 @@ func NewRouter(handler *gin.Engine, l *logger.Logger, c *config.Config, db *gorm
 	// Health probe
 	handler.GET("/healthz", func(c *gin.Context) { c.Status(http.StatusOK) })
 
+	// Media files
+	if local, ok := st.(*storage.Local); ok {
+		handler.Static("/media", local.Root())
+	}
+
 	// Repo
 	userRepo := repo.NewUserRepository(db)
 	categoryRepo := repo.NewCategoryRepository(db)
+	attributeRepo := repo.NewAttributeRepository(db)
 	productRepo := repo.NewProductRepository(db)
+	productImageRepo := repo.NewProductImageRepository(db)
+	salePriceRepo := repo.NewSalePriceRepository(db)
 	basketRepo := repo.NewBasketRepository(db)
 	orderRepo := repo.NewOrderRepository(db)
+	exchangeRateRepo := repo.NewExchangeRateRepository(db)
+	warehouseRepo := repo.NewWarehouseRepository(db)
+	stockRepo := repo.NewStockRepository(db)
+	stockAlertRepo := repo.NewStockAlertRepository(db)
+	supplierRepo := repo.NewSupplierRepository(db)
+	purchaseOrderRepo := repo.NewPurchaseOrderRepository(db)
+	downloadRepo := repo.NewDownloadRepository(db)
+	reviewRepo := repo.NewReviewRepository(db)
+	recommendationRepo := repo.NewRecommendationRepository(db)
+	wishlistRepo := repo.NewWishlistRepository(db)
+	translationRepo := repo.NewTranslationRepository(db)
 
 	// Service
 	authService := service.NewJWTAuthService(*c)
 	userService := service.NewUserService(*userRepo)
-	storeService := service.NewStoreService(*categoryRepo, *productRepo, *basketRepo, *orderRepo)
+	mediaService := service.NewMediaService(st, *productImageRepo, *productRepo, c.MediaMaxSize, c.MediaThumbnailSize)
+	exchangeService := service.NewExchangeService(*exchangeRateRepo, c.DefaultCurrency)
+	localizationService := service.NewLocalizationService(*translationRepo, *productRepo, *categoryRepo,
+		c.DefaultLocale, c.Locales)
+	downloadService := service.NewDownloadService(files, *downloadRepo, *productRepo, c.DownloadBaseURL,
+		c.DownloadSecret, c.DownloadTTL, c.DownloadMaxCount, c.DownloadMaxSize)
+	stockAlertService := service.NewStockAlertService(*productRepo, *stockAlertRepo, n)
+	inventoryService := service.NewInventoryService(*warehouseRepo, *stockRepo, *productRepo, *stockAlertService,
+		c.ReservationTTL)
+	storeService := service.NewStoreService(*categoryRepo, *attributeRepo, *productRepo, *salePriceRepo, *basketRepo,
+		*orderRepo, *inventoryService, *exchangeService, *downloadService, c.DefaultCurrency)
+	purchasingService := service.NewPurchasingService(*supplierRepo, *purchaseOrderRepo, *productRepo,
+		*inventoryService, *exchangeService)
+	reviewService := service.NewReviewService(*reviewRepo, *productRepo, *orderRepo)
+	recommendationService := service.NewRecommendationService(*recommendationRepo, *productRepo,
+		c.RecommendationMinCount, c.RecommendationLimit)
+	wishlistService := service.NewWishlistService(*wishlistRepo, *productRepo, *storeService, n)
+	trashService := service.NewTrashService(*categoryRepo, *productRepo, *userRepo, *mediaService, *downloadService)
 
 	// Controller
 	auth := controller.NewAuth(*userService, *authService, *l)
-	category := controller.NewCategory(*storeService, *l)
-	product := controller.NewProduct(*storeService, *l)
-	basket := controller.NewBasket(*storeService, *l)
-	order := controller.NewOrder(*storeService, *l)
+	category := controller.NewCategory(*storeService, *localizationService, *l)
+	product := controller.NewProduct(*storeService, *mediaService, *downloadService, *recommendationService,
+		*exchangeService, *localizationService, *l)
+	basket := controller.NewBasket(*storeService, *exchangeService, *l)
+	order := controller.NewOrder(*storeService, *exchangeService, *l)
+	export := controller.NewExport(*storeService, *l)
+	user := controller.NewUser(*userService, *l)
+	trash := controller.NewTrash(*trashService, *l)
+	exchangeRate := controller.NewExchangeRate(*exchangeService, *l)
+	inventory := controller.NewInventory(*inventoryService, *stockAlertService, *l)
+	supplier := controller.NewSupplier(*purchasingService, *l)
+	purchaseOrder := controller.NewPurchaseOrder(*purchasingService, *l)
+	download := controller.NewDownload(*downloadService, *l)
+	review := controller.NewReview(*reviewService, *l)
+	wishlist := controller.NewWishlist(*wishlistService, *exchangeService, *l)
+	translation := controller.NewTranslation(*localizationService, *l)
+
+	// Jobs
+	sch.Every("trash purge", c.TrashPurgeInterval, func(ctx context.Context) error {
+		count, err := trashService.Purge(c.TrashRetention)
+		if count > 0 {
+			l.Info("trash purge - %d records removed", count)
+		}
+		return err
+	})
+	sch.Every("product publishing", c.ProductPublishInterval, func(ctx context.Context) error {
+		count, err := storeService.PublishScheduled()
+		if count > 0 {
+			l.Info("product publishing - %d products published", count)
+		}
+		return err
+	})
+	sch.Every("reservation sweep", c.ReservationSweepInterval, func(ctx context.Context) error {
+		count, err := inventoryService.ReleaseExpired()
+		if count > 0 {
+			l.Info("reservation sweep - %d reservations released", count)
+		}
+		return err
+	})
+	sch.Every("lot expiry", c.LotExpiryInterval, func(ctx context.Context) error {
+		count, err := inventoryService.ExpireLots()
+		if count > 0 {
+			l.Info("lot expiry - %d lots written off", count)
+		}
+		return err
+	})
+	sch.Every("stock alerts", c.StockAlertInterval, func(ctx context.Context) error {
+		count, err := stockAlertService.CheckQueued(ctx)
+		if count > 0 {
+			l.Info("stock alerts - %d alerts sent", count)
+		}
+		return err
+	})
+	sch.Every("back in stock", c.BackInStockInterval, func(ctx context.Context) error {
+		count, err := wishlistService.NotifyBackInStock(ctx)
+		if count > 0 {
+			l.Info("back in stock - %d notifications sent", count)
+		}
+		return err
+	})
+	sch.Every("recommendations", c.RecommendationInterval, func(ctx context.Context) error {
+		count, err := recommendationService.Compute()
+		if count > 0 {
+			l.Info("recommendations - %d recommendations computed", count)
+		}
+		return err
+	})
 
 	// Middleware
 	authMw := middleware.NewJWTAuthMiddleware(*authService, *userService, *l)
+	upload := middleware.ReadTimeout(c.HTTPUploadTimeout)
+	stream := middleware.WriteTimeout(c.HTTPStreamTimeout)
 
 	// Routers
 	h := handler.Group("/api/v1")
@@ -70,22 +176,58 @@ func NewRouter(handler *gin.Engine, l *logger.Logger, c *config.Config, db *gorm
 			a.POST("/login", auth.Login)
 			a.POST("/register", auth.Register)
 		}
+		h.GET("/locale", translation.GetLocales)
 		c := h.Group("/category", authMw.ValidateToken())
 		{
 			c.GET("", category.GetAllCategories)
 			c.GET(":id", category.GetCategory)
 			c.POST("", authMw.CheckRole("admin"), category.CreateCategory)
-			c.DELETE("", authMw.CheckRole("admin"), category.DeleteCategory)
-			c.POST("/bulk", authMw.CheckRole("admin"), category.CreateBulkCategory)
+			c.DELETE(":id", authMw.CheckRole("admin"), category.DeleteCategory)
+			c.POST("/bulk", upload, authMw.CheckRole("admin"), category.CreateBulkCategory)
+			c.GET(":id/attribute", category.GetCategoryAttributes)
+			c.POST(":id/attribute", authMw.CheckRole("admin"), category.CreateAttribute)
+			c.DELETE(":id/attribute/:attributeId", authMw.CheckRole("admin"), category.DeleteAttribute)
+			c.GET(":id/translation", authMw.CheckRole("admin"), translation.GetCategoryTranslations)
+			c.PUT(":id/translation/:locale", authMw.CheckRole("admin"), translation.SetCategoryTranslation)
+			c.DELETE(":id/translation/:locale", authMw.CheckRole("admin"), translation.DeleteCategoryTranslation)
 		}
-		p := h.Group("/product", authMw.ValidateToken())
+		p := h.Group("/product", authMw.ValidateToken(), authMw.IdentifyRole("admin"))
 		{
 			p.GET("", product.GetAllProducts)
 			p.GET(":id", product.GetProduct)
+			p.GET(":id/history", authMw.CheckRole("admin"), product.GetProductHistory)
+			p.GET(":id/price-history", product.GetPriceHistory)
+			p.GET(":id/bought-together", product.GetBoughtTogether)
+			p.GET(":id/also-bought", product.GetAlsoBought)
+			p.GET(":id/stock", authMw.CheckRole("admin"), inventory.GetStockLevels)
+			p.GET(":id/lot", authMw.CheckRole("admin"), inventory.GetStockLots)
+			p.GET(":id/serial", authMw.CheckRole("admin"), inventory.GetSerialNumbers)
+			p.GET(":id/supplier", authMw.CheckRole("admin"), supplier.GetProductSuppliers)
 			p.GET("/search/:query", product.SearchProducts)
+			p.GET("/barcode/:code", product.GetProductByBarcode)
+			p.GET("/label", authMw.CheckRole("admin"), product.GetProductLabels)
 			p.POST("", authMw.CheckRole("admin"), product.CreateProduct)
+			p.POST("/bulk", upload, authMw.CheckRole("admin"), product.CreateBulkProduct)
 			p.PUT(":id", authMw.CheckRole("admin"), product.UpdateProduct)
 			p.DELETE(":id", authMw.CheckRole("admin"), product.DeleteProduct)
+			p.PATCH(":id/status", authMw.CheckRole("admin"), product.SetProductStatus)
+			p.PUT(":id/bundle", authMw.CheckRole("admin"), product.SetProductBundle)
+			p.DELETE(":id/bundle", authMw.CheckRole("admin"), product.RemoveProductBundle)
+			p.POST(":id/image", upload, authMw.CheckRole("admin"), product.UploadProductImage)
+			p.POST(":id/file", upload, authMw.CheckRole("admin"), product.UploadProductFile)
+			p.PUT(":id/image/order", authMw.CheckRole("admin"), product.ReorderProductImages)
+			p.PATCH(":id/image/:imageId/primary", authMw.CheckRole("admin"), product.SetPrimaryProductImage)
+			p.DELETE(":id/image/:imageId", authMw.CheckRole("admin"), product.DeleteProductImage)
+			p.GET(":id/sale-price", product.GetSalePrices)
+			p.POST(":id/sale-price", authMw.CheckRole("admin"), product.CreateSalePrice)
+			p.DELETE(":id/sale-price/:salePriceId", authMw.CheckRole("admin"), product.DeleteSalePrice)
+			p.GET(":id/translation", authMw.CheckRole("admin"), translation.GetProductTranslations)
+			p.PUT(":id/translation/:locale", authMw.CheckRole("admin"), translation.SetProductTranslation)
+			p.DELETE(":id/translation/:locale", authMw.CheckRole("admin"), translation.DeleteProductTranslation)
+			p.GET(":id/review", review.GetProductReviews)
+			p.POST(":id/review", review.CreateReview)
+			p.POST(":id/back-in-stock", wishlist.SubscribeBackInStock)
+			p.DELETE(":id/back-in-stock", wishlist.UnsubscribeBackInStock)
 		}
 		b := h.Group("/basket", authMw.ValidateToken())
 		{
@@ -94,11 +236,93 @@ func NewRouter(handler *gin.Engine, l *logger.Logger, c *config.Config, db *gorm
 			b.PUT("", basket.UpdateBasketItem)
 			b.DELETE("", basket.RemoveBasketItem)
 		}
+		wl := h.Group("/wishlist", authMw.ValidateToken())
+		{
+			wl.GET("", wishlist.GetWishlist)
+			wl.POST("", wishlist.AddWishlistItem)
+			wl.DELETE("", wishlist.RemoveWishlistItem)
+			wl.POST("/basket", wishlist.MoveWishlistItemToBasket)
+		}
 		o := h.Group("/order", authMw.ValidateToken())
 		{
 			o.GET("", order.GetAllOrders)
 			o.POST("", order.CreateOrder)
 			o.PATCH(":id/cancel", order.CancelOrder)
+			o.PATCH(":id/fulfill", authMw.CheckRole("admin"), order.FulfillOrder)
+			o.PATCH(":id/deliver", authMw.CheckRole("admin"), order.DeliverOrder)
+			o.GET("/serial/:serial", authMw.CheckRole("admin"), order.GetOrderBySerial)
+		}
+		u := h.Group("/user", authMw.ValidateToken(), authMw.CheckRole("admin"))
+		{
+			u.DELETE(":id", user.DeleteUser)
+		}
+		t := h.Group("/trash", authMw.ValidateToken(), authMw.CheckRole("admin"))
+		{
+			t.GET("/product", trash.GetDeletedProducts)
+			t.GET("/category", trash.GetDeletedCategories)
+			t.GET("/user", trash.GetDeletedUsers)
+			t.PATCH("/product/:id/restore", trash.RestoreProduct)
+			t.PATCH("/category/:id/restore", trash.RestoreCategory)
+			t.PATCH("/user/:id/restore", trash.RestoreUser)
+		}
+		e := h.Group("/export", stream, authMw.ValidateToken(), authMw.CheckRole("admin"))
+		{
+			e.GET("/product", export.ExportProducts)
+			e.GET("/category", export.ExportCategories)
+			e.GET("/order", export.ExportOrders)
+		}
+		r := h.Group("/exchange-rate", authMw.ValidateToken())
+		{
+			r.GET("", exchangeRate.GetExchangeRates)
+			r.PUT(":currency", authMw.CheckRole("admin"), exchangeRate.SetExchangeRate)
+			r.DELETE(":currency", authMw.CheckRole("admin"), exchangeRate.DeleteExchangeRate)
+			r.POST("/bulk", upload, authMw.CheckRole("admin"), exchangeRate.ImportExchangeRates)
+		}
+		w := h.Group("/warehouse", authMw.ValidateToken(), authMw.CheckRole("admin"))
+		{
+			w.GET("", inventory.GetWarehouses)
+			w.POST("", inventory.CreateWarehouse)
+			w.PATCH(":id/default", inventory.SetDefaultWarehouse)
+		}
+		s := h.Group("/stock", authMw.ValidateToken(), authMw.CheckRole("admin"))
+		{
+			s.GET("/movement", inventory.GetStockMovements)
+			s.GET("/low", inventory.GetLowStock)
+			s.GET("/lot/expiring", inventory.GetExpiringLots)
+			s.POST("/adjustment", inventory.CreateStockAdjustment)
+		}
+		sp := h.Group("/supplier", authMw.ValidateToken(), authMw.CheckRole("admin"))
+		{
+			sp.GET("", supplier.GetSuppliers)
+			sp.GET(":id", supplier.GetSupplier)
+			sp.POST("", supplier.CreateSupplier)
+			sp.PUT(":id", supplier.UpdateSupplier)
+			sp.DELETE(":id", supplier.DeleteSupplier)
+			sp.GET(":id/product", supplier.GetSupplierProducts)
+			sp.PUT(":id/product/:productId", supplier.SetSupplierProduct)
+			sp.DELETE(":id/product/:productId", supplier.DeleteSupplierProduct)
+		}
+		po := h.Group("/purchase-order", authMw.ValidateToken(), authMw.CheckRole("admin"))
+		{
+			po.GET("", purchaseOrder.GetPurchaseOrders)
+			po.GET(":id", purchaseOrder.GetPurchaseOrder)
+			po.POST("", purchaseOrder.CreatePurchaseOrder)
+			po.POST("/draft", purchaseOrder.DraftPurchaseOrder)
+			po.PUT(":id", purchaseOrder.UpdatePurchaseOrder)
+			po.PATCH(":id/order", purchaseOrder.OrderPurchaseOrder)
+			po.PATCH(":id/cancel", purchaseOrder.CancelPurchaseOrder)
+			po.POST(":id/receive", purchaseOrder.ReceivePurchaseOrder)
+		}
+		rv := h.Group("/review", authMw.ValidateToken(), authMw.CheckRole("admin"))
+		{
+			rv.GET("", review.GetReviews)
+			rv.PATCH(":id/approve", review.ApproveReview)
+			rv.PATCH(":id/reject", review.RejectReview)
+		}
+		// Download links are signed and carry no token.
+		d := h.Group("/download", stream)
+		{
+			d.GET(":id", download.DownloadFile)
 		}
 	}
 }
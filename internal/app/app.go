@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
 	"github.com/bestetufan/beste-store/config"
@@ -12,6 +13,9 @@ import (
 	"github.com/bestetufan/beste-store/pkg/database_handler"
 	"github.com/bestetufan/beste-store/pkg/httpserver"
 	"github.com/bestetufan/beste-store/pkg/logger"
+	"github.com/bestetufan/beste-store/pkg/notifier"
+	"github.com/bestetufan/beste-store/pkg/scheduler"
+	"github.com/bestetufan/beste-store/pkg/storage"
 	"github.com/gin-gonic/gin"
 )
 
@@ -34,16 +38,41 @@ func Run(cfg *config.Config) {
 
 	// Migrate & seed
 	if cfg.AutoMigrate {
-		err = migration.Execute(db)
+		err = migration.Execute(db, cfg.DefaultCurrency)
 		if err != nil {
 			l.Fatal(fmt.Errorf("app - Run - migration.Execute: %w", err))
 		}
 	}
 
+	// Storage
+	st, err := newStorage(cfg)
+	if err != nil {
+		l.Fatal(fmt.Errorf("app - Run - newStorage: %w", err))
+	}
+	files, err := newFileStorage(cfg)
+	if err != nil {
+		l.Fatal(fmt.Errorf("app - Run - newFileStorage: %w", err))
+	}
+	// Download links are signed with the secret; without one anyone could
+	// sign them.
+	if len(cfg.DownloadSecret) == 0 {
+		l.Fatal("app - Run - DOWNLOAD_SECRET is not set")
+	}
+
+	// Notifier
+	n, err := newNotifier(cfg, l)
+	if err != nil {
+		l.Fatal(fmt.Errorf("app - Run - newNotifier: %w", err))
+	}
+
 	// GIN & router
 	gin.SetMode(cfg.GINMode)
 	handler := gin.New()
-	router.NewRouter(handler, l, cfg, db)
+	sch := scheduler.New(l)
+	router.NewRouter(handler, l, cfg, db, st, files, n, sch)
+
+	// Background jobs
+	sch.Start()
 
 	// HTTP server
 	httpServer := httpserver.New(handler, httpserver.Port(cfg.HTTPPort))
@@ -64,4 +93,65 @@ func Run(cfg *config.Config) {
 	if err != nil {
 		l.Error(fmt.Errorf("app - Run - httpServer.Shutdown: %w", err))
 	}
+	sch.Stop()
+}
+
+func newStorage(cfg *config.Config) (storage.Storage, error) {
+	switch cfg.StorageDriver {
+	case "s3":
+		return storage.NewS3(cfg.S3Endpoint, cfg.S3Bucket, cfg.S3Region,
+			cfg.S3AccessKey, cfg.S3SecretKey, cfg.StorageBaseURL)
+	case "local", "":
+		return storage.NewLocal(cfg.StorageLocalPath, cfg.StorageBaseURL)
+	default:
+		return nil, fmt.Errorf("unknown storage driver: %s", cfg.StorageDriver)
+	}
+}
+
+// newFileStorage opens the storage of the files of digital products. It uses
+// the driver of the media storage but is never served publicly.
+func newFileStorage(cfg *config.Config) (storage.Storage, error) {
+	switch cfg.StorageDriver {
+	case "s3":
+		return storage.NewS3(cfg.S3Endpoint, cfg.DownloadS3Bucket, cfg.S3Region,
+			cfg.S3AccessKey, cfg.S3SecretKey, "")
+	case "local", "":
+		return storage.NewLocal(cfg.DownloadLocalPath, "")
+	default:
+		return nil, fmt.Errorf("unknown storage driver: %s", cfg.StorageDriver)
+	}
+}
+
+// newNotifier builds a notifier delivering through every driver in the
+// comma separated NotifierDrivers, the log when none is given.
+func newNotifier(cfg *config.Config, l *logger.Logger) (notifier.Notifier, error) {
+	var notifiers notifier.Multi
+	for _, driver := range strings.Split(cfg.NotifierDrivers, ",") {
+		switch strings.TrimSpace(driver) {
+		case "log", "":
+			notifiers = append(notifiers, notifier.NewLog(l))
+		case "email":
+			var to []string
+			for _, address := range strings.Split(cfg.NotifyEmailTo, ",") {
+				if address = strings.TrimSpace(address); len(address) > 0 {
+					to = append(to, address)
+				}
+			}
+			email, err := notifier.NewEmail(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword,
+				cfg.SMTPFrom, to)
+			if err != nil {
+				return nil, err
+			}
+			notifiers = append(notifiers, email)
+		case "webhook":
+			webhook, err := notifier.NewWebhook(cfg.NotifyWebhookURL, cfg.NotifyWebhookSecret)
+			if err != nil {
+				return nil, err
+			}
+			notifiers = append(notifiers, webhook)
+		default:
+			return nil, fmt.Errorf("unknown notifier driver: %s", driver)
+		}
+	}
+	return notifiers, nil
 }
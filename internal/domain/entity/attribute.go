@@ -0,0 +1,126 @@
+package entity
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	AttributeTypeText    = "text"
+	AttributeTypeNumber  = "number"
+	AttributeTypeEnum    = "enum"
+	AttributeTypeBoolean = "boolean"
+)
+
+type Attribute struct {
+	ID           uint32    `gorm:"primary_key;auto_increment" json:"id"`
+	CategoryID   uint32    `gorm:"not null;uniqueIndex:idx_attribute_category_code" json:"category_id"`
+	Code         string    `gorm:"size:50;not null;uniqueIndex:idx_attribute_category_code" json:"code"`
+	Name         string    `gorm:"size:100;not null;" json:"name"`
+	Type         string    `gorm:"size:20;not null;" json:"type"`
+	Unit         string    `gorm:"size:20;" json:"unit"`
+	Options      []string  `gorm:"serializer:json" json:"options"`
+	IsRequired   bool      `gorm:"not null;" json:"is_required"`
+	IsFilterable bool      `gorm:"not null;" json:"is_filterable"`
+	CreatedAt    time.Time `gorm:"<-:create" json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type ProductAttribute struct {
+	ProductID   uint32     `gorm:"primary_key" json:"product_id"`
+	AttributeID uint32     `gorm:"primary_key" json:"attribute_id"`
+	Attribute   *Attribute `gorm:"foreignkey:AttributeID;references:ID" json:"attribute"`
+	Value       string     `gorm:"size:255;not null;index" json:"value"`
+	NumberValue *float64   `gorm:"index" json:"-"`
+	CreatedAt   time.Time  `gorm:"<-:create" json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+func NewAttribute(categoryId uint32, code string, name string, attrType string, unit string,
+	options []string, required bool, filterable bool) (*Attribute, error) {
+	if len(code) == 0 || len(name) == 0 {
+		return nil, fmt.Errorf("code and name fields are required")
+	}
+
+	switch attrType {
+	case AttributeTypeText, AttributeTypeNumber, AttributeTypeBoolean:
+		options = nil
+	case AttributeTypeEnum:
+		if len(options) == 0 {
+			return nil, fmt.Errorf("enum attribute requires at least one option")
+		}
+	default:
+		return nil, fmt.Errorf("unknown attribute type: %s", attrType)
+	}
+
+	return &Attribute{
+		CategoryID:   categoryId,
+		Code:         strings.ToLower(code),
+		Name:         name,
+		Type:         attrType,
+		Unit:         unit,
+		Options:      options,
+		IsRequired:   required,
+		IsFilterable: filterable,
+	}, nil
+}
+
+func NewProductAttribute(productId uint32, attribute *Attribute, value string) (*ProductAttribute, error) {
+	normalized, number, err := attribute.Normalize(value)
+	if err != nil {
+		return nil, err
+	}
+	return &ProductAttribute{
+		ProductID:   productId,
+		AttributeID: attribute.ID,
+		Attribute:   attribute,
+		Value:       normalized,
+		NumberValue: number,
+	}, nil
+}
+
+func (Attribute) TableName() string {
+	return "attribute"
+}
+
+func (ProductAttribute) TableName() string {
+	return "product_attribute"
+}
+
+// Normalize validates a raw value against the attribute schema and returns
+// its canonical string form, plus the parsed number for numeric attributes.
+func (a *Attribute) Normalize(value string) (string, *float64, error) {
+	value = strings.TrimSpace(value)
+	if len(value) == 0 {
+		return "", nil, fmt.Errorf("%s: value is required", a.Code)
+	}
+
+	switch a.Type {
+	case AttributeTypeNumber:
+		n, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s: %q is not a number", a.Code, value)
+		}
+		return strconv.FormatFloat(n, 'f', -1, 64), &n, nil
+	case AttributeTypeBoolean:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s: %q is not a boolean", a.Code, value)
+		}
+		return strconv.FormatBool(b), nil, nil
+	case AttributeTypeEnum:
+		for _, o := range a.Options {
+			if strings.EqualFold(o, value) {
+				return o, nil, nil
+			}
+		}
+		return "", nil, fmt.Errorf("%s: %q is not one of %s", a.Code, value, strings.Join(a.Options, ", "))
+	default:
+		if len(value) > 255 {
+			return "", nil, fmt.Errorf("%s: value is longer than 255 characters", a.Code)
+		}
+		return value, nil, nil
+	}
+}
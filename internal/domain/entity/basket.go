@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/bestetufan/beste-store/pkg/measure"
+	"github.com/bestetufan/beste-store/pkg/money"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -17,12 +19,12 @@ type Basket struct {
 }
 
 type BasketItem struct {
-	BasketID  string    `gorm:"primary_key" json:"basket_id"`
-	ProductID uint32    `gorm:"primary_key" json:"product_id"`
-	Product   *Product  `gorm:"foreignkey:ProductID;references:ID" json:"product"`
-	Quantity  int       `gorm:"not null" json:"quantity"`
-	CreatedAt time.Time `gorm:"<-:create" json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	BasketID  string           `gorm:"primary_key" json:"basket_id"`
+	ProductID uint32           `gorm:"primary_key" json:"product_id"`
+	Product   *Product         `gorm:"foreignkey:ProductID;references:ID" json:"product"`
+	Quantity  measure.Quantity `gorm:"not null" json:"quantity" swaggertype:"number"`
+	CreatedAt time.Time        `gorm:"<-:create" json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
 }
 
 func NewBasket(userName string) (*Basket, error) {
@@ -35,7 +37,7 @@ func NewBasket(userName string) (*Basket, error) {
 	}, nil
 }
 
-func NewBasketItem(id string, productId uint32, quantity int) (*BasketItem, error) {
+func NewBasketItem(id string, productId uint32, quantity measure.Quantity) (*BasketItem, error) {
 	if quantity <= 0 {
 		return nil, fmt.Errorf("quantity must be greater than zero")
 	}
@@ -67,3 +69,35 @@ func (b *Basket) SearchItemByProductId(productId uint32) (int, *BasketItem) {
 	}
 	return -1, nil
 }
+
+// Total returns the value of the basket at the effective product prices. It
+// fails when the products are priced in different currencies.
+func (b *Basket) Total() (money.Money, error) {
+	var total money.Money
+	for _, item := range b.Items {
+		if item.Product == nil {
+			continue
+		}
+		var err error
+		total, err = total.Add(item.Product.EffectivePrice().MulRat(item.Quantity.Rat()))
+		if err != nil {
+			return money.Money{}, err
+		}
+	}
+	return total, nil
+}
+
+// Demand returns the stock the items of the basket take by product id:
+// bundles take their components. Products must be loaded with their
+// components.
+func (b *Basket) Demand() map[uint32]measure.Quantity {
+	demand := make(map[uint32]measure.Quantity)
+	for _, item := range b.Items {
+		if item.Product == nil {
+			demand[item.ProductID] += item.Quantity
+			continue
+		}
+		item.Product.AddStockDemand(demand, item.Quantity)
+	}
+	return demand
+}
@@ -0,0 +1,182 @@
+package entity
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/bestetufan/beste-store/pkg/measure"
+	"github.com/bestetufan/beste-store/pkg/money"
+)
+
+// Bundles are sold at a fixed price of their own or at the list prices of
+// their components less a discount in percent.
+const (
+	BundlePricingFixed    = "fixed"
+	BundlePricingDiscount = "discount"
+)
+
+// BundleComponent is a quantity of a product sold as part of a bundle. A
+// bundle has no stock of its own: selling one takes its components out of
+// stock.
+type BundleComponent struct {
+	BundleID  uint32           `gorm:"primary_key" json:"bundle_id"`
+	ProductID uint32           `gorm:"primary_key" json:"product_id"`
+	Product   *Product         `gorm:"foreignkey:ProductID;references:ID" json:"product,omitempty"`
+	Quantity  measure.Quantity `gorm:"not null" json:"quantity" swaggertype:"number"`
+	CreatedAt time.Time        `gorm:"<-:create" json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+// NewBundleComponent creates a component of a bundle holding quantity of
+// product, which must be a multiple of the step the product is sold in.
+func NewBundleComponent(bundleId uint32, product *Product, quantity measure.Quantity) (*BundleComponent, error) {
+	switch {
+	case product.ID == bundleId:
+		return nil, fmt.Errorf("a bundle can not contain itself")
+	case product.IsBundle():
+		return nil, fmt.Errorf("%s is a bundle; bundles can not contain bundles", product.Sku)
+	case product.IsDigital:
+		return nil, fmt.Errorf("%s is digital; bundles can not contain digital products", product.Sku)
+	case quantity <= 0:
+		return nil, fmt.Errorf("quantity of %s must be greater than zero", product.Sku)
+	case !quantity.IsMultipleOf(product.QuantityStep):
+		return nil, fmt.Errorf("%s is sold in steps of %s", product.Sku, product.FormatQuantity(product.QuantityStep))
+	}
+	return &BundleComponent{
+		BundleID:  bundleId,
+		ProductID: product.ID,
+		Product:   product,
+		Quantity:  quantity,
+	}, nil
+}
+
+func (BundleComponent) TableName() string {
+	return "bundle_component"
+}
+
+// IsBundle reports whether the product is a bundle of other products.
+func (p *Product) IsBundle() bool {
+	return len(p.BundlePricing) > 0
+}
+
+// SetBundle makes the product a bundle priced with pricing; discount is the
+// percentage taken off the component prices of bundles priced by discount.
+// Bundles are sold by the piece, are not serialized and have no stock of
+// their own. An empty pricing makes the product a regular product again.
+func (p *Product) SetBundle(pricing string, discount int) error {
+	switch pricing {
+	case "":
+		p.BundlePricing, p.BundleDiscount, p.Components = "", 0, nil
+		return nil
+	case BundlePricingFixed:
+		if discount != 0 {
+			return fmt.Errorf("discount is only used by bundles priced by discount")
+		}
+	case BundlePricingDiscount:
+		if discount < 0 || discount >= 100 {
+			return fmt.Errorf("discount must be between 0 and 99 percent")
+		}
+	default:
+		return fmt.Errorf("unknown bundle pricing: %q", pricing)
+	}
+
+	switch {
+	case p.Unit != UnitPiece:
+		return fmt.Errorf("bundles are sold by the piece")
+	case p.IsSerialized():
+		return fmt.Errorf("bundles can not be serialized")
+	case p.IsDigital:
+		return fmt.Errorf("digital products can not be bundles")
+	case !p.IsBundle() && p.Quantity != 0:
+		return fmt.Errorf("%s has stock of its own and can not become a bundle", p.Sku)
+	}
+	p.BundlePricing, p.BundleDiscount = pricing, discount
+	return nil
+}
+
+// ComponentPrice returns the price of a bundle priced by discount: the list
+// prices of its components less the discount. Components must be loaded.
+func (p *Product) ComponentPrice() (money.Money, error) {
+	var total money.Money
+	for _, c := range p.Components {
+		if c.Product == nil {
+			return money.Money{}, fmt.Errorf("product %d not found", c.ProductID)
+		}
+		var err error
+		total, err = total.Add(c.Product.UnitPrice.MulRat(c.Quantity.Rat()))
+		if err != nil {
+			return money.Money{}, fmt.Errorf("components of %s are priced in different currencies", p.Sku)
+		}
+	}
+	return total.MulRat(big.NewRat(int64(100-p.BundleDiscount), 100)), nil
+}
+
+// OnHand returns the stock on hand of the product. That of a bundle is the
+// number of whole bundles its components make up.
+func (p *Product) OnHand() measure.Quantity {
+	if p.IsBundle() {
+		return p.bundleQuantity(func(c *Product) measure.Quantity { return c.Quantity })
+	}
+	return p.Quantity
+}
+
+// bundleQuantity returns the number of whole bundles the given stock of the
+// components makes up. Components must be loaded; missing ones, e.g. in the
+// trash, make up none.
+func (p *Product) bundleQuantity(stock func(*Product) measure.Quantity) measure.Quantity {
+	bundles := -1
+	for _, c := range p.Components {
+		n := 0
+		if c.Product != nil && c.Quantity > 0 && stock(c.Product) > 0 {
+			n = int(stock(c.Product) / c.Quantity)
+		}
+		if bundles < 0 || n < bundles {
+			bundles = n
+		}
+	}
+	if bundles < 0 {
+		return 0
+	}
+	return measure.Units(bundles)
+}
+
+// AddStockDemand adds the stock quantity of the product takes to demand, by
+// product id: the components for a bundle, none for a digital product and
+// the product itself otherwise. Components must be loaded.
+func (p *Product) AddStockDemand(demand map[uint32]measure.Quantity, quantity measure.Quantity) {
+	if p.IsDigital {
+		return
+	}
+	if !p.IsBundle() {
+		demand[p.ID] += quantity
+		return
+	}
+	for _, c := range p.Components {
+		demand[c.ProductID] += componentQuantity(c.Quantity, quantity)
+	}
+}
+
+// StockProductIds returns the ids of the products the stock of the product
+// is kept in, in ascending order. Digital products have no stock.
+func (p *Product) StockProductIds() []uint32 {
+	if p.IsDigital {
+		return nil
+	}
+	if !p.IsBundle() {
+		return []uint32{p.ID}
+	}
+	ids := make([]uint32, 0, len(p.Components))
+	for _, c := range p.Components {
+		ids = append(ids, c.ProductID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// componentQuantity returns the quantity of a component with perBundle in
+// each bundle that bundles take. Bundles are sold in whole pieces.
+func componentQuantity(perBundle measure.Quantity, bundles measure.Quantity) measure.Quantity {
+	return perBundle * bundles / measure.Unit
+}
@@ -2,15 +2,20 @@ package entity
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type Category struct {
-	ID        uint32    `gorm:"primary_key;auto_increment" json:"id"`
-	Name      string    `gorm:"size:50;not null;" json:"name"`
-	IsActive  bool      `gorm:"not null;" json:"is_active"`
-	Products  []Product `gorm:"foreignkey:CategoryID" json:"products"`
-	CreatedAt time.Time `gorm:"<-:create" json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID         uint32         `gorm:"primary_key;auto_increment" json:"id"`
+	Name       string         `gorm:"size:50;not null;" json:"name"`
+	IsActive   bool           `gorm:"not null;" json:"is_active"`
+	TracksLots bool           `gorm:"not null;default:false" json:"tracks_lots"`
+	Products   []Product      `gorm:"foreignkey:CategoryID" json:"products"`
+	Attributes []Attribute    `gorm:"foreignkey:CategoryID" json:"attributes"`
+	CreatedAt  time.Time      `gorm:"<-:create" json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"deleted_at" swaggertype:"string" format:"date-time"`
 }
 
 func NewCategory(name string, active bool) *Category {
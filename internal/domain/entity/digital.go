@@ -0,0 +1,116 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+// ProductFile is the file customers download after buying a digital product.
+// It is kept in a storage that is not served publicly.
+type ProductFile struct {
+	ProductID   uint32    `gorm:"primary_key" json:"product_id"`
+	Key         string    `gorm:"size:255;not null" json:"-"`
+	FileName    string    `gorm:"size:255;not null" json:"file_name"`
+	ContentType string    `gorm:"size:100;not null" json:"content_type"`
+	Size        int64     `gorm:"not null" json:"size"`
+	CreatedAt   time.Time `gorm:"<-:create" json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func NewProductFile(productId uint32, key string, fileName string, contentType string, size int64) (*ProductFile, error) {
+	if len(key) == 0 {
+		return nil, fmt.Errorf("file key is required")
+	}
+	if len(fileName) == 0 {
+		return nil, fmt.Errorf("file name is required")
+	}
+	return &ProductFile{
+		ProductID:   productId,
+		Key:         key,
+		FileName:    fileName,
+		ContentType: contentType,
+		Size:        size,
+	}, nil
+}
+
+func (ProductFile) TableName() string {
+	return "product_file"
+}
+
+// Download is the right of the customer who ordered a digital product to
+// download its file up to MaxCount times until ExpiresAt.
+type Download struct {
+	ID        string    `gorm:"primary_key;size:36" json:"id"`
+	OrderID   string    `gorm:"size:36;not null;index" json:"order_id"`
+	ProductID uint32    `gorm:"not null" json:"product_id"`
+	UserName  string    `gorm:"size:255;not null;index" json:"user_name"`
+	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
+	MaxCount  int       `gorm:"not null" json:"max_count"`
+	Count     int       `gorm:"not null;default:0" json:"count"`
+	CreatedAt time.Time `gorm:"<-:create" json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func NewDownload(id string, orderId string, productId uint32, userName string, expiresAt time.Time,
+	maxCount int) (*Download, error) {
+	if maxCount <= 0 {
+		return nil, fmt.Errorf("download limit must be greater than zero")
+	}
+	return &Download{
+		ID:        id,
+		OrderID:   orderId,
+		ProductID: productId,
+		UserName:  userName,
+		ExpiresAt: expiresAt,
+		MaxCount:  maxCount,
+	}, nil
+}
+
+func (Download) TableName() string {
+	return "download"
+}
+
+// Remaining returns how many more times the file can be downloaded.
+func (d *Download) Remaining() int {
+	if d.Count >= d.MaxCount {
+		return 0
+	}
+	return d.MaxCount - d.Count
+}
+
+// DownloadLink is a signed address the file of a digital product bought in
+// an order item can be downloaded from.
+type DownloadLink struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Remaining int       `json:"remaining"`
+}
+
+// SetDigital makes the product digital: it is delivered as a file instead
+// of being shipped and has no stock. Digital products are sold by the piece
+// and are neither serialized nor bundles.
+func (p *Product) SetDigital(digital bool) error {
+	if !digital {
+		p.IsDigital = false
+		return nil
+	}
+
+	switch {
+	case p.IsBundle():
+		return fmt.Errorf("bundles can not be digital")
+	case p.Unit != UnitPiece:
+		return fmt.Errorf("digital products are sold by the piece")
+	case p.IsSerialized():
+		return fmt.Errorf("digital products can not be serialized")
+	case !p.IsDigital && p.Quantity != 0:
+		return fmt.Errorf("%s has stock and can not become digital", p.Sku)
+	}
+	p.IsDigital = true
+	return nil
+}
+
+// IsDownloadable reports whether the product is digital and has a file to
+// download. File must be loaded.
+func (p *Product) IsDownloadable() bool {
+	return p.IsDigital && p.File != nil
+}
@@ -0,0 +1,79 @@
+package entity
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/bestetufan/beste-store/pkg/money"
+)
+
+// ExchangeRateScale is the number of decimal places kept for exchange rates.
+const ExchangeRateScale = 10
+
+// ExchangeRate is the value of one unit of Currency in the default currency
+// of the store, e.g. 1 EUR = 35.2 TRY.
+type ExchangeRate struct {
+	ID        uint32      `gorm:"primary_key;auto_increment" json:"-"`
+	Currency  string      `gorm:"size:3;not null;unique" json:"currency"`
+	Rate      json.Number `gorm:"type:decimal(20,10);not null" json:"rate" swaggertype:"number"`
+	UserName  string      `gorm:"size:255" json:"user_name"`
+	CreatedAt time.Time   `gorm:"<-:create" json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+func NewExchangeRate(currency string, rate string, userName string) (*ExchangeRate, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if !money.Valid(currency) {
+		return nil, fmt.Errorf("unknown currency: %q", currency)
+	}
+
+	value, err := ParseExchangeRate(rate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExchangeRate{
+		Currency: currency,
+		Rate:     json.Number(formatExchangeRate(value)),
+		UserName: userName,
+	}, nil
+}
+
+func (ExchangeRate) TableName() string {
+	return "exchange_rate"
+}
+
+// Ratio returns the rate as an exact fraction.
+func (e *ExchangeRate) Ratio() *big.Rat {
+	value, err := ParseExchangeRate(e.Rate.String())
+	if err != nil {
+		return new(big.Rat)
+	}
+	return value
+}
+
+// ParseExchangeRate reads a positive decimal rate such as "35.2" or "35,2"
+// with at most ExchangeRateScale decimal places.
+func ParseExchangeRate(rate string) (*big.Rat, error) {
+	s := strings.TrimSpace(strings.Replace(rate, ",", ".", 1))
+	if len(s) == 0 || strings.ContainsAny(s, "/eE+-") {
+		return nil, fmt.Errorf("invalid rate: %q", rate)
+	}
+	if i := strings.IndexByte(s, '.'); i >= 0 && len(strings.TrimRight(s[i+1:], "0")) > ExchangeRateScale {
+		return nil, fmt.Errorf("rate has more than %d decimal places: %q", ExchangeRateScale, rate)
+	}
+
+	value, ok := new(big.Rat).SetString(s)
+	if !ok || value.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid rate: %q", rate)
+	}
+	return value, nil
+}
+
+func formatExchangeRate(value *big.Rat) string {
+	s := value.FloatString(ExchangeRateScale)
+	return strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+}
@@ -1,34 +1,77 @@
 package entity
 
 import (
+	"encoding/json"
 	"fmt"
+	"math/big"
 	"time"
 
+	"github.com/bestetufan/beste-store/pkg/measure"
+	"github.com/bestetufan/beste-store/pkg/money"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// Statuses of orders. Orders are fulfilled when they are shipped and
+// delivered once the customer has received them; orders of digital products
+// only are delivered at once, as downloads.
+const (
+	OrderStatusIncomplete = "incomplete"
+	OrderStatusFulfilled  = "fulfilled"
+	OrderStatusDelivered  = "delivered"
+	OrderStatusCanceled   = "canceled"
+)
+
 type Order struct {
-	ID          string       `gorm:"primary_key;" json:"id"`
-	UserName    string       `gorm:"size:255;not null;" json:"user_name"`
-	Status      string       `gorm:"size:100;not null;" json:"status"`
-	Name        string       `gorm:"size:100;not null;" json:"name"`
-	Address     string       `gorm:"size:255;not null;" json:"address"`
-	PhoneNumber string       `gorm:"size:100;not null;" json:"phone_number"`
-	CardNumber  string       `gorm:"size:255;not null;" json:"card_number"`
-	CardExp     string       `gorm:"size:100;not null;" json:"card_exp"`
-	CardCVV     int          `gorm:"not null;" json:"card_cvv"`
-	Items       []*OrderItem `gorm:"foreignkey:OrderID;" json:"items"`
-	CreatedAt   time.Time    `gorm:"<-:create" json:"created_at"`
-	UpdatedAt   time.Time    `json:"updated_at"`
+	ID          string `gorm:"primary_key;" json:"id"`
+	UserName    string `gorm:"size:255;not null;" json:"user_name"`
+	Status      string `gorm:"size:100;not null;" json:"status"`
+	Name        string `gorm:"size:100;not null;" json:"name"`
+	Address     string `gorm:"size:255;not null;" json:"address"`
+	PhoneNumber string `gorm:"size:100;not null;" json:"phone_number"`
+	CardNumber  string `gorm:"size:255;not null;" json:"card_number"`
+	CardExp     string `gorm:"size:100;not null;" json:"card_exp"`
+	CardCVV     int    `gorm:"not null;" json:"card_cvv"`
+	Currency    string `gorm:"size:3;not null;default:''" json:"currency"`
+	// ExchangeRate converted the product prices, in PriceCurrency, into the
+	// item prices, in Currency.
+	PriceCurrency string       `gorm:"size:3;not null;default:''" json:"price_currency"`
+	ExchangeRate  json.Number  `gorm:"type:decimal(20,10);not null;default:1" json:"exchange_rate" swaggertype:"number"`
+	Items         []*OrderItem `gorm:"foreignkey:OrderID;" json:"items"`
+	CreatedAt     time.Time    `gorm:"<-:create" json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
 }
 
 type OrderItem struct {
-	OrderID   string    `gorm:"primary_key" json:"order_id"`
-	ProductID uint32    `gorm:"primary_key" json:"product_id"`
-	Product   *Product  `gorm:"foreignkey:ProductID;references:ID" json:"product"`
-	CreatedAt time.Time `gorm:"<-:create" json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	OrderID   string           `gorm:"primary_key" json:"order_id"`
+	ProductID uint32           `gorm:"primary_key" json:"product_id"`
+	Product   *Product         `gorm:"foreignkey:ProductID;references:ID" json:"product"`
+	Quantity  measure.Quantity `json:"quantity" swaggertype:"number"`
+	UnitPrice money.Money      `gorm:"embedded;embeddedPrefix:UnitPrice" json:"unit_price" swaggertype:"number"`
+	// Serials are the serial numbers of the units of a serialized product
+	// assigned to the item at fulfillment.
+	Serials []string `gorm:"-" json:"serials,omitempty"`
+	// Download is the link the file of a digital product is downloaded from.
+	Download *DownloadLink `gorm:"-" json:"download,omitempty"`
+	// Components are the products a bundle was made up of when it was
+	// ordered.
+	Components []*OrderItemComponent `gorm:"foreignkey:OrderID,BundleID;references:OrderID,ProductID" json:"components,omitempty"`
+	CreatedAt  time.Time             `gorm:"<-:create" json:"created_at"`
+	UpdatedAt  time.Time             `json:"updated_at"`
+}
+
+// OrderItemComponent is a product sold as part of a bundle ordered in an
+// order item. Quantity is the quantity of the product all bundles of the item
+// took.
+type OrderItemComponent struct {
+	OrderID   string           `gorm:"primary_key" json:"-"`
+	BundleID  uint32           `gorm:"primary_key" json:"-"`
+	ProductID uint32           `gorm:"primary_key" json:"product_id"`
+	Product   *Product         `gorm:"foreignkey:ProductID;references:ID" json:"product"`
+	Quantity  measure.Quantity `json:"quantity" swaggertype:"number"`
+	Serials   []string         `gorm:"-" json:"serials,omitempty"`
+	CreatedAt time.Time        `gorm:"<-:create" json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
 }
 
 func NewOrder(userName string, name string, address string, phoneNumber string,
@@ -38,7 +81,7 @@ func NewOrder(userName string, name string, address string, phoneNumber string,
 	}
 	return &Order{
 		UserName:    userName,
-		Status:      "incomplete",
+		Status:      OrderStatusIncomplete,
 		Name:        name,
 		Address:     address,
 		PhoneNumber: phoneNumber,
@@ -49,13 +92,43 @@ func NewOrder(userName string, name string, address string, phoneNumber string,
 	}, nil
 }
 
-func NewOrderItem(orderId string, productId uint32) (*OrderItem, error) {
+// NewOrderItem creates an order item; unitPrice is the price the product was
+// sold at, including any sale price in effect.
+func NewOrderItem(orderId string, productId uint32, quantity measure.Quantity, unitPrice money.Money) (*OrderItem, error) {
 	return &OrderItem{
 		OrderID:   orderId,
 		ProductID: productId,
+		Quantity:  quantity,
+		UnitPrice: unitPrice,
 	}, nil
 }
 
+// SetComponents records the components of a bundle sold in the item, whose
+// components must be loaded.
+func (i *OrderItem) SetComponents(bundle *Product) {
+	i.Components = nil
+	for _, c := range bundle.Components {
+		i.Components = append(i.Components, &OrderItemComponent{
+			OrderID:   i.OrderID,
+			BundleID:  i.ProductID,
+			ProductID: c.ProductID,
+			Quantity:  componentQuantity(c.Quantity, i.Quantity),
+		})
+	}
+}
+
+// SetExchangeRate records the currency the order is charged in and the rate
+// that converts product prices from priceCurrency into it. The rate is kept
+// with ExchangeRateScale decimal places; the returned rate is the recorded
+// one and must be used to convert the item prices.
+func (b *Order) SetExchangeRate(currency string, priceCurrency string, rate *big.Rat) *big.Rat {
+	b.Currency, b.PriceCurrency = currency, priceCurrency
+	b.ExchangeRate = json.Number(formatExchangeRate(rate))
+
+	recorded, _ := new(big.Rat).SetString(b.ExchangeRate.String())
+	return recorded
+}
+
 func (Order) TableName() string {
 	return "order"
 }
@@ -69,7 +142,30 @@ func (OrderItem) TableName() string {
 	return "order_item"
 }
 
+func (OrderItemComponent) TableName() string {
+	return "order_item_component"
+}
+
 func (b *Order) AddItem(item *OrderItem) error {
 	b.Items = append(b.Items, item)
 	return nil
 }
+
+// Demand returns the stock the items of the order took by product id: the
+// components of bundles and the other products themselves, except digital
+// ones. Products must be loaded.
+func (b *Order) Demand() map[uint32]measure.Quantity {
+	demand := make(map[uint32]measure.Quantity)
+	for _, item := range b.Items {
+		if item.Product != nil && item.Product.IsDigital {
+			continue
+		}
+		if len(item.Components) == 0 {
+			demand[item.ProductID] += item.Quantity
+		}
+		for _, c := range item.Components {
+			demand[c.ProductID] += c.Quantity
+		}
+	}
+	return demand
+}
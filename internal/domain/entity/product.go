@@ -1,31 +1,373 @@
 package entity
 
 import (
+	"fmt"
+	"strings"
 	"time"
+
+	"github.com/bestetufan/beste-store/pkg/barcode"
+	"github.com/bestetufan/beste-store/pkg/measure"
+	"github.com/bestetufan/beste-store/pkg/money"
+	"gorm.io/gorm"
 )
 
 type Product struct {
-	ID         uint32    `gorm:"primary_key;auto_increment" json:"id"`
-	Name       string    `gorm:"size:255;not null;" json:"name"`
-	Sku        string    `gorm:"size:100;not null;unique" json:"sku"`
-	UnitPrice  float64   `json:"unit_price"`
-	Quantity   int       `json:"quantity"`
-	CategoryID uint32    `json:"category_id"`
-	Category   Category  `json:"category"`
-	CreatedAt  time.Time `gorm:"<-:create" json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
-}
-
-func NewProduct(name string, sku string, unitPrice float64, quantity int, categoryID uint32) *Product {
+	ID              uint32              `gorm:"primary_key;auto_increment" json:"id"`
+	Name            string              `gorm:"size:255;not null;" json:"name"`
+	Sku             string              `gorm:"size:100;not null;unique" json:"sku"`
+	GTIN            *string             `gorm:"size:14;unique" json:"gtin"`
+	UnitPrice       money.Money         `gorm:"embedded;embeddedPrefix:UnitPrice" json:"unit_price" swaggertype:"number"`
+	Currency        string              `gorm:"-" json:"currency"`
+	Unit            string              `gorm:"size:10;not null;default:'piece'" json:"unit"`
+	QuantityStep    measure.Quantity    `gorm:"not null;default:1000" json:"quantity_step" swaggertype:"number"`
+	MinQuantity     measure.Quantity    `gorm:"not null;default:1000" json:"min_quantity" swaggertype:"number"`
+	Quantity        measure.Quantity    `gorm:"not null;default:0" json:"quantity" swaggertype:"number"`
+	Reserved        measure.Quantity    `gorm:"not null;default:0" json:"reserved" swaggertype:"number"`
+	ReorderPoint    measure.Quantity    `gorm:"not null;default:0" json:"reorder_point" swaggertype:"number"`
+	ReorderQuantity measure.Quantity    `gorm:"not null;default:0" json:"reorder_quantity" swaggertype:"number"`
+	SerialType      string              `gorm:"size:10;not null;default:''" json:"serial_type"`
+	BundlePricing   string              `gorm:"size:10;not null;default:''" json:"bundle_pricing"`
+	BundleDiscount  int                 `gorm:"not null;default:0" json:"bundle_discount"`
+	IsDigital       bool                `gorm:"not null;default:false" json:"is_digital"`
+	Rating          float64             `gorm:"type:decimal(3,2);not null;default:0" json:"rating"`
+	RatingCount     int                 `gorm:"not null;default:0" json:"rating_count"`
+	CategoryID      uint32              `json:"category_id"`
+	Status          string              `gorm:"size:20;not null;default:'published';index" json:"status"`
+	PublishAt       *time.Time          `gorm:"index" json:"publish_at"`
+	Category        Category            `json:"category"`
+	Attributes      []*ProductAttribute `gorm:"foreignkey:ProductID" json:"attributes"`
+	Images          []*ProductImage     `gorm:"foreignkey:ProductID" json:"images"`
+	SalePrice       *SalePrice          `gorm:"foreignkey:ProductID" json:"sale_price"`
+	Components      []*BundleComponent  `gorm:"foreignkey:BundleID" json:"components,omitempty"`
+	File            *ProductFile        `gorm:"foreignkey:ProductID" json:"file,omitempty"`
+	CreatedAt       time.Time           `gorm:"<-:create" json:"created_at"`
+	UpdatedAt       time.Time           `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt      `gorm:"index" json:"deleted_at" swaggertype:"string" format:"date-time"`
+}
+
+const (
+	ProductStatusDraft     = "draft"
+	ProductStatusScheduled = "scheduled"
+	ProductStatusPublished = "published"
+	ProductStatusArchived  = "archived"
+)
+
+// Units of serialized products are tracked by serial number; those of
+// products with IMEIs by their IMEI.
+const (
+	SerialTypeSerial = "serial"
+	SerialTypeIMEI   = "imei"
+)
+
+// Units of measure products are sold in. Quantities of products sold by the
+// piece are whole numbers; the others can be sold in fractions.
+const (
+	UnitPiece    = "piece"
+	UnitKilogram = "kg"
+	UnitGram     = "g"
+	UnitLitre    = "l"
+	UnitMetre    = "m"
+)
+
+var units = []string{UnitPiece, UnitKilogram, UnitGram, UnitLitre, UnitMetre}
+
+// productTransitions lists the statuses a product can move to from each
+// status. Archived products have to go back to draft before being published
+// again.
+var productTransitions = map[string][]string{
+	ProductStatusDraft:     {ProductStatusScheduled, ProductStatusPublished, ProductStatusArchived},
+	ProductStatusScheduled: {ProductStatusDraft, ProductStatusScheduled, ProductStatusPublished, ProductStatusArchived},
+	ProductStatusPublished: {ProductStatusDraft, ProductStatusArchived},
+	ProductStatusArchived:  {ProductStatusDraft},
+}
+
+// NewProduct creates a draft product sold by the piece.
+func NewProduct(name string, sku string, unitPrice money.Money, quantity measure.Quantity, categoryID uint32) *Product {
 	return &Product{
-		Name:       name,
-		Sku:        sku,
-		UnitPrice:  unitPrice,
-		Quantity:   quantity,
-		CategoryID: categoryID,
+		Name:         name,
+		Sku:          sku,
+		UnitPrice:    unitPrice,
+		Currency:     unitPrice.Currency,
+		Status:       ProductStatusDraft,
+		Unit:         UnitPiece,
+		QuantityStep: measure.Unit,
+		MinQuantity:  measure.Unit,
+		Quantity:     quantity,
+		CategoryID:   categoryID,
 	}
 }
 
 func (Product) TableName() string {
 	return "product"
 }
+
+// AfterFind exposes the currency of the list price, which is written next to
+// the price in JSON.
+func (p *Product) AfterFind(tx *gorm.DB) error {
+	p.Currency = p.UnitPrice.Currency
+	return nil
+}
+
+// SetStatus moves the product to status if the transition is allowed.
+// Scheduled products are published at publishAt, which must be in the future;
+// published products record the time they were published in PublishAt, which
+// is publishAt when given and not in the future, now otherwise.
+func (p *Product) SetStatus(status string, publishAt *time.Time, now time.Time) error {
+	allowed, ok := productTransitions[p.Status]
+	if !ok {
+		return fmt.Errorf("unknown status: %q", p.Status)
+	}
+	if !IsProductStatus(status) {
+		return fmt.Errorf("unknown status: %q", status)
+	}
+	if !containsString(allowed, status) {
+		return fmt.Errorf("product can not be moved from %s to %s", p.Status, status)
+	}
+
+	switch status {
+	case ProductStatusScheduled:
+		if publishAt == nil || !publishAt.After(now) {
+			return fmt.Errorf("publish_at must be in the future")
+		}
+		p.PublishAt = publishAt
+	case ProductStatusPublished:
+		if publishAt == nil || publishAt.After(now) {
+			publishAt = &now
+		}
+		p.PublishAt = publishAt
+	case ProductStatusDraft:
+		p.PublishAt = nil
+	}
+	p.Status = status
+
+	return nil
+}
+
+// Available returns the quantity on hand that is not reserved for baskets.
+// That of a bundle is the number of whole bundles the available stock of its
+// components makes up.
+func (p *Product) Available() measure.Quantity {
+	if p.IsBundle() {
+		return p.bundleQuantity((*Product).Available)
+	}
+	return p.Quantity - p.Reserved
+}
+
+// SetUnit sets the unit of measure of the product and the quantities it is
+// sold in: multiples of step, at least min. A zero step is one unit for
+// products sold by the piece and a thousandth otherwise; a zero min is one
+// step.
+func (p *Product) SetUnit(unit string, step measure.Quantity, min measure.Quantity) error {
+	if !containsString(units, unit) {
+		return fmt.Errorf("unknown unit: %q", unit)
+	}
+	if p.IsBundle() && unit != UnitPiece {
+		return fmt.Errorf("bundles are sold by the piece")
+	}
+	if p.IsDigital && unit != UnitPiece {
+		return fmt.Errorf("digital products are sold by the piece")
+	}
+	if step == 0 {
+		step = 1
+		if unit == UnitPiece {
+			step = measure.Unit
+		}
+	}
+	if min == 0 {
+		min = step
+	}
+
+	switch {
+	case step < 0:
+		return fmt.Errorf("quantity_step must be greater than zero")
+	case unit == UnitPiece && !step.IsWhole():
+		return fmt.Errorf("quantity_step of products sold by the piece must be a whole number")
+	case min < 0 || !min.IsMultipleOf(step):
+		return fmt.Errorf("min_quantity must be a multiple of quantity_step")
+	}
+	p.Unit, p.QuantityStep, p.MinQuantity = unit, step, min
+	return nil
+}
+
+// CheckQuantity checks that quantity can be sold: at least the minimum
+// quantity and a multiple of the step.
+func (p *Product) CheckQuantity(quantity measure.Quantity) error {
+	switch {
+	case quantity <= 0:
+		return fmt.Errorf("quantity must be greater than zero")
+	case quantity < p.MinQuantity:
+		return fmt.Errorf("%s can not be sold in less than %s", p.Name, p.FormatQuantity(p.MinQuantity))
+	case !quantity.IsMultipleOf(p.QuantityStep):
+		return fmt.Errorf("%s is sold in steps of %s", p.Name, p.FormatQuantity(p.QuantityStep))
+	}
+	return nil
+}
+
+// CheckStockQuantity checks that quantity can be held in stock: products sold
+// by the piece are counted in whole pieces; bundles and digital products hold
+// none.
+func (p *Product) CheckStockQuantity(quantity measure.Quantity) error {
+	if p.IsBundle() && quantity != 0 {
+		return fmt.Errorf("%s is a bundle and is stocked through its components", p.Sku)
+	}
+	if p.IsDigital && quantity != 0 {
+		return fmt.Errorf("%s is digital and has no stock", p.Sku)
+	}
+	if p.Unit == UnitPiece && !quantity.IsWhole() {
+		return fmt.Errorf("%s is counted in whole pieces", p.Sku)
+	}
+	return nil
+}
+
+// FormatQuantity writes quantity with the unit of the product, e.g. "1.5 kg"
+// or "2 pieces".
+func (p *Product) FormatQuantity(quantity measure.Quantity) string {
+	switch {
+	case p.Unit != UnitPiece && len(p.Unit) > 0:
+		return quantity.String() + " " + p.Unit
+	case quantity == measure.Unit:
+		return "1 piece"
+	default:
+		return quantity.String() + " pieces"
+	}
+}
+
+// PriceUnit returns the unit a price of the product is given per, e.g. "₺/kg"
+// for a product sold by the kilogram and "₺" for one sold by the piece.
+func (p *Product) PriceUnit(currency string) string {
+	if p.Unit == UnitPiece || len(p.Unit) == 0 {
+		return money.Symbol(currency)
+	}
+	return money.Symbol(currency) + "/" + p.Unit
+}
+
+// SetReorder sets the stock level at or below which the product has to be
+// reordered and the quantity to reorder. A zero reorder point only alerts
+// when the product is out of stock.
+func (p *Product) SetReorder(point measure.Quantity, quantity measure.Quantity) error {
+	if point < 0 {
+		return fmt.Errorf("reorder_point can not be negative")
+	}
+	if quantity < 0 {
+		return fmt.Errorf("reorder_quantity can not be negative")
+	}
+	p.ReorderPoint, p.ReorderQuantity = point, quantity
+	return nil
+}
+
+// SuggestedReorder returns the quantity to reorder: the reorder quantity, or
+// when there is none, what brings the stock above the reorder point.
+func (p *Product) SuggestedReorder() measure.Quantity {
+	if p.ReorderQuantity > 0 {
+		return p.ReorderQuantity
+	}
+	return p.ReorderPoint - p.Quantity + p.QuantityStep
+}
+
+// StockAlertLevel returns StockAlertOutOfStock when no stock is on hand,
+// StockAlertLowStock when the stock on hand is at or below the reorder point
+// and an empty string otherwise. Archived products are not alerted, nor are
+// digital products or bundles, whose components are.
+func (p *Product) StockAlertLevel() string {
+	switch {
+	case p.Status == ProductStatusArchived, p.IsBundle(), p.IsDigital:
+		return ""
+	case p.Quantity <= 0:
+		return StockAlertOutOfStock
+	case p.Quantity <= p.ReorderPoint:
+		return StockAlertLowStock
+	default:
+		return ""
+	}
+}
+
+// TracksLots reports whether stock of the product is received in lots with
+// an expiry date. Category must be loaded.
+func (p *Product) TracksLots() bool {
+	return p.Category.TracksLots
+}
+
+// SetSerialType makes units of the product tracked by serial number or IMEI,
+// or not tracked individually when serialType is empty.
+func (p *Product) SetSerialType(serialType string) error {
+	if p.IsBundle() && len(serialType) > 0 {
+		return fmt.Errorf("bundles can not be serialized")
+	}
+	if p.IsDigital && len(serialType) > 0 {
+		return fmt.Errorf("digital products can not be serialized")
+	}
+	switch serialType {
+	case "", SerialTypeSerial, SerialTypeIMEI:
+		p.SerialType = serialType
+		return nil
+	default:
+		return fmt.Errorf("unknown serial type: %q", serialType)
+	}
+}
+
+// IsSerialized reports whether units of the product are tracked by serial
+// number.
+func (p *Product) IsSerialized() bool {
+	return len(p.SerialType) > 0
+}
+
+// ParseSerial checks a serial number of a unit of the product.
+func (p *Product) ParseSerial(serial string) (string, error) {
+	serial = strings.TrimSpace(serial)
+	switch {
+	case !p.IsSerialized():
+		return "", fmt.Errorf("%s is not serialized", p.Sku)
+	case len(serial) == 0:
+		return "", fmt.Errorf("serial number is required")
+	case len(serial) > 64:
+		return "", fmt.Errorf("serial number can not be longer than 64 characters")
+	case p.SerialType == SerialTypeIMEI && !ValidIMEI(serial):
+		return "", fmt.Errorf("invalid IMEI: %q", serial)
+	}
+	return serial, nil
+}
+
+// SetGTIN sets the barcode of the product after checking its check digit.
+// UPC-A codes are stored as EAN-13. An empty code removes the barcode.
+func (p *Product) SetGTIN(code string) error {
+	if len(strings.TrimSpace(code)) == 0 {
+		p.GTIN = nil
+		return nil
+	}
+	gtin, err := barcode.ParseGTIN(code)
+	if err != nil {
+		return err
+	}
+	p.GTIN = &gtin
+	return nil
+}
+
+// IsProductStatus reports whether status is a known product status.
+func IsProductStatus(status string) bool {
+	_, ok := productTransitions[status]
+	return ok
+}
+
+// IsPublished reports whether customers can see and buy the product.
+func (p *Product) IsPublished() bool {
+	return p.Status == ProductStatusPublished
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// EffectivePrice returns the sale price when one was in effect at load time
+// and the list price otherwise. Only the active sale price is ever loaded
+// into SalePrice.
+func (p *Product) EffectivePrice() money.Money {
+	if p.SalePrice != nil {
+		return p.SalePrice.Price
+	}
+	return p.UnitPrice
+}
@@ -0,0 +1,70 @@
+package entity
+
+import (
+	"strconv"
+	"strings"
+)
+
+type ProductFilter struct {
+	CategoryID uint32
+	Attributes []AttributeFilter
+	// Statuses limits the products to the given statuses; empty means all.
+	Statuses []string
+	// Sort orders the products, by id when empty.
+	Sort string
+}
+
+// Orders of product listings. Products rated best come first by rating,
+// those with more reviews first among equally rated ones.
+const (
+	ProductSortRating = "rating"
+)
+
+// IsProductSort reports whether sort is a known order of product listings.
+func IsProductSort(sort string) bool {
+	return sort == "" || sort == ProductSortRating
+}
+
+type AttributeFilter struct {
+	Code   string
+	Values []string
+	Min    *float64
+	Max    *float64
+}
+
+type Facet struct {
+	Code   string       `json:"code"`
+	Name   string       `json:"name"`
+	Type   string       `json:"type"`
+	Unit   string       `json:"unit"`
+	Values []FacetValue `json:"values"`
+}
+
+type FacetValue struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// NewAttributeFilter parses a raw filter expression. "min..max" (either side
+// may be omitted) is a numeric range, anything else is a comma separated list
+// of accepted values.
+func NewAttributeFilter(code string, raw string) AttributeFilter {
+	filter := AttributeFilter{Code: strings.ToLower(code)}
+
+	if bounds := strings.SplitN(raw, "..", 2); len(bounds) == 2 {
+		if min, err := strconv.ParseFloat(bounds[0], 64); err == nil {
+			filter.Min = &min
+		}
+		if max, err := strconv.ParseFloat(bounds[1], 64); err == nil {
+			filter.Max = &max
+		}
+		return filter
+	}
+
+	for _, v := range strings.Split(raw, ",") {
+		if v = strings.TrimSpace(v); len(v) > 0 {
+			filter.Values = append(filter.Values, v)
+		}
+	}
+	return filter
+}
@@ -0,0 +1,41 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+type ProductImage struct {
+	ID           uint32    `gorm:"primary_key;auto_increment" json:"id"`
+	ProductID    uint32    `gorm:"not null;index" json:"product_id"`
+	Key          string    `gorm:"size:255;not null;" json:"key"`
+	ThumbnailKey string    `gorm:"size:255;not null;" json:"thumbnail_key"`
+	ContentType  string    `gorm:"size:100;not null;" json:"content_type"`
+	Size         int64     `gorm:"not null;" json:"size"`
+	Width        int       `json:"width"`
+	Height       int       `json:"height"`
+	Position     int       `gorm:"not null;" json:"position"`
+	IsPrimary    bool      `gorm:"not null;" json:"is_primary"`
+	CreatedAt    time.Time `gorm:"<-:create" json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func NewProductImage(productId uint32, key string, thumbnailKey string, contentType string,
+	size int64, width int, height int) (*ProductImage, error) {
+	if len(key) == 0 || len(thumbnailKey) == 0 {
+		return nil, fmt.Errorf("image keys are required")
+	}
+	return &ProductImage{
+		ProductID:    productId,
+		Key:          key,
+		ThumbnailKey: thumbnailKey,
+		ContentType:  contentType,
+		Size:         size,
+		Width:        width,
+		Height:       height,
+	}, nil
+}
+
+func (ProductImage) TableName() string {
+	return "product_image"
+}
@@ -0,0 +1,221 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/bestetufan/beste-store/pkg/measure"
+	"github.com/bestetufan/beste-store/pkg/money"
+	"gorm.io/gorm"
+)
+
+const (
+	RevisionActionCreated  = "created"
+	RevisionActionUpdated  = "updated"
+	RevisionActionDeleted  = "deleted"
+	RevisionActionRestored = "restored"
+)
+
+var errRevisionImmutable = errors.New("product history can not be modified")
+
+// ProductRevision records one change of a product. Revisions are never
+// updated or deleted, even when the product itself is purged.
+type ProductRevision struct {
+	ID        uint32          `gorm:"primary_key;auto_increment" json:"id"`
+	ProductID uint32          `gorm:"not null;index" json:"product_id"`
+	Action    string          `gorm:"size:20;not null" json:"action"`
+	UserName  string          `gorm:"size:255" json:"user_name"`
+	Changes   []ProductChange `gorm:"serializer:json" json:"changes"`
+	CreatedAt time.Time       `gorm:"<-:create" json:"created_at"`
+}
+
+// ProductChange is the old and new value of a field. Attribute values use
+// "attr.<code>" field names and the quantities of bundle components
+// "component.<product id>".
+type ProductChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// ProductPrice is a price a product had from CreatedAt until the next one.
+type ProductPrice struct {
+	ID        uint32      `gorm:"primary_key;auto_increment" json:"-"`
+	ProductID uint32      `gorm:"not null;index" json:"-"`
+	Price     money.Money `gorm:"embedded;embeddedPrefix:Price" json:"price" swaggertype:"number"`
+	UserName  string      `gorm:"size:255" json:"-"`
+	CreatedAt time.Time   `gorm:"<-:create;index" json:"changed_at"`
+}
+
+func NewProductRevision(productId uint32, action string, userName string, changes []ProductChange) *ProductRevision {
+	return &ProductRevision{
+		ProductID: productId,
+		Action:    action,
+		UserName:  userName,
+		Changes:   changes,
+	}
+}
+
+func NewProductPrice(productId uint32, price money.Money, userName string) *ProductPrice {
+	return &ProductPrice{
+		ProductID: productId,
+		Price:     price,
+		UserName:  userName,
+	}
+}
+
+// ProductChanges lists the fields that differ between two versions of a
+// product. A nil before lists every field of after as new.
+func ProductChanges(before, after *Product) []ProductChange {
+	var changes []ProductChange
+	created := before == nil
+	add := func(field string, old, new interface{}) {
+		if created {
+			old = nil
+		}
+		if old != new {
+			changes = append(changes, ProductChange{Field: field, Old: old, New: new})
+		}
+	}
+
+	if created {
+		before = &Product{}
+	}
+	add("name", before.Name, after.Name)
+	add("sku", before.Sku, after.Sku)
+	add("gtin", formatString(before.GTIN), formatString(after.GTIN))
+	add("unit_price", json.Number(before.UnitPrice.String()), json.Number(after.UnitPrice.String()))
+	add("currency", before.UnitPrice.Currency, after.UnitPrice.Currency)
+	add("unit", before.Unit, after.Unit)
+	add("quantity_step", before.QuantityStep, after.QuantityStep)
+	add("min_quantity", before.MinQuantity, after.MinQuantity)
+	add("quantity", before.Quantity, after.Quantity)
+	add("reorder_point", before.ReorderPoint, after.ReorderPoint)
+	add("reorder_quantity", before.ReorderQuantity, after.ReorderQuantity)
+	add("serial_type", before.SerialType, after.SerialType)
+	add("bundle_pricing", before.BundlePricing, after.BundlePricing)
+	add("bundle_discount", before.BundleDiscount, after.BundleDiscount)
+	add("is_digital", before.IsDigital, after.IsDigital)
+	add("file", fileName(before.File), fileName(after.File))
+	add("category_id", before.CategoryID, after.CategoryID)
+	add("status", before.Status, after.Status)
+	add("publish_at", formatTime(before.PublishAt), formatTime(after.PublishAt))
+
+	oldValues, newValues := attributeValues(before), attributeValues(after)
+	codes := make([]string, 0, len(oldValues)+len(newValues))
+	for code := range oldValues {
+		codes = append(codes, code)
+	}
+	for code := range newValues {
+		if _, ok := oldValues[code]; !ok {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+
+	for _, code := range codes {
+		var old, new interface{}
+		if v, ok := oldValues[code]; ok {
+			old = v
+		}
+		if v, ok := newValues[code]; ok {
+			new = v
+		}
+		if old != new {
+			changes = append(changes, ProductChange{Field: "attr." + code, Old: old, New: new})
+		}
+	}
+
+	oldComponents, newComponents := componentQuantities(before), componentQuantities(after)
+	ids := make([]uint32, 0, len(oldComponents)+len(newComponents))
+	for id := range oldComponents {
+		ids = append(ids, id)
+	}
+	for id := range newComponents {
+		if _, ok := oldComponents[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for _, id := range ids {
+		var old, new interface{}
+		if q, ok := oldComponents[id]; ok {
+			old = q
+		}
+		if q, ok := newComponents[id]; ok {
+			new = q
+		}
+		if old != new {
+			changes = append(changes, ProductChange{Field: fmt.Sprintf("component.%d", id), Old: old, New: new})
+		}
+	}
+
+	return changes
+}
+
+func formatTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func formatString(s *string) interface{} {
+	if s == nil {
+		return nil
+	}
+	return *s
+}
+
+func fileName(f *ProductFile) interface{} {
+	if f == nil {
+		return nil
+	}
+	return f.FileName
+}
+
+func attributeValues(p *Product) map[string]string {
+	values := make(map[string]string, len(p.Attributes))
+	for _, a := range p.Attributes {
+		if a.Attribute != nil {
+			values[a.Attribute.Code] = a.Value
+		}
+	}
+	return values
+}
+
+func componentQuantities(p *Product) map[uint32]measure.Quantity {
+	quantities := make(map[uint32]measure.Quantity, len(p.Components))
+	for _, c := range p.Components {
+		quantities[c.ProductID] = c.Quantity
+	}
+	return quantities
+}
+
+func (ProductRevision) TableName() string {
+	return "product_revision"
+}
+
+func (ProductRevision) BeforeUpdate(tx *gorm.DB) error {
+	return errRevisionImmutable
+}
+
+func (ProductRevision) BeforeDelete(tx *gorm.DB) error {
+	return errRevisionImmutable
+}
+
+func (ProductPrice) TableName() string {
+	return "product_price"
+}
+
+func (ProductPrice) BeforeUpdate(tx *gorm.DB) error {
+	return errRevisionImmutable
+}
+
+func (ProductPrice) BeforeDelete(tx *gorm.DB) error {
+	return errRevisionImmutable
+}
@@ -0,0 +1,193 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bestetufan/beste-store/pkg/measure"
+	"github.com/bestetufan/beste-store/pkg/money"
+)
+
+const (
+	PurchaseOrderStatusDraft             = "draft"
+	PurchaseOrderStatusOrdered           = "ordered"
+	PurchaseOrderStatusPartiallyReceived = "partially_received"
+	PurchaseOrderStatusReceived          = "received"
+	PurchaseOrderStatusCanceled          = "canceled"
+)
+
+// PurchaseOrder is an order of products from a supplier, to be received
+// into a warehouse. Drafts can be changed freely; once ordered, the items
+// are received, at once or in parts, until everything has arrived or the
+// rest is canceled.
+type PurchaseOrder struct {
+	ID          uint32               `gorm:"primary_key;auto_increment" json:"id"`
+	SupplierID  uint32               `gorm:"not null;index" json:"supplier_id"`
+	Supplier    *Supplier            `json:"supplier,omitempty"`
+	WarehouseID uint32               `gorm:"not null" json:"warehouse_id"`
+	Warehouse   *Warehouse           `json:"warehouse,omitempty"`
+	Status      string               `gorm:"size:20;not null;index" json:"status"`
+	Currency    string               `gorm:"size:3;not null" json:"currency"`
+	Note        string               `gorm:"size:255" json:"note"`
+	UserName    string               `gorm:"size:255" json:"user_name"`
+	Items       []*PurchaseOrderItem `gorm:"foreignkey:PurchaseOrderID" json:"items"`
+	OrderedAt   *time.Time           `json:"ordered_at"`
+	ReceivedAt  *time.Time           `json:"received_at"`
+	CreatedAt   time.Time            `gorm:"<-:create" json:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at"`
+}
+
+type PurchaseOrderItem struct {
+	ID               uint32           `gorm:"primary_key;auto_increment" json:"id"`
+	PurchaseOrderID  uint32           `gorm:"not null;index" json:"purchase_order_id"`
+	ProductID        uint32           `gorm:"not null;index" json:"product_id"`
+	Product          *Product         `json:"product,omitempty"`
+	Quantity         measure.Quantity `gorm:"not null" json:"quantity" swaggertype:"number"`
+	ReceivedQuantity measure.Quantity `gorm:"not null;default:0" json:"received_quantity" swaggertype:"number"`
+	UnitCost         money.Money      `gorm:"embedded;embeddedPrefix:UnitCost" json:"unit_cost" swaggertype:"number"`
+}
+
+// PurchaseOrderFilter narrows down a purchase order listing. Zero values
+// match every purchase order.
+type PurchaseOrderFilter struct {
+	SupplierID uint32
+	Status     string
+}
+
+// NewPurchaseOrder creates a draft purchase order in the currency of the
+// supplier.
+func NewPurchaseOrder(supplier *Supplier, warehouseId uint32, note string, userName string) *PurchaseOrder {
+	return &PurchaseOrder{
+		SupplierID:  supplier.ID,
+		WarehouseID: warehouseId,
+		Status:      PurchaseOrderStatusDraft,
+		Currency:    supplier.Currency,
+		Note:        note,
+		UserName:    userName,
+	}
+}
+
+func (PurchaseOrder) TableName() string {
+	return "purchase_order"
+}
+
+func (PurchaseOrderItem) TableName() string {
+	return "purchase_order_item"
+}
+
+// AddItem adds quantity units of a product at unitCost, which must be in the
+// currency of the purchase order.
+func (p *PurchaseOrder) AddItem(productId uint32, quantity measure.Quantity, unitCost money.Money) error {
+	if quantity <= 0 {
+		return fmt.Errorf("quantity must be greater than zero")
+	}
+	if unitCost.Amount < 0 {
+		return fmt.Errorf("unit_cost can not be negative")
+	}
+	if unitCost.Currency != p.Currency {
+		return fmt.Errorf("unit_cost must be in %s", p.Currency)
+	}
+	if p.Item(productId) != nil {
+		return fmt.Errorf("product %d is already in the purchase order", productId)
+	}
+
+	p.Items = append(p.Items, &PurchaseOrderItem{
+		PurchaseOrderID: p.ID,
+		ProductID:       productId,
+		Quantity:        quantity,
+		UnitCost:        unitCost,
+	})
+	return nil
+}
+
+// Item returns the item of a product, or nil.
+func (p *PurchaseOrder) Item(productId uint32) *PurchaseOrderItem {
+	for _, item := range p.Items {
+		if item.ProductID == productId {
+			return item
+		}
+	}
+	return nil
+}
+
+// Total returns the cost of all items.
+func (p *PurchaseOrder) Total() money.Money {
+	total := money.New(0, p.Currency)
+	for _, item := range p.Items {
+		total.Amount += item.UnitCost.MulRat(item.Quantity.Rat()).Amount
+	}
+	return total
+}
+
+// IsDraft reports whether the purchase order can still be changed.
+func (p *PurchaseOrder) IsDraft() bool {
+	return p.Status == PurchaseOrderStatusDraft
+}
+
+// CanReceive reports whether items of the purchase order can be received.
+func (p *PurchaseOrder) CanReceive() bool {
+	return p.Status == PurchaseOrderStatusOrdered || p.Status == PurchaseOrderStatusPartiallyReceived
+}
+
+// Order marks a draft as sent to the supplier.
+func (p *PurchaseOrder) Order(now time.Time) error {
+	if !p.IsDraft() {
+		return fmt.Errorf("purchase order is %s", p.Status)
+	}
+	if len(p.Items) == 0 {
+		return fmt.Errorf("purchase order has no items")
+	}
+	p.Status, p.OrderedAt = PurchaseOrderStatusOrdered, &now
+	return nil
+}
+
+// Cancel cancels a draft, or the items of an ordered purchase order that
+// have not been received yet. Received items stay in stock.
+func (p *PurchaseOrder) Cancel() error {
+	if !p.IsDraft() && !p.CanReceive() {
+		return fmt.Errorf("purchase order is %s", p.Status)
+	}
+	p.Status = PurchaseOrderStatusCanceled
+	return nil
+}
+
+// SetReceived records the quantity received of each item, e.g. as
+// quantities of its receipts in the stock ledger, and updates the status.
+func (p *PurchaseOrder) SetReceived(received map[uint32]measure.Quantity, now time.Time) {
+	complete, started := true, false
+	for _, item := range p.Items {
+		item.ReceivedQuantity = received[item.ProductID]
+		if item.ReceivedQuantity > 0 {
+			started = true
+		}
+		if item.Outstanding() > 0 {
+			complete = false
+		}
+	}
+
+	switch {
+	case complete:
+		p.Status, p.ReceivedAt = PurchaseOrderStatusReceived, &now
+	case started:
+		p.Status = PurchaseOrderStatusPartiallyReceived
+	}
+}
+
+// Outstanding returns the quantity still to be received.
+func (i *PurchaseOrderItem) Outstanding() measure.Quantity {
+	if i.ReceivedQuantity >= i.Quantity {
+		return 0
+	}
+	return i.Quantity - i.ReceivedQuantity
+}
+
+// IsPurchaseOrderStatus reports whether status is a known purchase order
+// status.
+func IsPurchaseOrderStatus(status string) bool {
+	switch status {
+	case PurchaseOrderStatusDraft, PurchaseOrderStatusOrdered, PurchaseOrderStatusPartiallyReceived,
+		PurchaseOrderStatusReceived, PurchaseOrderStatusCanceled:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,27 @@
+package entity
+
+import "time"
+
+// Kinds of product recommendations. Products bought together were in the
+// same orders as the product; products customers also bought were ordered,
+// at any time, by the customers who ordered the product.
+const (
+	RecommendationBoughtTogether = "bought_together"
+	RecommendationAlsoBought     = "also_bought"
+)
+
+// ProductRecommendation is a product recommended with another, precomputed
+// from the order history. Count is the number of orders, or of customers,
+// that bought both; recommendations of a product are ranked by Position.
+type ProductRecommendation struct {
+	ProductID uint32    `gorm:"primary_key" json:"product_id"`
+	Kind      string    `gorm:"primary_key;size:20" json:"kind"`
+	RelatedID uint32    `gorm:"primary_key" json:"related_id"`
+	Position  int       `gorm:"not null" json:"position"`
+	Count     int       `gorm:"not null" json:"count"`
+	CreatedAt time.Time `gorm:"<-:create" json:"created_at"`
+}
+
+func (ProductRecommendation) TableName() string {
+	return "product_recommendation"
+}
@@ -0,0 +1,79 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	ReviewStatusPending  = "pending"
+	ReviewStatusApproved = "approved"
+	ReviewStatusRejected = "rejected"
+)
+
+// Review is the rating, from 1 to 5, and opinion of a customer who received
+// a product. Reviews are shown and counted towards the rating of the product
+// once approved by an admin.
+type Review struct {
+	ID          uint32     `gorm:"primary_key;auto_increment" json:"id"`
+	ProductID   uint32     `gorm:"not null;uniqueIndex:idx_review_product_user" json:"product_id"`
+	UserName    string     `gorm:"size:255;not null;uniqueIndex:idx_review_product_user" json:"user_name"`
+	Rating      int        `gorm:"not null" json:"rating"`
+	Title       string     `gorm:"size:255;not null" json:"title"`
+	Text        string     `gorm:"type:text" json:"text"`
+	Status      string     `gorm:"size:20;not null;index" json:"status"`
+	ModeratedBy string     `gorm:"size:255" json:"moderated_by,omitempty"`
+	ModeratedAt *time.Time `json:"moderated_at,omitempty"`
+	CreatedAt   time.Time  `gorm:"<-:create" json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// ReviewFilter narrows down a review listing. Zero values match every
+// review.
+type ReviewFilter struct {
+	ProductID uint32
+	Status    string
+}
+
+// NewReview creates a review waiting for moderation.
+func NewReview(productId uint32, userName string, rating int, title string, text string) (*Review, error) {
+	if rating < 1 || rating > 5 {
+		return nil, fmt.Errorf("rating must be between 1 and 5")
+	}
+	title = strings.TrimSpace(title)
+	if len(title) == 0 {
+		return nil, fmt.Errorf("title is required")
+	}
+	return &Review{
+		ProductID: productId,
+		UserName:  userName,
+		Rating:    rating,
+		Title:     title,
+		Text:      strings.TrimSpace(text),
+		Status:    ReviewStatusPending,
+	}, nil
+}
+
+func (Review) TableName() string {
+	return "product_review"
+}
+
+// Moderate approves or rejects the review. A review can be moderated again,
+// e.g. to take down an approved one.
+func (r *Review) Moderate(approved bool, userName string, now time.Time) {
+	r.Status = ReviewStatusRejected
+	if approved {
+		r.Status = ReviewStatusApproved
+	}
+	r.ModeratedBy, r.ModeratedAt = userName, &now
+}
+
+// IsReviewStatus reports whether status is a known review status.
+func IsReviewStatus(status string) bool {
+	switch status {
+	case ReviewStatusPending, ReviewStatusApproved, ReviewStatusRejected:
+		return true
+	}
+	return false
+}
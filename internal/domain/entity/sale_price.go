@@ -0,0 +1,48 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bestetufan/beste-store/pkg/money"
+)
+
+// SalePrice replaces the list price of a product from StartsAt until EndsAt.
+// Sale prices of a product never overlap.
+type SalePrice struct {
+	ID        uint32      `gorm:"primary_key;auto_increment" json:"id"`
+	ProductID uint32      `gorm:"not null;index" json:"product_id"`
+	Price     money.Money `gorm:"embedded;embeddedPrefix:Price" json:"price" swaggertype:"number"`
+	StartsAt  time.Time   `gorm:"not null;index" json:"starts_at"`
+	EndsAt    time.Time   `gorm:"not null;index" json:"ends_at"`
+	CreatedAt time.Time   `gorm:"<-:create" json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+func NewSalePrice(productId uint32, price money.Money, startsAt time.Time, endsAt time.Time) (*SalePrice, error) {
+	if price.Amount <= 0 {
+		return nil, fmt.Errorf("price must be greater than zero")
+	}
+	if !endsAt.After(startsAt) {
+		return nil, fmt.Errorf("ends_at must be after starts_at")
+	}
+	return &SalePrice{
+		ProductID: productId,
+		Price:     price,
+		StartsAt:  startsAt,
+		EndsAt:    endsAt,
+	}, nil
+}
+
+func (SalePrice) TableName() string {
+	return "sale_price"
+}
+
+// IsActive reports whether the sale price applies at time t.
+func (s *SalePrice) IsActive(t time.Time) bool {
+	return !t.Before(s.StartsAt) && t.Before(s.EndsAt)
+}
+
+func (s *SalePrice) Overlaps(o *SalePrice) bool {
+	return s.StartsAt.Before(o.EndsAt) && o.StartsAt.Before(s.EndsAt)
+}
@@ -0,0 +1,94 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	SerialNumberInStock = "in_stock"
+	SerialNumberSold    = "sold"
+	SerialNumberRemoved = "removed"
+)
+
+// SerialNumber is one unit of a serialized product, registered when it is
+// received into a warehouse. Sold units belong to the item of the order they
+// were assigned to at fulfillment; units taken out of stock by an adjustment,
+// e.g. damaged or stolen ones, are removed.
+type SerialNumber struct {
+	ID          uint32     `gorm:"primary_key;auto_increment" json:"id"`
+	ProductID   uint32     `gorm:"not null;uniqueIndex:idx_serial_number_product_serial" json:"product_id"`
+	Product     *Product   `json:"product,omitempty"`
+	Serial      string     `gorm:"size:64;not null;uniqueIndex:idx_serial_number_product_serial;index" json:"serial"`
+	WarehouseID uint32     `gorm:"not null" json:"warehouse_id"`
+	Status      string     `gorm:"size:20;not null;index" json:"status"`
+	OrderID     *string    `gorm:"size:36;index" json:"order_id"`
+	Reference   string     `gorm:"size:100" json:"reference"`
+	UserName    string     `gorm:"size:255" json:"user_name"`
+	SoldAt      *time.Time `json:"sold_at"`
+	CreatedAt   time.Time  `gorm:"<-:create" json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// NewSerialNumber registers a unit of product received into a warehouse.
+// Serials of products with IMEIs must be valid IMEIs.
+func NewSerialNumber(product *Product, warehouseId uint32, serial string, reference string,
+	userName string) (*SerialNumber, error) {
+	serial, err := product.ParseSerial(serial)
+	if err != nil {
+		return nil, err
+	}
+	return &SerialNumber{
+		ProductID:   product.ID,
+		Serial:      serial,
+		WarehouseID: warehouseId,
+		Status:      SerialNumberInStock,
+		Reference:   reference,
+		UserName:    userName,
+	}, nil
+}
+
+func (SerialNumber) TableName() string {
+	return "serial_number"
+}
+
+// Sell assigns the unit to an item of an order.
+func (s *SerialNumber) Sell(orderId string, now time.Time) error {
+	if s.Status != SerialNumberInStock {
+		return fmt.Errorf("serial %s is %s", s.Serial, strings.Replace(s.Status, "_", " ", 1))
+	}
+	s.Status, s.OrderID, s.SoldAt = SerialNumberSold, &orderId, &now
+	return nil
+}
+
+// Remove takes the unit out of stock.
+func (s *SerialNumber) Remove() error {
+	if s.Status != SerialNumberInStock {
+		return fmt.Errorf("serial %s is %s", s.Serial, strings.Replace(s.Status, "_", " ", 1))
+	}
+	s.Status = SerialNumberRemoved
+	return nil
+}
+
+// ValidIMEI reports whether imei is 15 digits with a valid Luhn check digit.
+func ValidIMEI(imei string) bool {
+	if len(imei) != 15 {
+		return false
+	}
+	sum := 0
+	for i := 0; i < len(imei); i++ {
+		d := int(imei[i] - '0')
+		if d < 0 || d > 9 {
+			return false
+		}
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
@@ -0,0 +1,31 @@
+package entity
+
+import "testing"
+
+func TestValidIMEI(t *testing.T) {
+	tests := []struct {
+		imei string
+		want bool
+	}{
+		{"490154203237518", true},
+		{"356938035643809", true},
+		{"000000000000000", true},
+		{"490154203237519", false},
+		{"356938035643808", false},
+		{"49015420323751", false},
+		{"4901542032375180", false},
+		{"", false},
+		{"49015420323751a", false},
+		{"49015420323751/", false},
+		{"490154 03237518", false},
+		{"-90154203237518", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.imei, func(t *testing.T) {
+			if got := ValidIMEI(tt.imei); got != tt.want {
+				t.Errorf("ValidIMEI(%q) = %v, want %v", tt.imei, got, tt.want)
+			}
+		})
+	}
+}
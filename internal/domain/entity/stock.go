@@ -0,0 +1,175 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bestetufan/beste-store/pkg/measure"
+	"gorm.io/gorm"
+)
+
+const (
+	StockMovementReceipt    = "receipt"
+	StockMovementSale       = "sale"
+	StockMovementReturn     = "return"
+	StockMovementAdjustment = "adjustment"
+	StockMovementExpiry     = "expiry"
+	// StockMovementReservation movements held stock for baskets before
+	// reservations were kept apart from the ledger as StockReservation.
+	StockMovementReservation = "reservation"
+)
+
+var errStockMovementImmutable = errors.New("stock movements can not be modified")
+
+// StockMovement is one entry of the stock ledger: Quantity units of a
+// product coming into (positive) or going out of (negative) a warehouse.
+// Movements are never updated or deleted; the on-hand quantity of a product
+// in a warehouse is the sum of its movements.
+//
+// Reference ties related movements together, e.g. "basket:<id>" for the
+// reservations of a basket, "order:<id>" for the sale of an order and
+// "purchase_order:<id>" for the receipts of a purchase order. Movements of
+// units in a lot carry its LotID.
+type StockMovement struct {
+	ID          uint32           `gorm:"primary_key;auto_increment" json:"id"`
+	ProductID   uint32           `gorm:"not null;index" json:"product_id"`
+	WarehouseID uint32           `gorm:"not null;index" json:"warehouse_id"`
+	LotID       *uint32          `gorm:"index" json:"lot_id"`
+	Type        string           `gorm:"size:20;not null;index" json:"type"`
+	Quantity    measure.Quantity `gorm:"not null" json:"quantity" swaggertype:"number"`
+	Reference   string           `gorm:"size:100;index" json:"reference"`
+	Note        string           `gorm:"size:255" json:"note"`
+	UserName    string           `gorm:"size:255" json:"user_name"`
+	CreatedAt   time.Time        `gorm:"<-:create;index" json:"created_at"`
+}
+
+// StockLevel is the on-hand quantity of a product in a warehouse, kept in
+// step with the stock ledger, and the part of it reserved for baskets.
+type StockLevel struct {
+	ProductID   uint32           `gorm:"primary_key" json:"product_id"`
+	WarehouseID uint32           `gorm:"primary_key" json:"warehouse_id"`
+	Warehouse   *Warehouse       `gorm:"foreignkey:WarehouseID" json:"warehouse"`
+	Quantity    measure.Quantity `gorm:"not null;default:0" json:"quantity" swaggertype:"number"`
+	Reserved    measure.Quantity `gorm:"not null;default:0" json:"reserved" swaggertype:"number"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+// StockReservation holds Quantity units of a product in a warehouse for a
+// basket until ExpiresAt. Reserved units stay on hand but can not be sold
+// to anyone else; expired reservations are released by a background job.
+type StockReservation struct {
+	ID          uint32           `gorm:"primary_key;auto_increment" json:"id"`
+	ProductID   uint32           `gorm:"not null;index" json:"product_id"`
+	WarehouseID uint32           `gorm:"not null" json:"warehouse_id"`
+	BasketID    string           `gorm:"size:36;not null;index" json:"basket_id"`
+	Quantity    measure.Quantity `gorm:"not null" json:"quantity" swaggertype:"number"`
+	ExpiresAt   time.Time        `gorm:"not null;index" json:"expires_at"`
+	CreatedAt   time.Time        `gorm:"<-:create" json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+// StockMovementFilter narrows a listing of stock movements; zero values
+// match everything.
+type StockMovementFilter struct {
+	ProductID   uint32
+	WarehouseID uint32
+	Type        string
+	Reference   string
+}
+
+// stockMovementSigns lists the sign each type of movement must have: 1 for
+// incoming, -1 for outgoing and 0 for either.
+var stockMovementSigns = map[string]int{
+	StockMovementReceipt:     1,
+	StockMovementSale:        -1,
+	StockMovementReturn:      1,
+	StockMovementAdjustment:  0,
+	StockMovementExpiry:      -1,
+	StockMovementReservation: 0,
+}
+
+func NewStockReservation(productId uint32, warehouseId uint32, basketId string, quantity measure.Quantity,
+	expiresAt time.Time) (*StockReservation, error) {
+	if quantity <= 0 {
+		return nil, fmt.Errorf("quantity must be greater than zero")
+	}
+	return &StockReservation{
+		ProductID:   productId,
+		WarehouseID: warehouseId,
+		BasketID:    basketId,
+		Quantity:    quantity,
+		ExpiresAt:   expiresAt,
+	}, nil
+}
+
+func NewStockMovement(productId uint32, warehouseId uint32, movementType string, quantity measure.Quantity,
+	reference string, note string, userName string) (*StockMovement, error) {
+	sign, ok := stockMovementSigns[movementType]
+	if !ok {
+		return nil, fmt.Errorf("unknown movement type: %q", movementType)
+	}
+	if quantity == 0 {
+		return nil, fmt.Errorf("quantity can not be zero")
+	}
+	if sign > 0 && quantity < 0 || sign < 0 && quantity > 0 {
+		return nil, fmt.Errorf("quantity of a %s can not be %s", movementType, quantity)
+	}
+	return &StockMovement{
+		ProductID:   productId,
+		WarehouseID: warehouseId,
+		Type:        movementType,
+		Quantity:    quantity,
+		Reference:   reference,
+		Note:        note,
+		UserName:    userName,
+	}, nil
+}
+
+// IsStockMovementType reports whether movementType is a known type of stock
+// movement.
+func IsStockMovementType(movementType string) bool {
+	_, ok := stockMovementSigns[movementType]
+	return ok
+}
+
+func (StockMovement) TableName() string {
+	return "stock_movement"
+}
+
+func (StockMovement) BeforeUpdate(tx *gorm.DB) error {
+	return errStockMovementImmutable
+}
+
+func (StockMovement) BeforeDelete(tx *gorm.DB) error {
+	return errStockMovementImmutable
+}
+
+func (StockLevel) TableName() string {
+	return "stock_level"
+}
+
+// Available returns the quantity that can still be sold.
+func (l *StockLevel) Available() measure.Quantity {
+	return l.Quantity - l.Reserved
+}
+
+func (StockReservation) TableName() string {
+	return "stock_reservation"
+}
+
+// BasketReference is the stock movement reference of the items of a basket.
+func BasketReference(basketId string) string {
+	return "basket:" + basketId
+}
+
+// OrderReference is the stock movement reference of the items of an order.
+func OrderReference(orderId string) string {
+	return "order:" + orderId
+}
+
+// PurchaseOrderReference is the stock movement reference of the receipts of
+// a purchase order.
+func PurchaseOrderReference(purchaseOrderId uint32) string {
+	return fmt.Sprintf("purchase_order:%d", purchaseOrderId)
+}
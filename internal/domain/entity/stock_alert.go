@@ -0,0 +1,35 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/bestetufan/beste-store/pkg/measure"
+)
+
+const (
+	StockAlertLowStock   = "low_stock"
+	StockAlertOutOfStock = "out_of_stock"
+)
+
+// StockAlert is the last stock alert sent for a product. A product has one
+// only while its stock is low or out, so that an alert is sent once when the
+// stock drops and again only when its level changes.
+type StockAlert struct {
+	ProductID uint32           `gorm:"primary_key;auto_increment:false" json:"product_id"`
+	Level     string           `gorm:"size:20;not null" json:"level"`
+	Quantity  measure.Quantity `gorm:"not null" json:"quantity" swaggertype:"number"`
+	CreatedAt time.Time        `gorm:"<-:create" json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+func NewStockAlert(product *Product) *StockAlert {
+	return &StockAlert{
+		ProductID: product.ID,
+		Level:     product.StockAlertLevel(),
+		Quantity:  product.Quantity,
+	}
+}
+
+func (StockAlert) TableName() string {
+	return "stock_alert"
+}
@@ -0,0 +1,69 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bestetufan/beste-store/pkg/measure"
+)
+
+// ExpiryDateLayout is the layout of the expiry dates of lots.
+const ExpiryDateLayout = "2006-01-02"
+
+// StockLot is a production lot of a product in a warehouse. Quantity of the
+// on-hand units of the product in the warehouse belong to the lot; the rest
+// are not in any lot. A lot can be sold through its expiry date and expires
+// when that day is over.
+type StockLot struct {
+	ID          uint32           `gorm:"primary_key;auto_increment" json:"id"`
+	ProductID   uint32           `gorm:"not null;uniqueIndex:idx_stock_lot_number" json:"product_id"`
+	Product     *Product         `json:"product,omitempty"`
+	WarehouseID uint32           `gorm:"not null;uniqueIndex:idx_stock_lot_number" json:"warehouse_id"`
+	Warehouse   *Warehouse       `json:"warehouse,omitempty"`
+	Number      string           `gorm:"size:50;not null;uniqueIndex:idx_stock_lot_number" json:"number"`
+	ExpiresAt   time.Time        `gorm:"not null;index" json:"expires_at"`
+	Quantity    measure.Quantity `gorm:"not null;default:0" json:"quantity" swaggertype:"number"`
+	CreatedAt   time.Time        `gorm:"<-:create" json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+func NewStockLot(productId uint32, warehouseId uint32, number string, expiresAt time.Time) (*StockLot, error) {
+	number = strings.TrimSpace(number)
+	if len(number) == 0 {
+		return nil, fmt.Errorf("lot number is required")
+	}
+	if len(number) > 50 {
+		return nil, fmt.Errorf("lot number can not be longer than 50 characters")
+	}
+	return &StockLot{
+		ProductID:   productId,
+		WarehouseID: warehouseId,
+		Number:      number,
+		ExpiresAt:   expiresAt,
+	}, nil
+}
+
+func (StockLot) TableName() string {
+	return "stock_lot"
+}
+
+// IsExpired reports whether the lot can no longer be sold at now.
+func (l *StockLot) IsExpired(now time.Time) bool {
+	return !l.ExpiresAt.After(LotExpiryCutoff(now))
+}
+
+// LotExpiryCutoff returns the expiry date at or before which lots are
+// expired at now.
+func LotExpiryCutoff(now time.Time) time.Time {
+	return now.AddDate(0, 0, -1)
+}
+
+// ParseExpiryDate reads an expiry date such as "2026-12-31".
+func ParseExpiryDate(date string) (time.Time, error) {
+	expiresAt, err := time.ParseInLocation(ExpiryDateLayout, strings.TrimSpace(date), time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid expiry date: %q", date)
+	}
+	return expiresAt, nil
+}
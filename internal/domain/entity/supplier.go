@@ -0,0 +1,82 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bestetufan/beste-store/pkg/money"
+	"gorm.io/gorm"
+)
+
+// Supplier is a company the store buys products from. Purchase orders to a
+// supplier are in its Currency.
+type Supplier struct {
+	ID        uint32    `gorm:"primary_key;auto_increment" json:"id"`
+	Name      string    `gorm:"size:255;not null;unique" json:"name"`
+	Email     string    `gorm:"size:255" json:"email"`
+	Phone     string    `gorm:"size:100" json:"phone"`
+	Currency  string    `gorm:"size:3;not null" json:"currency"`
+	CreatedAt time.Time `gorm:"<-:create" json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SupplierProduct is a product a supplier sells to the store and the cost
+// price it sells it at.
+type SupplierProduct struct {
+	SupplierID  uint32      `gorm:"primary_key;auto_increment:false" json:"supplier_id"`
+	ProductID   uint32      `gorm:"primary_key;auto_increment:false;index" json:"product_id"`
+	Supplier    *Supplier   `json:"supplier,omitempty"`
+	Product     *Product    `json:"product,omitempty"`
+	SupplierSku string      `gorm:"size:100" json:"supplier_sku"`
+	CostPrice   money.Money `gorm:"embedded;embeddedPrefix:CostPrice" json:"cost_price" swaggertype:"number"`
+	Currency    string      `gorm:"-" json:"currency"`
+	CreatedAt   time.Time   `gorm:"<-:create" json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+func NewSupplier(name string, email string, phone string, currency string) (*Supplier, error) {
+	name = strings.TrimSpace(name)
+	if len(name) == 0 {
+		return nil, fmt.Errorf("name field is required")
+	}
+	currency = strings.ToUpper(currency)
+	if !money.Valid(currency) {
+		return nil, fmt.Errorf("unknown currency: %q", currency)
+	}
+	return &Supplier{
+		Name:     name,
+		Email:    email,
+		Phone:    phone,
+		Currency: currency,
+	}, nil
+}
+
+func NewSupplierProduct(supplierId uint32, productId uint32, supplierSku string,
+	costPrice money.Money) (*SupplierProduct, error) {
+	if costPrice.Amount <= 0 {
+		return nil, fmt.Errorf("cost_price must be greater than zero")
+	}
+	return &SupplierProduct{
+		SupplierID:  supplierId,
+		ProductID:   productId,
+		SupplierSku: supplierSku,
+		CostPrice:   costPrice,
+		Currency:    costPrice.Currency,
+	}, nil
+}
+
+func (Supplier) TableName() string {
+	return "supplier"
+}
+
+func (SupplierProduct) TableName() string {
+	return "supplier_product"
+}
+
+// AfterFind exposes the currency of the cost price, which is written next to
+// the price in JSON.
+func (p *SupplierProduct) AfterFind(tx *gorm.DB) error {
+	p.Currency = p.CostPrice.Currency
+	return nil
+}
@@ -0,0 +1,72 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Types of records with translatable fields.
+const (
+	TranslationProduct  = "product"
+	TranslationCategory = "category"
+)
+
+const TranslationFieldName = "name"
+
+// translatableFields lists the translatable fields of each type of record
+// with their maximum length, which is that of the field itself.
+var translatableFields = map[string]map[string]int{
+	TranslationProduct:  {TranslationFieldName: 255},
+	TranslationCategory: {TranslationFieldName: 50},
+}
+
+// Translation is the value of a field of a product or category in another
+// locale than the default one of the store, which is kept on the record
+// itself. Translations are looked up by locale and value to search products
+// in the language of a customer.
+type Translation struct {
+	ID         uint32    `gorm:"primary_key;auto_increment" json:"-"`
+	EntityType string    `gorm:"size:20;not null;uniqueIndex:idx_translation_field,priority:1;index:idx_translation_search,priority:1" json:"entity_type"`
+	EntityID   uint32    `gorm:"not null;uniqueIndex:idx_translation_field,priority:2" json:"entity_id"`
+	Locale     string    `gorm:"size:35;not null;uniqueIndex:idx_translation_field,priority:3;index:idx_translation_search,priority:2" json:"locale"`
+	Field      string    `gorm:"size:20;not null;uniqueIndex:idx_translation_field,priority:4;index:idx_translation_search,priority:3" json:"field"`
+	Value      string    `gorm:"size:255;not null;index:idx_translation_search,priority:4" json:"value"`
+	UserName   string    `gorm:"size:255" json:"user_name"`
+	CreatedAt  time.Time `gorm:"<-:create" json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func NewTranslation(entityType string, entityId uint32, locale string, field string, value string,
+	userName string) (*Translation, error) {
+	fields, ok := translatableFields[entityType]
+	if !ok {
+		return nil, fmt.Errorf("unknown translation type: %q", entityType)
+	}
+	field = strings.ToLower(strings.TrimSpace(field))
+	maxLength, ok := fields[field]
+	if !ok {
+		return nil, fmt.Errorf("%s field is not translatable", field)
+	}
+	value = strings.TrimSpace(value)
+	if len(value) == 0 {
+		return nil, fmt.Errorf("%s field is required", field)
+	}
+	if utf8.RuneCountInString(value) > maxLength {
+		return nil, fmt.Errorf("%s field is longer than %d characters", field, maxLength)
+	}
+
+	return &Translation{
+		EntityType: entityType,
+		EntityID:   entityId,
+		Locale:     locale,
+		Field:      field,
+		Value:      value,
+		UserName:   userName,
+	}, nil
+}
+
+func (Translation) TableName() string {
+	return "translation"
+}
@@ -2,15 +2,18 @@ package entity
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type User struct {
-	ID        uint32    `gorm:"primary_key;auto_increment" json:"id"`
-	Email     string    `gorm:"size:255;not null;unique" json:"email"`
-	Password  string    `gorm:"size:100;not null;" json:"-"`
-	Roles     []*Role   `gorm:"many2many:user_roles;" json:"roles"`
-	CreatedAt time.Time `gorm:"<-:create" json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        uint32         `gorm:"primary_key;auto_increment" json:"id"`
+	Email     string         `gorm:"size:255;not null;unique" json:"email"`
+	Password  string         `gorm:"size:100;not null;" json:"-"`
+	Roles     []*Role        `gorm:"many2many:user_roles;" json:"roles"`
+	CreatedAt time.Time      `gorm:"<-:create" json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at" swaggertype:"string" format:"date-time"`
 }
 
 func NewUser(email string, password string, roles []*Role) *User {
@@ -0,0 +1,36 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Warehouse is a place stock is kept in. Stock added without a warehouse,
+// such as the initial stock of a product, goes to the default warehouse.
+type Warehouse struct {
+	ID        uint32    `gorm:"primary_key;auto_increment" json:"id"`
+	Code      string    `gorm:"size:50;not null;unique" json:"code"`
+	Name      string    `gorm:"size:255;not null" json:"name"`
+	IsDefault bool      `gorm:"not null;default:false" json:"is_default"`
+	CreatedAt time.Time `gorm:"<-:create" json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func NewWarehouse(code string, name string) (*Warehouse, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) == 0 {
+		return nil, fmt.Errorf("code field is required")
+	}
+	if len(name) == 0 {
+		return nil, fmt.Errorf("name field is required")
+	}
+	return &Warehouse{
+		Code: code,
+		Name: name,
+	}, nil
+}
+
+func (Warehouse) TableName() string {
+	return "warehouse"
+}
@@ -0,0 +1,50 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+// WishlistItem is a product a user saved to buy later.
+type WishlistItem struct {
+	UserName  string    `gorm:"primary_key;size:255" json:"user_name"`
+	ProductID uint32    `gorm:"primary_key" json:"product_id"`
+	Product   *Product  `gorm:"foreignkey:ProductID;references:ID" json:"product"`
+	CreatedAt time.Time `gorm:"<-:create" json:"created_at"`
+}
+
+func NewWishlistItem(userName string, productId uint32) (*WishlistItem, error) {
+	if len(userName) == 0 {
+		return nil, fmt.Errorf("userName field is required")
+	}
+	return &WishlistItem{
+		UserName:  userName,
+		ProductID: productId,
+	}, nil
+}
+
+func (WishlistItem) TableName() string {
+	return "wishlist_item"
+}
+
+// StockSubscription asks for a notification to the user when a product that
+// is out of stock becomes available again. It is removed once sent.
+type StockSubscription struct {
+	UserName  string    `gorm:"primary_key;size:255" json:"user_name"`
+	ProductID uint32    `gorm:"primary_key;index" json:"product_id"`
+	CreatedAt time.Time `gorm:"<-:create" json:"created_at"`
+}
+
+func NewStockSubscription(userName string, productId uint32) (*StockSubscription, error) {
+	if len(userName) == 0 {
+		return nil, fmt.Errorf("userName field is required")
+	}
+	return &StockSubscription{
+		UserName:  userName,
+		ProductID: productId,
+	}, nil
+}
+
+func (StockSubscription) TableName() string {
+	return "stock_subscription"
+}
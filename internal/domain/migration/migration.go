@@ -2,9 +2,13 @@ package migration
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"github.com/bestetufan/beste-store/pkg/measure"
+	"github.com/bestetufan/beste-store/pkg/money"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var roles = []entity.Role{
@@ -18,32 +22,148 @@ var users = []entity.User{
 
 var categories = []entity.Category{
 	{Name: "Cep Telefonu", IsActive: true},
+	{Name: "Market", IsActive: true, TracksLots: true},
+	{Name: "Saç Boyası", IsActive: true, TracksLots: true},
+	{Name: "Parfüm", IsActive: true, TracksLots: true},
 }
 
-func Execute(db *gorm.DB) error {
+// lotCategories lists the categories tracking lots once lots are introduced
+// to an existing database.
+var lotCategories = []string{"Market", "Saç Boyası", "Parfüm"}
+
+var defaultWarehouse = entity.Warehouse{Code: "MAIN", Name: "Main Warehouse", IsDefault: true}
+
+// priceColumns lists the float price columns replaced by money.Money columns
+// named <column>Amount and <column>Currency.
+var priceColumns = []struct {
+	model  interface{}
+	column string
+}{
+	{&entity.Product{}, "UnitPrice"},
+	{&entity.SalePrice{}, "Price"},
+	{&entity.ProductPrice{}, "Price"},
+	{&entity.OrderItem{}, "UnitPrice"},
+}
+
+// quantityColumns lists the columns holding quantities, which were whole
+// units before quantities were kept in thousandths of a unit.
+var quantityColumns = []struct {
+	model   interface{}
+	columns []string
+}{
+	{&entity.Product{}, []string{"Quantity", "Reserved", "ReorderPoint", "ReorderQuantity"}},
+	{&entity.BasketItem{}, []string{"Quantity"}},
+	{&entity.OrderItem{}, []string{"Quantity"}},
+	{&entity.StockMovement{}, []string{"Quantity"}},
+	{&entity.StockLevel{}, []string{"Quantity", "Reserved"}},
+	{&entity.StockReservation{}, []string{"Quantity"}},
+	{&entity.StockAlert{}, []string{"Quantity"}},
+	{&entity.StockLot{}, []string{"Quantity"}},
+	{&entity.PurchaseOrderItem{}, []string{"Quantity", "ReceivedQuantity"}},
+}
+
+// Execute creates or updates the tables and seeds an empty database.
+// currency is the currency of prices stored before amounts had one.
+func Execute(db *gorm.DB, currency string) error {
 	// Check if migration done
 	tables, err := db.Migrator().GetTables()
 	if err != nil {
 		return fmt.Errorf("seeder - Load - db.Migrator.GetTables: %w", err)
 	}
+	// Products have had a quantity step since quantities are kept in
+	// thousandths.
+	wholeQuantities := containsTable(tables, entity.Product{}.TableName()) &&
+		!db.Migrator().HasColumn(&entity.Product{}, "QuantityStep")
 
-	if len(tables) == 0 {
-		// Auto create tables
-		err = db.AutoMigrate(
-			&entity.User{},
-			&entity.Role{},
-			&entity.Basket{},
-			&entity.BasketItem{},
-			&entity.Category{},
-			&entity.Product{},
-			&entity.Order{},
-			&entity.OrderItem{},
-		)
+	// Auto create or update tables
+	err = db.AutoMigrate(
+		&entity.User{},
+		&entity.Role{},
+		&entity.Basket{},
+		&entity.BasketItem{},
+		&entity.Category{},
+		&entity.Attribute{},
+		&entity.Product{},
+		&entity.BundleComponent{},
+		&entity.ProductAttribute{},
+		&entity.ProductImage{},
+		&entity.ProductFile{},
+		&entity.ProductRevision{},
+		&entity.Review{},
+		&entity.ProductRecommendation{},
+		&entity.WishlistItem{},
+		&entity.StockSubscription{},
+		&entity.Translation{},
+		&entity.ProductPrice{},
+		&entity.SalePrice{},
+		&entity.Order{},
+		&entity.OrderItem{},
+		&entity.OrderItemComponent{},
+		&entity.Download{},
+		&entity.ExchangeRate{},
+		&entity.Warehouse{},
+		&entity.StockLevel{},
+		&entity.StockMovement{},
+		&entity.StockReservation{},
+		&entity.StockAlert{},
+		&entity.StockLot{},
+		&entity.SerialNumber{},
+		&entity.Supplier{},
+		&entity.SupplierProduct{},
+		&entity.PurchaseOrder{},
+		&entity.PurchaseOrderItem{},
+	)
+
+	if err != nil {
+		return fmt.Errorf("seeder - Load - db.AutoMigrate: %w", err)
+	}
+
+	for _, p := range priceColumns {
+		if err := convertPriceColumn(db, p.model, p.column, currency); err != nil {
+			return fmt.Errorf("seeder - Load - convertPriceColumn(%s): %w", p.column, err)
+		}
+	}
+
+	if wholeQuantities {
+		if err := scaleQuantityColumns(db); err != nil {
+			return fmt.Errorf("seeder - Load - scaleQuantityColumns: %w", err)
+		}
+	}
+
+	// Orders placed before currencies were recorded were charged in the
+	// currency of their products.
+	err = db.Model(&entity.Order{}).Where("Currency = ?", "").
+		UpdateColumns(map[string]interface{}{"Currency": currency, "PriceCurrency": currency, "ExchangeRate": 1}).Error
+	if err != nil {
+		return fmt.Errorf("seeder - Load - Model(Order).UpdateColumns: %w", err)
+	}
+
+	// Orders of digital products only were fulfilled at once before orders
+	// were delivered; they were delivered as downloads.
+	shipped := db.Table("order_item i").Select("i.OrderID").
+		Joins("JOIN product p ON p.ID = i.ProductID").Where("p.IsDigital = ?", false)
+	err = db.Model(&entity.Order{}).Where("Status = ? AND ID NOT IN (?)", entity.OrderStatusFulfilled, shipped).
+		UpdateColumn("Status", entity.OrderStatusDelivered).Error
+	if err != nil {
+		return fmt.Errorf("seeder - Load - Model(Order).UpdateColumn: %w", err)
+	}
+
+	if err := openStockLedger(db); err != nil {
+		return fmt.Errorf("seeder - Load - openStockLedger: %w", err)
+	}
 
+	if err := releaseReservationMovements(db); err != nil {
+		return fmt.Errorf("seeder - Load - releaseReservationMovements: %w", err)
+	}
+
+	if len(tables) > 0 && !containsTable(tables, entity.StockLot{}.TableName()) {
+		err := db.Model(&entity.Category{}).Where("Name IN ?", lotCategories).UpdateColumn("TracksLots", true).Error
 		if err != nil {
-			return fmt.Errorf("seeder - Load - db.AutoMigrate: %w", err)
+			return fmt.Errorf("seeder - Load - Model(Category).UpdateColumn: %w", err)
 		}
+	}
 
+	if len(tables) == 0 {
 		for i := range categories {
 			err := db.Model(&entity.Category{}).Create(&categories[i]).Error
 			if err != nil {
@@ -68,3 +188,171 @@ func Execute(db *gorm.DB) error {
 
 	return nil
 }
+
+func containsTable(tables []string, table string) bool {
+	for _, t := range tables {
+		if t == table {
+			return true
+		}
+	}
+	return false
+}
+
+// convertPriceColumn moves a legacy float price column into the minor unit
+// amount and currency columns created by AutoMigrate, then drops it.
+func convertPriceColumn(db *gorm.DB, model interface{}, column string, currency string) error {
+	if !money.Valid(currency) {
+		return fmt.Errorf("unknown currency: %q", currency)
+	}
+
+	migrator := db.Migrator()
+	if !migrator.HasColumn(model, column) {
+		return nil
+	}
+
+	factor := math.Pow10(money.Exponent(currency))
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Session(&gorm.Session{AllowGlobalUpdate: true, SkipHooks: true}).Unscoped().Model(model).
+			UpdateColumns(map[string]interface{}{
+				column + "Amount":   gorm.Expr("ROUND(? * ?)", clause.Column{Name: column}, factor),
+				column + "Currency": currency,
+			}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Migrator().DropColumn(model, column)
+	})
+}
+
+// scaleQuantityColumns converts quantities stored in whole units into
+// thousandths of a unit.
+func scaleQuantityColumns(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, q := range quantityColumns {
+			values := make(map[string]interface{}, len(q.columns))
+			for _, column := range q.columns {
+				values[column] = gorm.Expr("? * ?", clause.Column{Name: column}, int64(measure.Unit))
+			}
+			err := tx.Session(&gorm.Session{AllowGlobalUpdate: true, SkipHooks: true}).Unscoped().Model(q.model).
+				UpdateColumns(values).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// openStockLedger creates the default warehouse when there is none and, when
+// the stock ledger is empty, brings the quantities of existing products into
+// it as an opening adjustment in the default warehouse. The stock held by
+// basket items, which used to be taken off the quantity, is back on hand;
+// baskets reserve it again when they are ordered.
+func openStockLedger(db *gorm.DB) error {
+	var warehouses int64
+	if err := db.Model(&entity.Warehouse{}).Count(&warehouses).Error; err != nil {
+		return err
+	}
+	if warehouses == 0 {
+		warehouse := defaultWarehouse
+		if err := db.Create(&warehouse).Error; err != nil {
+			return err
+		}
+	}
+
+	var movements int64
+	if err := db.Model(&entity.StockMovement{}).Count(&movements).Error; err != nil {
+		return err
+	}
+	if movements > 0 {
+		return nil
+	}
+
+	var warehouse entity.Warehouse
+	if err := db.Where("IsDefault = ?", true).Order("ID").First(&warehouse).Error; err != nil {
+		return err
+	}
+
+	var products []entity.Product
+	if err := db.Unscoped().Select("ID", "Quantity").Find(&products).Error; err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, product := range products {
+			var items []entity.BasketItem
+			if err := tx.Where("ProductID = ?", product.ID).Find(&items).Error; err != nil {
+				return err
+			}
+
+			onHand := product.Quantity
+			for _, item := range items {
+				onHand += item.Quantity
+			}
+			if onHand == 0 {
+				continue
+			}
+
+			opening := entity.StockMovement{ProductID: product.ID, WarehouseID: warehouse.ID,
+				Type: entity.StockMovementAdjustment, Quantity: onHand, Note: "opening balance"}
+			if err := tx.Create(&opening).Error; err != nil {
+				return err
+			}
+
+			level := entity.StockLevel{ProductID: product.ID, WarehouseID: warehouse.ID, Quantity: onHand}
+			if err := tx.Create(&level).Error; err != nil {
+				return err
+			}
+			err := tx.Unscoped().Model(&entity.Product{}).Where("ID = ?", product.ID).
+				UpdateColumn("Quantity", onHand).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// releaseReservationMovements gives back the stock held for baskets by
+// reservation movements, which were used before reservations expired. Baskets
+// reserve it again when they are changed or ordered.
+func releaseReservationMovements(db *gorm.DB) error {
+	var held []struct {
+		ProductID   uint32
+		WarehouseID uint32
+		Reference   string
+		Quantity    measure.Quantity
+	}
+	err := db.Model(&entity.StockMovement{}).
+		Select("ProductID, WarehouseID, Reference, SUM(Quantity) AS Quantity").
+		Where("Type = ?", entity.StockMovementReservation).
+		Group("ProductID, WarehouseID, Reference").
+		Having("SUM(Quantity) < 0").
+		Scan(&held).Error
+	if err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, h := range held {
+			release := entity.StockMovement{ProductID: h.ProductID, WarehouseID: h.WarehouseID,
+				Type: entity.StockMovementReservation, Quantity: -h.Quantity, Reference: h.Reference,
+				Note: "released for expiring reservations"}
+			if err := tx.Create(&release).Error; err != nil {
+				return err
+			}
+
+			err := tx.Model(&entity.StockLevel{}).Where("ProductID = ? AND WarehouseID = ?", h.ProductID, h.WarehouseID).
+				UpdateColumn("Quantity", gorm.Expr("Quantity + ?", -h.Quantity)).Error
+			if err != nil {
+				return err
+			}
+			err = tx.Unscoped().Model(&entity.Product{}).Where("ID = ?", h.ProductID).
+				UpdateColumn("Quantity", gorm.Expr("Quantity + ?", -h.Quantity)).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
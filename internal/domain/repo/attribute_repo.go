@@ -0,0 +1,76 @@
+package repo
+
+import (
+	"errors"
+
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"gorm.io/gorm"
+)
+
+type AttributeRepository struct {
+	db *gorm.DB
+}
+
+func NewAttributeRepository(db *gorm.DB) *AttributeRepository {
+	return &AttributeRepository{
+		db: db,
+	}
+}
+
+func (r *AttributeRepository) GetAllByCategoryId(categoryId uint32) []entity.Attribute {
+	var attributes []entity.Attribute
+	r.db.Where(&entity.Attribute{CategoryID: categoryId}).Order("Code").Find(&attributes)
+
+	return attributes
+}
+
+func (r *AttributeRepository) GetById(id uint32) *entity.Attribute {
+	var attribute entity.Attribute
+	result := r.db.First(&attribute, id)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil
+	}
+
+	return &attribute
+}
+
+func (r *AttributeRepository) GetByCode(categoryId uint32, code string) *entity.Attribute {
+	var attribute entity.Attribute
+	result := r.db.Where(&entity.Attribute{CategoryID: categoryId, Code: code}).First(&attribute)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil
+	}
+
+	return &attribute
+}
+
+func (r *AttributeRepository) Create(c *entity.Attribute) error {
+	result := r.db.Create(&c)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+func (r *AttributeRepository) Update(c *entity.Attribute) error {
+	result := r.db.Save(&c)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+func (r *AttributeRepository) DeleteById(id uint32) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("AttributeID = ?", id).Delete(&entity.ProductAttribute{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&entity.Attribute{}, id).Error
+	})
+}
@@ -19,7 +19,11 @@ func NewBasketRepository(db *gorm.DB) *BasketRepository {
 
 func (r *BasketRepository) Get(userName string) *entity.Basket {
 	var basket entity.Basket
-	result := r.db.Preload("Items").Preload("Items.Product").Preload("Items.Product.Category").FirstOrCreate(&basket, entity.Basket{UserName: userName})
+	result := r.db.Preload("Items").Preload("Items.Product").Preload("Items.Product.Category").
+		Preload("Items.Product.SalePrice", activeSalePrice).
+		Preload("Items.Product.Components.Product").
+		Preload("Items.Product.File").
+		FirstOrCreate(&basket, entity.Basket{UserName: userName})
 
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 		return nil
@@ -97,3 +101,20 @@ func (r *BasketRepository) DeleteItemsByBasketId(basketId string) error {
 
 	return nil
 }
+
+func (r *BasketRepository) GetItemsByProductId(productId uint32) []entity.BasketItem {
+	var items []entity.BasketItem
+	r.db.Where("ProductID = ?", productId).Find(&items)
+
+	return items
+}
+
+func (r *BasketRepository) DeleteItemsByProductId(productId uint32) error {
+	result := r.db.Where("ProductID = ?", productId).Delete(&entity.BasketItem{})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
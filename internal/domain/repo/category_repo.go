@@ -2,6 +2,7 @@ package repo
 
 import (
 	"errors"
+	"time"
 
 	"github.com/bestetufan/beste-store/internal/domain/entity"
 	"gorm.io/gorm"
@@ -17,6 +18,13 @@ func NewCategoryRepository(db *gorm.DB) *CategoryRepository {
 	}
 }
 
+// Transaction runs fn with a repository bound to a single database transaction.
+func (r *CategoryRepository) Transaction(fn func(r *CategoryRepository) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&CategoryRepository{db: tx})
+	})
+}
+
 func (r *CategoryRepository) GetAll(pageIndex, pageSize int) ([]entity.Category, int) {
 	var categories []entity.Category
 	var count int64
@@ -35,6 +43,20 @@ func (r *CategoryRepository) GetAllActives(pageIndex, pageSize int) ([]entity.Ca
 	return categories, int(count)
 }
 
+// FindInBatches calls fn with consecutive batches of categories ordered by id.
+func (r *CategoryRepository) FindInBatches(onlyActives bool, batchSize int, fn func([]entity.Category) error) error {
+	var categories []entity.Category
+	query := r.db.Model(&entity.Category{})
+	if onlyActives {
+		query = query.Where(&entity.Category{IsActive: true})
+	}
+	result := query.FindInBatches(&categories, batchSize, func(tx *gorm.DB, batch int) error {
+		return fn(categories)
+	})
+
+	return result.Error
+}
+
 func (r *CategoryRepository) GetById(id uint32) *entity.Category {
 	var product entity.Category
 	result := r.db.First(&product, id)
@@ -86,3 +108,71 @@ func (r *CategoryRepository) DeleteById(id uint32) error {
 
 	return nil
 }
+
+// GetAllDeleted returns the soft deleted categories, most recently deleted first.
+func (r *CategoryRepository) GetAllDeleted(pageIndex, pageSize int) ([]entity.Category, int) {
+	var categories []entity.Category
+	var count int64
+
+	deleted := r.db.Unscoped().Model(&entity.Category{}).Where("DeletedAt IS NOT NULL")
+	deleted.Count(&count)
+	deleted.Order("DeletedAt DESC").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&categories)
+
+	return categories, int(count)
+}
+
+func (r *CategoryRepository) GetDeletedById(id uint32) *entity.Category {
+	var category entity.Category
+	result := r.db.Unscoped().Where("DeletedAt IS NOT NULL").First(&category, id)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil
+	}
+
+	return &category
+}
+
+func (r *CategoryRepository) Restore(id uint32) error {
+	result := r.db.Unscoped().Model(&entity.Category{}).Where("ID = ?", id).Update("DeletedAt", nil)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+// Purge permanently deletes the categories deleted before the given time,
+// with their attributes and translations. Categories still referred to by a
+// product, deleted or not, are kept. It returns the number of purged
+// categories.
+func (r *CategoryRepository) Purge(deletedBefore time.Time) (int, error) {
+	var count int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		purgeable := tx.Unscoped().Model(&entity.Category{}).Select("ID").
+			Where("DeletedAt < ?", deletedBefore).
+			Where("NOT EXISTS (?)", tx.Unscoped().Model(&entity.Product{}).Select("1").Where("product.CategoryID = category.ID"))
+
+		var ids []uint32
+		if err := purgeable.Pluck("ID", &ids).Error; err != nil || len(ids) == 0 {
+			return err
+		}
+
+		attributes := tx.Model(&entity.Attribute{}).Select("ID").Where("CategoryID IN ?", ids)
+		if err := tx.Where("AttributeID IN (?)", attributes).Delete(&entity.ProductAttribute{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("CategoryID IN ?", ids).Delete(&entity.Attribute{}).Error; err != nil {
+			return err
+		}
+		err := tx.Where("EntityType = ? AND EntityID IN ?", entity.TranslationCategory, ids).Delete(&entity.Translation{}).Error
+		if err != nil {
+			return err
+		}
+		result := tx.Unscoped().Delete(&entity.Category{}, ids)
+		count = result.RowsAffected
+		return result.Error
+	})
+
+	return int(count), err
+}
@@ -0,0 +1,73 @@
+package repo
+
+import (
+	"errors"
+
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"gorm.io/gorm"
+)
+
+type DownloadRepository struct {
+	db *gorm.DB
+}
+
+func NewDownloadRepository(db *gorm.DB) *DownloadRepository {
+	return &DownloadRepository{
+		db: db,
+	}
+}
+
+func (r *DownloadRepository) GetById(id string) *entity.Download {
+	var download entity.Download
+	result := r.db.Where("ID = ?", id).First(&download)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil
+	}
+
+	return &download
+}
+
+func (r *DownloadRepository) GetByOrderId(orderId string) []entity.Download {
+	var downloads []entity.Download
+	r.db.Where("OrderID = ?", orderId).Order("ProductID").Find(&downloads)
+
+	return downloads
+}
+
+func (r *DownloadRepository) Create(downloads []*entity.Download) error {
+	if len(downloads) == 0 {
+		return nil
+	}
+	result := r.db.Create(&downloads)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+// Count counts a download unless its limit is reached, which it reports by
+// returning false. Concurrent downloads can not exceed the limit.
+func (r *DownloadRepository) Count(id string) (bool, error) {
+	result := r.db.Model(&entity.Download{}).
+		Where("ID = ? AND Count < MaxCount", id).
+		UpdateColumn("Count", gorm.Expr("Count + 1"))
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func (r *DownloadRepository) DeleteByOrderId(orderId string) error {
+	result := r.db.Where("OrderID = ?", orderId).Delete(&entity.Download{})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
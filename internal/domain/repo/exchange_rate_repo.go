@@ -0,0 +1,64 @@
+package repo
+
+import (
+	"errors"
+
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"gorm.io/gorm"
+)
+
+type ExchangeRateRepository struct {
+	db *gorm.DB
+}
+
+func NewExchangeRateRepository(db *gorm.DB) *ExchangeRateRepository {
+	return &ExchangeRateRepository{
+		db: db,
+	}
+}
+
+// Transaction runs fn with a repository bound to a single database transaction.
+func (r *ExchangeRateRepository) Transaction(fn func(r *ExchangeRateRepository) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&ExchangeRateRepository{db: tx})
+	})
+}
+
+func (r *ExchangeRateRepository) GetAll() []entity.ExchangeRate {
+	var rates []entity.ExchangeRate
+	r.db.Order("Currency").Find(&rates)
+
+	return rates
+}
+
+func (r *ExchangeRateRepository) GetByCurrency(currency string) *entity.ExchangeRate {
+	var rate entity.ExchangeRate
+	result := r.db.Where("Currency = ?", currency).First(&rate)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil
+	}
+
+	return &rate
+}
+
+// Save creates the rate of a currency or replaces the existing one.
+func (r *ExchangeRateRepository) Save(rate *entity.ExchangeRate) error {
+	existing := r.GetByCurrency(rate.Currency)
+	if existing == nil {
+		return r.db.Create(rate).Error
+	}
+
+	rate.ID, rate.CreatedAt = existing.ID, existing.CreatedAt
+	return r.db.Model(rate).Select("Rate", "UserName", "UpdatedAt").Updates(rate).Error
+}
+
+func (r *ExchangeRateRepository) DeleteByCurrency(currency string) error {
+	result := r.db.Where("Currency = ?", currency).Delete(&entity.ExchangeRate{})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
@@ -1,6 +1,8 @@
 package repo
 
 import (
+	"errors"
+
 	"github.com/bestetufan/beste-store/internal/domain/entity"
 	"gorm.io/gorm"
 )
@@ -15,14 +17,54 @@ func NewOrderRepository(db *gorm.DB) *OrderRepository {
 	}
 }
 
+// OrderTx holds the repositories an order is placed with, bound to a single
+// database transaction.
+type OrderTx struct {
+	Orders    *OrderRepository
+	Baskets   *BasketRepository
+	Stock     *StockRepository
+	Downloads *DownloadRepository
+}
+
+// Transaction runs fn with the repositories an order is placed with bound to a
+// single database transaction.
+func (r *OrderRepository) Transaction(fn func(tx OrderTx) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(OrderTx{
+			Orders:    &OrderRepository{db: tx},
+			Baskets:   &BasketRepository{db: tx},
+			Stock:     &StockRepository{db: tx},
+			Downloads: &DownloadRepository{db: tx},
+		})
+	})
+}
+
 func (r *OrderRepository) Get(userName string, id string) *entity.Order {
 	var order entity.Order
 	r.db.Where(&entity.Order{ID: id, UserName: userName}).
 		Preload("Items").
-		Preload("Items.Product").
-		Preload("Items.Product.Category").
+		Preload("Items.Product", unscoped).
+		Preload("Items.Product.Category", unscoped).
+		Preload("Items.Components.Product", unscoped).
+		First(&order)
+
+	return &order
+}
+
+// GetById returns an order of any user.
+func (r *OrderRepository) GetById(id string) *entity.Order {
+	var order entity.Order
+	result := r.db.Where("ID = ?", id).
+		Preload("Items").
+		Preload("Items.Product", unscoped).
+		Preload("Items.Product.Category", unscoped).
+		Preload("Items.Components.Product", unscoped).
 		First(&order)
 
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil
+	}
+
 	return &order
 }
 
@@ -30,13 +72,40 @@ func (r *OrderRepository) GetAll(userName string) []entity.Order {
 	var orders []entity.Order
 	r.db.Where(&entity.Order{UserName: userName}).
 		Preload("Items").
-		Preload("Items.Product").
-		Preload("Items.Product.Category").
+		Preload("Items.Product", unscoped).
+		Preload("Items.Product.Category", unscoped).
+		Preload("Items.Components.Product", unscoped).
 		Find(&orders)
 
 	return orders
 }
 
+// FindInBatches calls fn with consecutive batches of orders ordered by id.
+// Empty userName or status match every order.
+func (r *OrderRepository) FindInBatches(userName string, status string, batchSize int, fn func([]entity.Order) error) error {
+	var orders []entity.Order
+	result := r.db.Where(&entity.Order{UserName: userName, Status: status}).
+		Preload("Items").
+		FindInBatches(&orders, batchSize, func(tx *gorm.DB, batch int) error {
+			return fn(orders)
+		})
+
+	return result.Error
+}
+
+// HasReceived reports whether a delivered order of the user contains the
+// product, ordered by itself or in a bundle.
+func (r *OrderRepository) HasReceived(userName string, productId uint32) bool {
+	var count int64
+	items := r.db.Model(&entity.OrderItem{}).Select("OrderID").Where("ProductID = ?", productId)
+	components := r.db.Model(&entity.OrderItemComponent{}).Select("OrderID").Where("ProductID = ?", productId)
+	r.db.Model(&entity.Order{}).
+		Where("UserName = ? AND Status = ? AND (ID IN (?) OR ID IN (?))", userName, entity.OrderStatusDelivered, items, components).
+		Count(&count)
+
+	return count > 0
+}
+
 func (r *OrderRepository) Create(c *entity.Order) error {
 	result := r.db.Create(&c)
 
@@ -56,3 +125,9 @@ func (r *OrderRepository) Update(c *entity.Order) error {
 
 	return nil
 }
+
+// unscoped includes soft deleted records in a preload, e.g. products of
+// past orders.
+func unscoped(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
@@ -0,0 +1,71 @@
+package repo
+
+import (
+	"errors"
+
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"gorm.io/gorm"
+)
+
+type ProductImageRepository struct {
+	db *gorm.DB
+}
+
+func NewProductImageRepository(db *gorm.DB) *ProductImageRepository {
+	return &ProductImageRepository{
+		db: db,
+	}
+}
+
+func (r *ProductImageRepository) GetAllByProductId(productId uint32) []*entity.ProductImage {
+	var images []*entity.ProductImage
+	r.db.Where("ProductID = ?", productId).Order("Position").Find(&images)
+
+	return images
+}
+
+func (r *ProductImageRepository) GetById(id uint32) *entity.ProductImage {
+	var image entity.ProductImage
+	result := r.db.First(&image, id)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil
+	}
+
+	return &image
+}
+
+func (r *ProductImageRepository) Create(c *entity.ProductImage) error {
+	result := r.db.Create(&c)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+func (r *ProductImageRepository) DeleteById(id uint32) error {
+	result := r.db.Delete(&entity.ProductImage{}, id)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+// SaveGallery stores position and primary flag of all images of a product
+// at once.
+func (r *ProductImageRepository) SaveGallery(images []*entity.ProductImage) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, image := range images {
+			err := tx.Model(image).Select("Position", "IsPrimary").
+				Updates(entity.ProductImage{Position: image.Position, IsPrimary: image.IsPrimary}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -2,6 +2,7 @@ package repo
 
 import (
 	"errors"
+	"time"
 
 	"github.com/bestetufan/beste-store/internal/domain/entity"
 	"gorm.io/gorm"
@@ -17,15 +18,79 @@ func NewProductRepository(db *gorm.DB) *ProductRepository {
 	}
 }
 
-func (r *ProductRepository) GetAll(pageIndex, pageSize int) ([]entity.Product, int) {
+// Transaction runs fn with a repository bound to a single database transaction.
+func (r *ProductRepository) Transaction(fn func(r *ProductRepository) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&ProductRepository{db: tx})
+	})
+}
+
+// Stock returns a stock repository bound to the database session of r, so
+// that stock moved with it is part of the transaction r runs in, if any.
+func (r *ProductRepository) Stock() *StockRepository {
+	return &StockRepository{db: r.db}
+}
+
+func (r *ProductRepository) GetAll(pageIndex, pageSize int, filter entity.ProductFilter) ([]entity.Product, int) {
 	var products []entity.Product
 	var count int64
 
-	r.db.Preload("Category").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&products).Count(&count)
+	r.filtered(filter).Count(&count)
+	query := withDetails(r.filtered(filter))
+	if filter.Sort == entity.ProductSortRating {
+		query = query.Order("product.Rating DESC, product.RatingCount DESC, product.ID")
+	}
+	query.
+		Offset((pageIndex - 1) * pageSize).
+		Limit(pageSize).
+		Find(&products)
 
 	return products, int(count)
 }
 
+// FindInBatches calls fn with consecutive batches of the products matching
+// the filter, ordered by id, without loading the whole result at once.
+func (r *ProductRepository) FindInBatches(filter entity.ProductFilter, batchSize int, fn func([]entity.Product) error) error {
+	var products []entity.Product
+	result := withDetails(r.filtered(filter)).FindInBatches(&products, batchSize, func(tx *gorm.DB, batch int) error {
+		return fn(products)
+	})
+
+	return result.Error
+}
+
+// GetFacets returns value counts of filterable attributes among the products
+// matching the filter.
+func (r *ProductRepository) GetFacets(filter entity.ProductFilter) []entity.Facet {
+	var rows []struct {
+		Code  string
+		Name  string
+		Type  string
+		Unit  string
+		Value string
+		Count int
+	}
+
+	r.db.Table("product_attribute pa").
+		Select("a.Code AS Code, a.Name AS Name, a.Type AS Type, a.Unit AS Unit, pa.Value AS Value, COUNT(DISTINCT pa.ProductID) AS Count").
+		Joins("JOIN attribute a ON a.ID = pa.AttributeID").
+		Where("a.IsFilterable = ? AND pa.ProductID IN (?)", true, r.filtered(filter).Select("product.ID")).
+		Group("a.Code, a.Name, a.Type, a.Unit, pa.Value").
+		Order("a.Code, Count DESC, pa.Value").
+		Scan(&rows)
+
+	facets := []entity.Facet{}
+	for _, row := range rows {
+		if len(facets) == 0 || facets[len(facets)-1].Code != row.Code {
+			facets = append(facets, entity.Facet{Code: row.Code, Name: row.Name, Type: row.Type, Unit: row.Unit})
+		}
+		last := &facets[len(facets)-1]
+		last.Values = append(last.Values, entity.FacetValue{Value: row.Value, Count: row.Count})
+	}
+
+	return facets
+}
+
 func (r *ProductRepository) GetAllByCategoryId(categoryId uint32) ([]entity.Product, int) {
 	var products []entity.Product
 	var count int64
@@ -36,7 +101,7 @@ func (r *ProductRepository) GetAllByCategoryId(categoryId uint32) ([]entity.Prod
 
 func (r *ProductRepository) GetById(id uint32) *entity.Product {
 	var product entity.Product
-	result := r.db.Preload("Category").First(&product, id)
+	result := withDetails(r.db).First(&product, id)
 
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 		return nil
@@ -45,9 +110,33 @@ func (r *ProductRepository) GetById(id uint32) *entity.Product {
 	return &product
 }
 
+// GetByIds returns the products with the given ids, in no particular order.
+func (r *ProductRepository) GetByIds(ids []uint32) []entity.Product {
+	var products []entity.Product
+	if len(ids) == 0 {
+		return products
+	}
+	withDetails(r.db).Where("ID IN ?", ids).Find(&products)
+
+	return products
+}
+
 func (r *ProductRepository) GetBySKU(sku string) *entity.Product {
 	var product entity.Product
-	result := r.db.Preload("Category").Where(&entity.Product{Sku: sku}).First(&product)
+	result := withDetails(r.db).Where(&entity.Product{Sku: sku}).First(&product)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil
+	}
+
+	return &product
+}
+
+// GetByGTIN returns the product with the given barcode, which must be
+// normalized with barcode.ParseGTIN.
+func (r *ProductRepository) GetByGTIN(gtin string) *entity.Product {
+	var product entity.Product
+	result := withDetails(r.db).Where("GTIN = ?", gtin).First(&product)
 
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 		return nil
@@ -56,15 +145,77 @@ func (r *ProductRepository) GetBySKU(sku string) *entity.Product {
 	return &product
 }
 
-func (r *ProductRepository) Search(query string) []entity.Product {
+// Search returns the products whose name or sku contain query, limited to
+// the given statuses unless statuses is empty. When a locale is given, the
+// names translated in it are searched too.
+func (r *ProductRepository) Search(query string, statuses []string, locale string) []entity.Product {
 	var products []entity.Product
-	r.db.Preload("Category").Where("name LIKE ? OR sku LIKE ?", "%"+query+"%", "%"+query+"%").Find(&products)
+	pattern := "%" + query + "%"
+	match := r.db.Where("name LIKE ? OR sku LIKE ?", pattern, pattern)
+	if len(locale) > 0 {
+		match = match.Or("EXISTS (?)", r.db.Model(&entity.Translation{}).Select("1").
+			Where("EntityType = ? AND Locale = ? AND Field = ? AND Value LIKE ?",
+				entity.TranslationProduct, locale, entity.TranslationFieldName, pattern).
+			Where("translation.EntityID = product.ID"))
+	}
+	db := withDetails(r.db).Where(match)
+	if len(statuses) > 0 {
+		db = db.Where("Status IN ?", statuses)
+	}
+	db.Find(&products)
+
+	return products
+}
+
+// GetDueScheduled returns the scheduled products whose publishing time is
+// not after now.
+func (r *ProductRepository) GetDueScheduled(now time.Time) []entity.Product {
+	var products []entity.Product
+	withDetails(r.db).Where("Status = ? AND PublishAt <= ?", entity.ProductStatusScheduled, now).
+		Order("PublishAt").Find(&products)
 
 	return products
 }
 
+// GetLowStock returns the products that are not archived and whose stock
+// on hand is at or below their reorder point or out, emptiest first.
+func (r *ProductRepository) GetLowStock(pageIndex, pageSize int) ([]entity.Product, int) {
+	var products []entity.Product
+	var count int64
+
+	r.lowStock().Count(&count)
+	r.lowStock().Preload("Category").
+		Order("Quantity, ID").
+		Offset((pageIndex - 1) * pageSize).
+		Limit(pageSize).
+		Find(&products)
+
+	return products, int(count)
+}
+
+// GetLowStockIds returns the ids of the products GetLowStock returns.
+func (r *ProductRepository) GetLowStockIds() []uint32 {
+	var ids []uint32
+	r.lowStock().Order("ID").Pluck("ID", &ids)
+
+	return ids
+}
+
+// lowStock selects the products of GetLowStock. Reorder points are never
+// negative, so products out of stock are at or below theirs too. Bundles and
+// digital products have no stock of their own and are left out.
+func (r *ProductRepository) lowStock() *gorm.DB {
+	return r.db.Model(&entity.Product{}).
+		Where("Status <> ? AND BundlePricing = ? AND IsDigital = ? AND Quantity <= ReorderPoint",
+			entity.ProductStatusArchived, "", false)
+}
+
+// Create inserts a product with no stock. Quantity and Reserved are kept in
+// step with the stock ledger and reservations by StockRepository, Rating and
+// RatingCount with the approved reviews by ReviewRepository; they are never
+// written here.
 func (r *ProductRepository) Create(c *entity.Product) error {
-	result := r.db.Create(&c)
+	result := r.db.Omit("Quantity", "Reserved", "Rating", "RatingCount", "Attributes", "Images", "SalePrice", "Components", "File").Create(&c)
 
 	if result.Error != nil {
 		return result.Error
@@ -73,8 +224,9 @@ func (r *ProductRepository) Create(c *entity.Product) error {
 	return nil
 }
 
+// Update saves a product except its stock and rating, see Create.
 func (r *ProductRepository) Update(c *entity.Product) error {
-	result := r.db.Save(&c)
+	result := r.db.Omit("Quantity", "Reserved", "Rating", "RatingCount", "Attributes", "Images", "SalePrice", "Components", "File").Save(&c)
 
 	if result.Error != nil {
 		return result.Error
@@ -83,6 +235,72 @@ func (r *ProductRepository) Update(c *entity.Product) error {
 	return nil
 }
 
+// SetAttributes replaces all attribute values of a product.
+func (r *ProductRepository) SetAttributes(productId uint32, attributes []*entity.ProductAttribute) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("ProductID = ?", productId).Delete(&entity.ProductAttribute{}).Error; err != nil {
+			return err
+		}
+		if len(attributes) == 0 {
+			return nil
+		}
+		for _, a := range attributes {
+			a.ProductID = productId
+		}
+		return tx.Omit("Attribute").Create(&attributes).Error
+	})
+}
+
+// SetComponents replaces the components of a bundle.
+func (r *ProductRepository) SetComponents(bundleId uint32, components []*entity.BundleComponent) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("BundleID = ?", bundleId).Delete(&entity.BundleComponent{}).Error; err != nil {
+			return err
+		}
+		if len(components) == 0 {
+			return nil
+		}
+		for _, c := range components {
+			c.BundleID = bundleId
+		}
+		return tx.Omit("Product").Create(&components).Error
+	})
+}
+
+// GetFile returns the file of a digital product, also of one in the trash.
+func (r *ProductRepository) GetFile(productId uint32) *entity.ProductFile {
+	var file entity.ProductFile
+	result := r.db.First(&file, "ProductID = ?", productId)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil
+	}
+
+	return &file
+}
+
+// SetFile saves the file of a digital product, replacing the one it had.
+func (r *ProductRepository) SetFile(file *entity.ProductFile) error {
+	result := r.db.Save(file)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+// GetBundles returns the bundles the product is a component of.
+func (r *ProductRepository) GetBundles(productId uint32) []entity.Product {
+	var products []entity.Product
+	withDetails(r.db).
+		Where("ID IN (?)", r.db.Model(&entity.BundleComponent{}).Select("BundleID").Where("ProductID = ?", productId)).
+		Order("ID").
+		Find(&products)
+
+	return products
+}
+
 func (r *ProductRepository) DeleteById(id uint32) error {
 	result := r.db.Delete(&entity.Product{}, id)
 
@@ -92,3 +310,230 @@ func (r *ProductRepository) DeleteById(id uint32) error {
 
 	return nil
 }
+
+func (r *ProductRepository) AddRevision(c *entity.ProductRevision) error {
+	result := r.db.Create(&c)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+// GetRevisions returns the revisions of a product, newest first.
+func (r *ProductRepository) GetRevisions(productId uint32, pageIndex, pageSize int) ([]entity.ProductRevision, int) {
+	var revisions []entity.ProductRevision
+	var count int64
+
+	query := r.db.Model(&entity.ProductRevision{}).Where("ProductID = ?", productId)
+	query.Count(&count)
+	query.Order("ID DESC").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&revisions)
+
+	return revisions, int(count)
+}
+
+func (r *ProductRepository) AddPrice(c *entity.ProductPrice) error {
+	result := r.db.Create(&c)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+// GetPrices returns the prices of a product set since the given time in
+// chronological order, preceded by the price that was in effect at that time.
+func (r *ProductRepository) GetPrices(productId uint32, since time.Time) []entity.ProductPrice {
+	var prices []entity.ProductPrice
+	var previous entity.ProductPrice
+
+	result := r.db.Where("ProductID = ? AND CreatedAt < ?", productId, since).Order("CreatedAt DESC, ID DESC").First(&previous)
+	if result.Error == nil {
+		prices = append(prices, previous)
+	}
+
+	var changes []entity.ProductPrice
+	r.db.Where("ProductID = ? AND CreatedAt >= ?", productId, since).Order("CreatedAt, ID").Find(&changes)
+
+	return append(prices, changes...)
+}
+
+// GetAllDeleted returns the soft deleted products, most recently deleted first.
+func (r *ProductRepository) GetAllDeleted(pageIndex, pageSize int) ([]entity.Product, int) {
+	var products []entity.Product
+	var count int64
+
+	deleted := r.db.Unscoped().Model(&entity.Product{}).Where("DeletedAt IS NOT NULL")
+	deleted.Count(&count)
+	deleted.Preload("Category", unscoped).
+		Order("DeletedAt DESC").
+		Offset((pageIndex - 1) * pageSize).
+		Limit(pageSize).
+		Find(&products)
+
+	return products, int(count)
+}
+
+func (r *ProductRepository) GetDeletedById(id uint32) *entity.Product {
+	var product entity.Product
+	result := r.db.Unscoped().Where("DeletedAt IS NOT NULL").First(&product, id)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil
+	}
+
+	return &product
+}
+
+func (r *ProductRepository) GetDeletedBySKU(sku string) *entity.Product {
+	var product entity.Product
+	result := r.db.Unscoped().Where("Sku = ? AND DeletedAt IS NOT NULL", sku).First(&product)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil
+	}
+
+	return &product
+}
+
+func (r *ProductRepository) GetDeletedByGTIN(gtin string) *entity.Product {
+	var product entity.Product
+	result := r.db.Unscoped().Where("GTIN = ? AND DeletedAt IS NOT NULL", gtin).First(&product)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil
+	}
+
+	return &product
+}
+
+func (r *ProductRepository) Restore(id uint32) error {
+	result := r.db.Unscoped().Model(&entity.Product{}).Where("ID = ?", id).Update("DeletedAt", nil)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+// GetPurgeable returns the products deleted before the given time that no
+// order refers to, also as a bundle component. Ordered products are kept for
+// the order history.
+func (r *ProductRepository) GetPurgeable(deletedBefore time.Time) []entity.Product {
+	var products []entity.Product
+	r.db.Unscoped().
+		Where("DeletedAt < ?", deletedBefore).
+		Where("NOT EXISTS (?)", r.db.Table("order_item").Select("1").Where("order_item.ProductID = product.ID")).
+		Where("NOT EXISTS (?)", r.db.Table("order_item_component").Select("1").
+			Where("order_item_component.ProductID = product.ID")).
+		Find(&products)
+
+	return products
+}
+
+// Purge permanently deletes a product together with its attribute values,
+// sale prices, basket items, bundle components, stock levels, reservations,
+// stock alert, file, reviews, wishlist items, back in stock subscriptions and
+// translations.
+// Its stock movements are kept like its history. Images and the file must be
+// removed from the storage beforehand.
+func (r *ProductRepository) Purge(id uint32) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("ProductID = ?", id).Delete(&entity.ProductAttribute{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("ProductID = ?", id).Delete(&entity.ProductImage{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("ProductID = ?", id).Delete(&entity.BasketItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("ProductID = ?", id).Delete(&entity.SalePrice{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("BundleID = ? OR ProductID = ?", id, id).Delete(&entity.BundleComponent{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("ProductID = ?", id).Delete(&entity.StockReservation{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("ProductID = ?", id).Delete(&entity.StockLevel{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("ProductID = ?", id).Delete(&entity.StockLot{}).Error; err != nil {
+			return err
+		}
+		// Sold units stay for looking up their orders.
+		err := tx.Where("ProductID = ? AND Status <> ?", id, entity.SerialNumberSold).Delete(&entity.SerialNumber{}).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("ProductID = ?", id).Delete(&entity.StockAlert{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("ProductID = ?", id).Delete(&entity.ProductFile{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("ProductID = ?", id).Delete(&entity.Review{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("ProductID = ?", id).Delete(&entity.WishlistItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("ProductID = ?", id).Delete(&entity.StockSubscription{}).Error; err != nil {
+			return err
+		}
+		err = tx.Where("EntityType = ? AND EntityID = ?", entity.TranslationProduct, id).Delete(&entity.Translation{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&entity.Product{}, id).Error
+	})
+}
+
+// withDetails preloads the associations shown on product responses.
+func withDetails(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Category").
+		Preload("Attributes.Attribute").
+		Preload("Images", func(db *gorm.DB) *gorm.DB {
+			return db.Order("Position")
+		}).
+		Preload("SalePrice", activeSalePrice).
+		Preload("Components.Product").
+		Preload("File")
+}
+
+func (r *ProductRepository) filtered(filter entity.ProductFilter) *gorm.DB {
+	query := r.db.Model(&entity.Product{})
+
+	if filter.CategoryID != 0 {
+		query = query.Where("product.CategoryID = ?", filter.CategoryID)
+	}
+
+	if len(filter.Statuses) > 0 {
+		query = query.Where("product.Status IN ?", filter.Statuses)
+	}
+
+	for _, f := range filter.Attributes {
+		exists := r.db.Table("product_attribute pa").
+			Select("1").
+			Joins("JOIN attribute a ON a.ID = pa.AttributeID").
+			Where("pa.ProductID = product.ID AND a.Code = ?", f.Code)
+		if len(f.Values) > 0 {
+			exists = exists.Where("pa.Value IN ?", f.Values)
+		}
+		if f.Min != nil {
+			exists = exists.Where("pa.NumberValue >= ?", *f.Min)
+		}
+		if f.Max != nil {
+			exists = exists.Where("pa.NumberValue <= ?", *f.Max)
+		}
+		query = query.Where("EXISTS (?)", exists)
+	}
+
+	return query
+}
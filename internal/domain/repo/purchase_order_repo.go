@@ -0,0 +1,122 @@
+package repo
+
+import (
+	"errors"
+
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"github.com/bestetufan/beste-store/pkg/measure"
+	"gorm.io/gorm"
+)
+
+type PurchaseOrderRepository struct {
+	db *gorm.DB
+}
+
+func NewPurchaseOrderRepository(db *gorm.DB) *PurchaseOrderRepository {
+	return &PurchaseOrderRepository{
+		db: db,
+	}
+}
+
+// GetAll returns the purchase orders matching the filter, newest first,
+// without their items.
+func (r *PurchaseOrderRepository) GetAll(filter entity.PurchaseOrderFilter, pageIndex, pageSize int) ([]entity.PurchaseOrder, int) {
+	var orders []entity.PurchaseOrder
+	var count int64
+
+	query := r.db.Model(&entity.PurchaseOrder{})
+	if filter.SupplierID != 0 {
+		query = query.Where("SupplierID = ?", filter.SupplierID)
+	}
+	if len(filter.Status) > 0 {
+		query = query.Where("Status = ?", filter.Status)
+	}
+
+	query.Count(&count)
+	query.Preload("Supplier").
+		Order("ID DESC").
+		Offset((pageIndex - 1) * pageSize).
+		Limit(pageSize).
+		Find(&orders)
+
+	return orders, int(count)
+}
+
+func (r *PurchaseOrderRepository) GetById(id uint32) *entity.PurchaseOrder {
+	var order entity.PurchaseOrder
+	result := r.db.
+		Preload("Supplier").
+		Preload("Warehouse").
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("ID")
+		}).
+		Preload("Items.Product", unscoped).
+		First(&order, id)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil
+	}
+
+	return &order
+}
+
+// CountBySupplier returns the number of purchase orders to a supplier.
+func (r *PurchaseOrderRepository) CountBySupplier(supplierId uint32) int {
+	var count int64
+	r.db.Model(&entity.PurchaseOrder{}).Where("SupplierID = ?", supplierId).Count(&count)
+
+	return int(count)
+}
+
+// GetOnOrder returns the quantities of products that are in open purchase
+// orders and not received yet, by product id.
+func (r *PurchaseOrderRepository) GetOnOrder(productIds []uint32) map[uint32]measure.Quantity {
+	var rows []struct {
+		ProductID uint32
+		Quantity  measure.Quantity
+	}
+	r.db.Table("purchase_order_item i").
+		Select("i.ProductID AS ProductID, SUM(i.Quantity - i.ReceivedQuantity) AS Quantity").
+		Joins("JOIN purchase_order o ON o.ID = i.PurchaseOrderID").
+		Where("o.Status IN ? AND i.ProductID IN ?", []string{entity.PurchaseOrderStatusDraft,
+			entity.PurchaseOrderStatusOrdered, entity.PurchaseOrderStatusPartiallyReceived}, productIds).
+		Group("i.ProductID").
+		Scan(&rows)
+
+	onOrder := make(map[uint32]measure.Quantity, len(rows))
+	for _, row := range rows {
+		onOrder[row.ProductID] = row.Quantity
+	}
+	return onOrder
+}
+
+// Create inserts a purchase order with its items.
+func (r *PurchaseOrderRepository) Create(c *entity.PurchaseOrder) error {
+	result := r.db.Omit("Supplier", "Warehouse", "Items.Product").Create(c)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+// Update saves a purchase order with its items. Items no longer in the
+// purchase order are deleted.
+func (r *PurchaseOrderRepository) Update(c *entity.PurchaseOrder) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Supplier", "Warehouse", "Items").Save(c).Error; err != nil {
+			return err
+		}
+
+		ids := []uint32{0}
+		for _, item := range c.Items {
+			item.PurchaseOrderID = c.ID
+			if err := tx.Omit("Product").Save(item).Error; err != nil {
+				return err
+			}
+			ids = append(ids, item.ID)
+		}
+		return tx.Where("PurchaseOrderID = ? AND ID NOT IN ?", c.ID, ids).Delete(&entity.PurchaseOrderItem{}).Error
+	})
+}
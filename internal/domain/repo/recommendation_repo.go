@@ -0,0 +1,117 @@
+package repo
+
+import (
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"gorm.io/gorm"
+)
+
+type RecommendationRepository struct {
+	db *gorm.DB
+}
+
+func NewRecommendationRepository(db *gorm.DB) *RecommendationRepository {
+	return &RecommendationRepository{
+		db: db,
+	}
+}
+
+// GetByProduct returns the recommendations of a kind for a product, best
+// first, leaving out products that are not published.
+func (r *RecommendationRepository) GetByProduct(productId uint32, kind string, limit int) []entity.ProductRecommendation {
+	var recommendations []entity.ProductRecommendation
+	r.db.Where("ProductID = ? AND Kind = ? AND RelatedID IN (?)", productId, kind, r.published()).
+		Order("Position").
+		Limit(limit).
+		Find(&recommendations)
+
+	return recommendations
+}
+
+// GetBestsellers returns the ids of the published products of a category
+// ordered in the most orders, leaving out the excluded ones.
+func (r *RecommendationRepository) GetBestsellers(categoryId uint32, exclude []uint32, limit int) []uint32 {
+	var ids []uint32
+	query := r.db.Table("order_item i").
+		Select("i.ProductID").
+		Where("i.OrderID IN (?)", r.orders()).
+		Where("i.ProductID IN (?)", r.published().Where("CategoryID = ?", categoryId))
+	if len(exclude) > 0 {
+		query = query.Where("i.ProductID NOT IN ?", exclude)
+	}
+	query.Group("i.ProductID").
+		Order("COUNT(*) DESC, i.ProductID").
+		Limit(limit).
+		Pluck("i.ProductID", &ids)
+
+	return ids
+}
+
+// GetBoughtTogether returns for each product the limit products that were
+// in the most orders together with it, in at least minCount, with the number
+// of those orders and their position, by product and then by position.
+func (r *RecommendationRepository) GetBoughtTogether(minCount int, limit int) []entity.ProductRecommendation {
+	pairs := r.db.Table("order_item a").
+		Joins("JOIN order_item b ON b.OrderID = a.OrderID AND b.ProductID <> a.ProductID").
+		Where("a.OrderID IN (?)", r.orders())
+
+	return r.rank(pairs, minCount, limit)
+}
+
+// GetAlsoBought returns for each product the limit products that were
+// ordered by the most of the same customers, by at least minCount, with the
+// number of those customers and their position, by product and then by
+// position.
+func (r *RecommendationRepository) GetAlsoBought(minCount int, limit int) []entity.ProductRecommendation {
+	bought := r.db.Table("order_item i").
+		Select("DISTINCT o.UserName AS UserName, i.ProductID AS ProductID").
+		Joins("JOIN (?) o ON o.ID = i.OrderID", r.orders().Select("ID, UserName"))
+
+	pairs := r.db.Table("(?) a", bought).
+		Joins("JOIN (?) b ON b.UserName = a.UserName AND b.ProductID <> a.ProductID", bought)
+
+	return r.rank(pairs, minCount, limit)
+}
+
+// Replace replaces all recommendations at once.
+func (r *RecommendationRepository) Replace(recommendations []*entity.ProductRecommendation) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&entity.ProductRecommendation{}).Error; err != nil {
+			return err
+		}
+		if len(recommendations) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&recommendations, 500).Error
+	})
+}
+
+// rank counts the rows of pairs, joining products a and b, by pair and keeps
+// the limit pairs counted at least minCount times with the highest counts of
+// each product. The ranking is done by the database so that only the pairs
+// kept are loaded.
+func (r *RecommendationRepository) rank(pairs *gorm.DB, minCount int, limit int) []entity.ProductRecommendation {
+	counted := pairs.
+		Select("a.ProductID AS ProductID, b.ProductID AS RelatedID, COUNT(*) AS Count, "+
+			"ROW_NUMBER() OVER (PARTITION BY a.ProductID ORDER BY COUNT(*) DESC, b.ProductID) AS Position").
+		Group("a.ProductID, b.ProductID").
+		Having("COUNT(*) >= ?", minCount)
+
+	var ranked []entity.ProductRecommendation
+	r.db.Table("(?) p", counted).
+		Select("p.ProductID, p.RelatedID, p.Count, p.Position").
+		Where("p.Position <= ?", limit).
+		Order("p.ProductID, p.Position").
+		Scan(&ranked)
+
+	return ranked
+}
+
+// orders selects the ids of the orders that are not canceled.
+func (r *RecommendationRepository) orders() *gorm.DB {
+	return r.db.Model(&entity.Order{}).Select("ID").Where("Status <> ?", entity.OrderStatusCanceled)
+}
+
+// published selects the ids of the published products.
+func (r *RecommendationRepository) published() *gorm.DB {
+	return r.db.Model(&entity.Product{}).Select("ID").Where("Status = ?", entity.ProductStatusPublished)
+}
@@ -0,0 +1,106 @@
+package repo
+
+import (
+	"errors"
+	"math"
+
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"gorm.io/gorm"
+)
+
+type ReviewRepository struct {
+	db *gorm.DB
+}
+
+func NewReviewRepository(db *gorm.DB) *ReviewRepository {
+	return &ReviewRepository{
+		db: db,
+	}
+}
+
+// GetAll returns the reviews matching the filter, newest first.
+func (r *ReviewRepository) GetAll(filter entity.ReviewFilter, pageIndex, pageSize int) ([]entity.Review, int) {
+	var reviews []entity.Review
+	var count int64
+
+	query := r.db.Model(&entity.Review{})
+	if filter.ProductID != 0 {
+		query = query.Where("ProductID = ?", filter.ProductID)
+	}
+	if len(filter.Status) > 0 {
+		query = query.Where("Status = ?", filter.Status)
+	}
+
+	query.Count(&count)
+	query.Order("ID DESC").
+		Offset((pageIndex - 1) * pageSize).
+		Limit(pageSize).
+		Find(&reviews)
+
+	return reviews, int(count)
+}
+
+func (r *ReviewRepository) GetById(id uint32) *entity.Review {
+	var review entity.Review
+	result := r.db.First(&review, id)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil
+	}
+
+	return &review
+}
+
+// GetByUser returns the review of a product by the user.
+func (r *ReviewRepository) GetByUser(productId uint32, userName string) *entity.Review {
+	var review entity.Review
+	result := r.db.Where("ProductID = ? AND UserName = ?", productId, userName).First(&review)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil
+	}
+
+	return &review
+}
+
+func (r *ReviewRepository) Create(c *entity.Review) error {
+	result := r.db.Create(&c)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+// Update saves a review and recomputes the rating of its product from its
+// approved reviews.
+func (r *ReviewRepository) Update(c *entity.Review) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&c).Error; err != nil {
+			return err
+		}
+		return updateRating(tx, c.ProductID)
+	})
+}
+
+// updateRating sets the rating of a product to the average of its approved
+// reviews, rounded to two decimals, and their count.
+func updateRating(tx *gorm.DB, productId uint32) error {
+	var rating struct {
+		Rating float64
+		Count  int
+	}
+	err := tx.Model(&entity.Review{}).
+		Select("COALESCE(AVG(Rating), 0) AS Rating, COUNT(*) AS Count").
+		Where("ProductID = ? AND Status = ?", productId, entity.ReviewStatusApproved).
+		Scan(&rating).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Model(&entity.Product{}).Unscoped().Where("ID = ?", productId).UpdateColumns(map[string]interface{}{
+		"Rating":      math.Round(rating.Rating*100) / 100,
+		"RatingCount": rating.Count,
+	}).Error
+}
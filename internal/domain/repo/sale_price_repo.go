@@ -0,0 +1,74 @@
+package repo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"gorm.io/gorm"
+)
+
+type SalePriceRepository struct {
+	db *gorm.DB
+}
+
+func NewSalePriceRepository(db *gorm.DB) *SalePriceRepository {
+	return &SalePriceRepository{
+		db: db,
+	}
+}
+
+// GetAllByProductId returns the past, current and scheduled sale prices of
+// a product in chronological order.
+func (r *SalePriceRepository) GetAllByProductId(productId uint32) []*entity.SalePrice {
+	var salePrices []*entity.SalePrice
+	r.db.Where("ProductID = ?", productId).Order("StartsAt").Find(&salePrices)
+
+	return salePrices
+}
+
+// GetAllBetween returns the sale prices of a product that apply at some
+// point between from and to.
+func (r *SalePriceRepository) GetAllBetween(productId uint32, from time.Time, to time.Time) []*entity.SalePrice {
+	var salePrices []*entity.SalePrice
+	r.db.Where("ProductID = ? AND StartsAt < ? AND EndsAt > ?", productId, to, from).Order("StartsAt").Find(&salePrices)
+
+	return salePrices
+}
+
+func (r *SalePriceRepository) GetById(id uint32) *entity.SalePrice {
+	var salePrice entity.SalePrice
+	result := r.db.First(&salePrice, id)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil
+	}
+
+	return &salePrice
+}
+
+func (r *SalePriceRepository) Create(c *entity.SalePrice) error {
+	result := r.db.Create(&c)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+func (r *SalePriceRepository) DeleteById(id uint32) error {
+	result := r.db.Delete(&entity.SalePrice{}, id)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+// activeSalePrice limits a SalePrice preload to the sale price in effect now.
+func activeSalePrice(db *gorm.DB) *gorm.DB {
+	now := time.Now()
+	return db.Where("StartsAt <= ? AND EndsAt > ?", now, now)
+}
@@ -0,0 +1,58 @@
+package repo
+
+import (
+	"errors"
+
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"gorm.io/gorm"
+)
+
+type StockAlertRepository struct {
+	db *gorm.DB
+}
+
+func NewStockAlertRepository(db *gorm.DB) *StockAlertRepository {
+	return &StockAlertRepository{
+		db: db,
+	}
+}
+
+func (r *StockAlertRepository) GetByProductId(productId uint32) *entity.StockAlert {
+	var alert entity.StockAlert
+	result := r.db.Where("ProductID = ?", productId).First(&alert)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil
+	}
+
+	return &alert
+}
+
+// GetProductIds returns the ids of the products with a stock alert.
+func (r *StockAlertRepository) GetProductIds() []uint32 {
+	var ids []uint32
+	r.db.Model(&entity.StockAlert{}).Order("ProductID").Pluck("ProductID", &ids)
+
+	return ids
+}
+
+// Save creates the stock alert of a product or replaces the existing one.
+func (r *StockAlertRepository) Save(alert *entity.StockAlert) error {
+	existing := r.GetByProductId(alert.ProductID)
+	if existing == nil {
+		return r.db.Create(alert).Error
+	}
+
+	alert.CreatedAt = existing.CreatedAt
+	return r.db.Model(alert).Select("Level", "Quantity", "UpdatedAt").Updates(alert).Error
+}
+
+func (r *StockAlertRepository) DeleteByProductId(productId uint32) error {
+	result := r.db.Where("ProductID = ?", productId).Delete(&entity.StockAlert{})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
@@ -0,0 +1,417 @@
+package repo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"github.com/bestetufan/beste-store/pkg/measure"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrNegativeStock is returned when a movement or a reservation would take
+// the available stock of a product in a warehouse below zero.
+var ErrNegativeStock = errors.New("not enough stock")
+
+type StockRepository struct {
+	db *gorm.DB
+}
+
+func NewStockRepository(db *gorm.DB) *StockRepository {
+	return &StockRepository{
+		db: db,
+	}
+}
+
+// Transaction runs fn with a repository bound to a single database transaction.
+func (r *StockRepository) Transaction(fn func(r *StockRepository) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&StockRepository{db: tx})
+	})
+}
+
+// AddMovement appends a movement to the ledger and applies it to the stock
+// level of the warehouse, to the quantity of its lot, if any, and to the
+// quantity of the product. It fails with ErrNegativeStock when the stock left
+// would not cover the reservations, or the stock left in the lot or outside
+// of lots would be negative.
+func (r *StockRepository) AddMovement(c *entity.StockMovement) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(c).Error; err != nil {
+			return err
+		}
+
+		level := entity.StockLevel{ProductID: c.ProductID, WarehouseID: c.WarehouseID, Quantity: c.Quantity}
+		err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "ProductID"}, {Name: "WarehouseID"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"Quantity":  gorm.Expr("Quantity + ?", c.Quantity),
+				"UpdatedAt": c.CreatedAt,
+			}),
+		}).Create(&level).Error
+		if err != nil {
+			return err
+		}
+
+		if c.LotID != nil {
+			err := tx.Model(&entity.StockLot{}).Where("ID = ?", *c.LotID).
+				UpdateColumn("Quantity", gorm.Expr("Quantity + ?", c.Quantity)).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		if c.Quantity < 0 {
+			if err := checkAvailable(tx, c.ProductID, c.WarehouseID); err != nil {
+				return err
+			}
+			if err := checkLots(tx, c.ProductID, c.WarehouseID); err != nil {
+				return err
+			}
+		}
+
+		return tx.Unscoped().Model(&entity.Product{}).Where("ID = ?", c.ProductID).
+			UpdateColumn("Quantity", gorm.Expr("Quantity + ?", c.Quantity)).Error
+	})
+}
+
+// AddReservation records a reservation and adds it to the reserved quantity
+// of the warehouse and of the product. It fails with ErrNegativeStock when
+// more than the available stock would be reserved.
+func (r *StockRepository) AddReservation(c *entity.StockReservation) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(c).Error; err != nil {
+			return err
+		}
+		if err := addReserved(tx, c.ProductID, c.WarehouseID, c.Quantity); err != nil {
+			return err
+		}
+		return checkAvailable(tx, c.ProductID, c.WarehouseID)
+	})
+}
+
+// ReleaseReservation gives back quantity units of a reservation, removing it
+// when nothing is left. Units already released, e.g. by a concurrent
+// release, are not released twice.
+func (r *StockRepository) ReleaseReservation(c *entity.StockReservation, quantity measure.Quantity) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var result *gorm.DB
+		if quantity >= c.Quantity {
+			quantity = c.Quantity
+			result = tx.Where("ID = ? AND Quantity = ?", c.ID, c.Quantity).Delete(&entity.StockReservation{})
+		} else {
+			result = tx.Model(&entity.StockReservation{}).Where("ID = ? AND Quantity = ?", c.ID, c.Quantity).
+				UpdateColumn("Quantity", c.Quantity-quantity)
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		c.Quantity -= quantity
+		return addReserved(tx, c.ProductID, c.WarehouseID, -quantity)
+	})
+}
+
+// GetReservations returns the reservations of a product for a basket,
+// newest first.
+func (r *StockRepository) GetReservations(productId uint32, basketId string) []entity.StockReservation {
+	var reservations []entity.StockReservation
+	r.db.Where("ProductID = ? AND BasketID = ?", productId, basketId).Order("ID DESC").Find(&reservations)
+
+	return reservations
+}
+
+// GetReservationsIn returns the reservations of a product in a warehouse,
+// newest first.
+func (r *StockRepository) GetReservationsIn(productId uint32, warehouseId uint32) []entity.StockReservation {
+	var reservations []entity.StockReservation
+	r.db.Where("ProductID = ? AND WarehouseID = ?", productId, warehouseId).Order("ID DESC").Find(&reservations)
+
+	return reservations
+}
+
+// GetExpiredReservations returns the reservations that expired before now.
+func (r *StockRepository) GetExpiredReservations(now time.Time) []entity.StockReservation {
+	var reservations []entity.StockReservation
+	r.db.Where("ExpiresAt < ?", now).Order("ID").Find(&reservations)
+
+	return reservations
+}
+
+// ExtendReservations moves the expiry of all reservations of a basket.
+func (r *StockRepository) ExtendReservations(basketId string, expiresAt time.Time) error {
+	return r.db.Model(&entity.StockReservation{}).Where("BasketID = ?", basketId).
+		UpdateColumn("ExpiresAt", expiresAt).Error
+}
+
+// GetLevels returns the stock levels of a product in every warehouse it has
+// been kept in.
+func (r *StockRepository) GetLevels(productId uint32) []entity.StockLevel {
+	var levels []entity.StockLevel
+	r.db.Preload("Warehouse").Where("ProductID = ?", productId).Order("WarehouseID").Find(&levels)
+
+	return levels
+}
+
+func (r *StockRepository) GetLevel(productId uint32, warehouseId uint32) *entity.StockLevel {
+	var level entity.StockLevel
+	result := r.db.Where("ProductID = ? AND WarehouseID = ?", productId, warehouseId).First(&level)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil
+	}
+
+	return &level
+}
+
+func (r *StockRepository) GetLot(productId uint32, warehouseId uint32, number string) *entity.StockLot {
+	var lot entity.StockLot
+	result := r.db.Where("ProductID = ? AND WarehouseID = ? AND Number = ?", productId, warehouseId, number).First(&lot)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil
+	}
+
+	return &lot
+}
+
+func (r *StockRepository) CreateLot(lot *entity.StockLot) error {
+	return r.db.Omit("Product", "Warehouse").Create(lot).Error
+}
+
+// GetLots returns the lots of a product with stock left, by warehouse and
+// expiry date.
+func (r *StockRepository) GetLots(productId uint32) []entity.StockLot {
+	var lots []entity.StockLot
+	r.db.Preload("Warehouse").Where("ProductID = ? AND Quantity > 0", productId).
+		Order("WarehouseID, ExpiresAt, ID").Find(&lots)
+
+	return lots
+}
+
+// GetLotsIn returns the lots of a product with stock left in a warehouse,
+// first expiring first.
+func (r *StockRepository) GetLotsIn(productId uint32, warehouseId uint32) []entity.StockLot {
+	var lots []entity.StockLot
+	r.db.Where("ProductID = ? AND WarehouseID = ? AND Quantity > 0", productId, warehouseId).
+		Order("ExpiresAt, ID").Find(&lots)
+
+	return lots
+}
+
+// GetExpiredLots returns the lots with stock left that are expired at now.
+func (r *StockRepository) GetExpiredLots(now time.Time) []entity.StockLot {
+	var lots []entity.StockLot
+	r.db.Where("Quantity > 0 AND ExpiresAt <= ?", entity.LotExpiryCutoff(now)).Order("ExpiresAt, ID").Find(&lots)
+
+	return lots
+}
+
+// GetExpiringLots returns the lots with stock left that expire before until,
+// first expiring first.
+func (r *StockRepository) GetExpiringLots(until time.Time, pageIndex, pageSize int) ([]entity.StockLot, int) {
+	var lots []entity.StockLot
+	var count int64
+
+	query := r.db.Model(&entity.StockLot{}).Where("Quantity > 0 AND ExpiresAt < ?", until)
+	query.Count(&count)
+	query.Preload("Product", unscoped).Preload("Warehouse").
+		Order("ExpiresAt, ID").
+		Offset((pageIndex - 1) * pageSize).
+		Limit(pageSize).
+		Find(&lots)
+
+	return lots, int(count)
+}
+
+// GetSerials returns the serial numbers of a product, all when status is
+// empty, newest first.
+func (r *StockRepository) GetSerials(productId uint32, status string, pageIndex, pageSize int) ([]entity.SerialNumber, int) {
+	var serials []entity.SerialNumber
+	var count int64
+
+	query := r.db.Model(&entity.SerialNumber{}).Where(&entity.SerialNumber{ProductID: productId, Status: status})
+	query.Count(&count)
+	query.Order("ID DESC").
+		Offset((pageIndex - 1) * pageSize).
+		Limit(pageSize).
+		Find(&serials)
+
+	return serials, int(count)
+}
+
+func (r *StockRepository) GetSerial(productId uint32, serial string) *entity.SerialNumber {
+	var serialNumber entity.SerialNumber
+	result := r.db.Where("ProductID = ? AND Serial = ?", productId, serial).First(&serialNumber)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil
+	}
+
+	return &serialNumber
+}
+
+// GetSoldSerial returns the unit with the given serial number sold last, of
+// any product.
+func (r *StockRepository) GetSoldSerial(serial string) *entity.SerialNumber {
+	var serialNumber entity.SerialNumber
+	result := r.db.Where("Serial = ? AND Status = ?", serial, entity.SerialNumberSold).
+		Order("SoldAt DESC").First(&serialNumber)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil
+	}
+
+	return &serialNumber
+}
+
+// GetSerialsByOrder returns the serial numbers of the units sold for an
+// order.
+func (r *StockRepository) GetSerialsByOrder(orderId string) []entity.SerialNumber {
+	var serials []entity.SerialNumber
+	r.db.Where("OrderID = ?", orderId).Order("ProductID, Serial").Find(&serials)
+
+	return serials
+}
+
+func (r *StockRepository) CreateSerial(serial *entity.SerialNumber) error {
+	return r.db.Omit("Product").Create(serial).Error
+}
+
+func (r *StockRepository) UpdateSerial(serial *entity.SerialNumber) error {
+	return r.db.Omit("Product").Save(serial).Error
+}
+
+// ReturnSerials puts the units of a product sold for an order back in stock.
+func (r *StockRepository) ReturnSerials(productId uint32, orderId string) error {
+	return r.db.Model(&entity.SerialNumber{}).
+		Where("ProductID = ? AND OrderID = ? AND Status = ?", productId, orderId, entity.SerialNumberSold).
+		Updates(map[string]interface{}{"Status": entity.SerialNumberInStock, "OrderID": nil, "SoldAt": nil}).Error
+}
+
+// GetMovements returns the movements matching the filter, newest first.
+func (r *StockRepository) GetMovements(filter entity.StockMovementFilter, pageIndex, pageSize int) ([]entity.StockMovement, int) {
+	var movements []entity.StockMovement
+	var count int64
+
+	query := r.db.Model(&entity.StockMovement{}).Where(&entity.StockMovement{
+		ProductID: filter.ProductID, WarehouseID: filter.WarehouseID, Type: filter.Type, Reference: filter.Reference})
+	query.Count(&count)
+	query.Order("ID DESC").
+		Offset((pageIndex - 1) * pageSize).
+		Limit(pageSize).
+		Find(&movements)
+
+	return movements, int(count)
+}
+
+// GetNetByReference returns, per warehouse, the sum of the movements of a
+// product with the given reference and one of the given types.
+func (r *StockRepository) GetNetByReference(productId uint32, reference string, types ...string) map[uint32]measure.Quantity {
+	var rows []struct {
+		WarehouseID uint32
+		Quantity    measure.Quantity
+	}
+	r.db.Model(&entity.StockMovement{}).
+		Select("WarehouseID, SUM(Quantity) AS Quantity").
+		Where("ProductID = ? AND Reference = ? AND Type IN ?", productId, reference, types).
+		Group("WarehouseID").
+		Scan(&rows)
+
+	net := make(map[uint32]measure.Quantity, len(rows))
+	for _, row := range rows {
+		if row.Quantity != 0 {
+			net[row.WarehouseID] = row.Quantity
+		}
+	}
+	return net
+}
+
+// StockNet is the sum of movements of a product in a warehouse and lot; LotID
+// is nil for units not in any lot.
+type StockNet struct {
+	WarehouseID uint32
+	LotID       *uint32
+	Quantity    measure.Quantity
+}
+
+// GetNetByLot is GetNetByReference per warehouse and lot.
+func (r *StockRepository) GetNetByLot(productId uint32, reference string, types ...string) []StockNet {
+	var rows []StockNet
+	r.db.Model(&entity.StockMovement{}).
+		Select("WarehouseID, LotID, SUM(Quantity) AS Quantity").
+		Where("ProductID = ? AND Reference = ? AND Type IN ?", productId, reference, types).
+		Group("WarehouseID, LotID").
+		Order("WarehouseID, LotID").
+		Scan(&rows)
+
+	net := rows[:0]
+	for _, row := range rows {
+		if row.Quantity != 0 {
+			net = append(net, row)
+		}
+	}
+	return net
+}
+
+// addReserved adds quantity, negative to release, to the reserved quantity of
+// a product in a warehouse and of the product.
+func addReserved(tx *gorm.DB, productId uint32, warehouseId uint32, quantity measure.Quantity) error {
+	result := tx.Model(&entity.StockLevel{}).Where("ProductID = ? AND WarehouseID = ?", productId, warehouseId).
+		UpdateColumn("Reserved", gorm.Expr("Reserved + ?", quantity))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNegativeStock
+	}
+
+	return tx.Unscoped().Model(&entity.Product{}).Where("ID = ?", productId).
+		UpdateColumn("Reserved", gorm.Expr("Reserved + ?", quantity)).Error
+}
+
+// checkAvailable fails with ErrNegativeStock when the reservations of a
+// product in a warehouse exceed its stock.
+func checkAvailable(tx *gorm.DB, productId uint32, warehouseId uint32) error {
+	var level entity.StockLevel
+	err := tx.Where("ProductID = ? AND WarehouseID = ?", productId, warehouseId).First(&level).Error
+	if err != nil {
+		return err
+	}
+	if level.Quantity < 0 || level.Available() < 0 {
+		return ErrNegativeStock
+	}
+	return nil
+}
+
+// checkLots fails with ErrNegativeStock when a lot of a product in a
+// warehouse is below zero or the lots hold more than its stock.
+func checkLots(tx *gorm.DB, productId uint32, warehouseId uint32) error {
+	var lots struct {
+		Quantity measure.Quantity
+		Lowest   int
+	}
+	err := tx.Model(&entity.StockLot{}).
+		Select("COALESCE(SUM(Quantity), 0) AS Quantity, COALESCE(MIN(Quantity), 0) AS Lowest").
+		Where("ProductID = ? AND WarehouseID = ?", productId, warehouseId).
+		Scan(&lots).Error
+	if err != nil {
+		return err
+	}
+	if lots.Lowest < 0 {
+		return ErrNegativeStock
+	}
+
+	var level entity.StockLevel
+	if err := tx.Where("ProductID = ? AND WarehouseID = ?", productId, warehouseId).First(&level).Error; err != nil {
+		return err
+	}
+	if lots.Quantity > level.Quantity {
+		return ErrNegativeStock
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package repo
+
+import (
+	"errors"
+
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"gorm.io/gorm"
+)
+
+type SupplierRepository struct {
+	db *gorm.DB
+}
+
+func NewSupplierRepository(db *gorm.DB) *SupplierRepository {
+	return &SupplierRepository{
+		db: db,
+	}
+}
+
+func (r *SupplierRepository) GetAll() []entity.Supplier {
+	var suppliers []entity.Supplier
+	r.db.Order("Name").Find(&suppliers)
+
+	return suppliers
+}
+
+func (r *SupplierRepository) GetById(id uint32) *entity.Supplier {
+	var supplier entity.Supplier
+	result := r.db.First(&supplier, id)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil
+	}
+
+	return &supplier
+}
+
+func (r *SupplierRepository) GetByName(name string) *entity.Supplier {
+	var supplier entity.Supplier
+	result := r.db.Where("Name = ?", name).First(&supplier)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil
+	}
+
+	return &supplier
+}
+
+func (r *SupplierRepository) Create(c *entity.Supplier) error {
+	result := r.db.Create(&c)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+func (r *SupplierRepository) Update(c *entity.Supplier) error {
+	result := r.db.Save(&c)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+// DeleteById deletes a supplier together with its products.
+func (r *SupplierRepository) DeleteById(id uint32) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("SupplierID = ?", id).Delete(&entity.SupplierProduct{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&entity.Supplier{}, id).Error
+	})
+}
+
+// GetProducts returns the products of a supplier with their cost prices.
+func (r *SupplierRepository) GetProducts(supplierId uint32) []entity.SupplierProduct {
+	var products []entity.SupplierProduct
+	r.db.Where("SupplierID = ?", supplierId).
+		Preload("Product").
+		Order("ProductID").
+		Find(&products)
+
+	return products
+}
+
+// GetProductSuppliers returns the suppliers of a product with their cost
+// prices.
+func (r *SupplierRepository) GetProductSuppliers(productId uint32) []entity.SupplierProduct {
+	var products []entity.SupplierProduct
+	r.db.Where("ProductID = ?", productId).
+		Preload("Supplier").
+		Order("SupplierID").
+		Find(&products)
+
+	return products
+}
+
+func (r *SupplierRepository) GetProduct(supplierId uint32, productId uint32) *entity.SupplierProduct {
+	var product entity.SupplierProduct
+	result := r.db.Where("SupplierID = ? AND ProductID = ?", supplierId, productId).First(&product)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil
+	}
+
+	return &product
+}
+
+// SaveProduct creates the cost price of a product at a supplier or replaces
+// the existing one.
+func (r *SupplierRepository) SaveProduct(c *entity.SupplierProduct) error {
+	existing := r.GetProduct(c.SupplierID, c.ProductID)
+	if existing == nil {
+		return r.db.Omit("Supplier", "Product").Create(c).Error
+	}
+
+	c.CreatedAt = existing.CreatedAt
+	return r.db.Model(c).Omit("Supplier", "Product").
+		Select("SupplierSku", "CostPriceAmount", "CostPriceCurrency", "UpdatedAt").Updates(c).Error
+}
+
+func (r *SupplierRepository) DeleteProduct(supplierId uint32, productId uint32) error {
+	result := r.db.Where("SupplierID = ? AND ProductID = ?", supplierId, productId).Delete(&entity.SupplierProduct{})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
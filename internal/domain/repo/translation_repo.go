@@ -0,0 +1,60 @@
+package repo
+
+import (
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"gorm.io/gorm"
+)
+
+type TranslationRepository struct {
+	db *gorm.DB
+}
+
+func NewTranslationRepository(db *gorm.DB) *TranslationRepository {
+	return &TranslationRepository{
+		db: db,
+	}
+}
+
+// GetAll returns the translations of a record ordered by locale and field.
+func (r *TranslationRepository) GetAll(entityType string, entityId uint32) []entity.Translation {
+	var translations []entity.Translation
+	r.db.Where("EntityType = ? AND EntityID = ?", entityType, entityId).
+		Order("Locale, Field").
+		Find(&translations)
+
+	return translations
+}
+
+// GetByEntities returns the translations of the given records in a locale.
+func (r *TranslationRepository) GetByEntities(entityType string, ids []uint32, locale string) []entity.Translation {
+	var translations []entity.Translation
+	if len(ids) == 0 {
+		return translations
+	}
+	r.db.Where("EntityType = ? AND EntityID IN ? AND Locale = ?", entityType, ids, locale).Find(&translations)
+
+	return translations
+}
+
+// Replace replaces the translations of a record in a locale with the given
+// ones.
+func (r *TranslationRepository) Replace(entityType string, entityId uint32, locale string,
+	translations []*entity.Translation) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("EntityType = ? AND EntityID = ? AND Locale = ?", entityType, entityId, locale).
+			Delete(&entity.Translation{}).Error
+		if err != nil || len(translations) == 0 {
+			return err
+		}
+		return tx.Create(&translations).Error
+	})
+}
+
+// Delete removes the translations of a record in a locale and returns how
+// many were removed.
+func (r *TranslationRepository) Delete(entityType string, entityId uint32, locale string) (int, error) {
+	result := r.db.Where("EntityType = ? AND EntityID = ? AND Locale = ?", entityType, entityId, locale).
+		Delete(&entity.Translation{})
+
+	return int(result.RowsAffected), result.Error
+}
@@ -2,6 +2,7 @@ package repo
 
 import (
 	"errors"
+	"time"
 
 	"github.com/bestetufan/beste-store/internal/domain/entity"
 	"gorm.io/gorm"
@@ -99,3 +100,68 @@ func (r *UserRepository) DeleteById(id int) error {
 
 	return nil
 }
+
+// GetAllDeleted returns the soft deleted users, most recently deleted first.
+func (r *UserRepository) GetAllDeleted(pageIndex, pageSize int) ([]entity.User, int) {
+	var users []entity.User
+	var count int64
+
+	deleted := r.db.Unscoped().Model(&entity.User{}).Where("DeletedAt IS NOT NULL")
+	deleted.Count(&count)
+	deleted.Order("DeletedAt DESC").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&users)
+
+	return users, int(count)
+}
+
+func (r *UserRepository) GetDeletedById(id int) *entity.User {
+	var user entity.User
+	result := r.db.Unscoped().Where("DeletedAt IS NOT NULL").First(&user, id)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil
+	}
+
+	return &user
+}
+
+func (r *UserRepository) GetDeletedByEmail(email string) *entity.User {
+	var user entity.User
+	result := r.db.Unscoped().Where("Email = ? AND DeletedAt IS NOT NULL", email).First(&user)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil
+	}
+
+	return &user
+}
+
+func (r *UserRepository) Restore(id int) error {
+	result := r.db.Unscoped().Model(&entity.User{}).Where("ID = ?", id).Update("DeletedAt", nil)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+// Purge permanently deletes the users deleted before the given time with
+// their role assignments. It returns the number of purged users.
+func (r *UserRepository) Purge(deletedBefore time.Time) (int, error) {
+	var count int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var users []entity.User
+		if err := tx.Unscoped().Where("DeletedAt < ?", deletedBefore).Find(&users).Error; err != nil || len(users) == 0 {
+			return err
+		}
+
+		if err := tx.Model(&users).Association("Roles").Clear(); err != nil {
+			return err
+		}
+		result := tx.Unscoped().Delete(&users)
+		count = result.RowsAffected
+		return result.Error
+	})
+
+	return int(count), err
+}
@@ -0,0 +1,80 @@
+package repo
+
+import (
+	"errors"
+
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"gorm.io/gorm"
+)
+
+type WarehouseRepository struct {
+	db *gorm.DB
+}
+
+func NewWarehouseRepository(db *gorm.DB) *WarehouseRepository {
+	return &WarehouseRepository{
+		db: db,
+	}
+}
+
+func (r *WarehouseRepository) GetAll() []entity.Warehouse {
+	var warehouses []entity.Warehouse
+	r.db.Order("ID").Find(&warehouses)
+
+	return warehouses
+}
+
+func (r *WarehouseRepository) GetById(id uint32) *entity.Warehouse {
+	var warehouse entity.Warehouse
+	result := r.db.First(&warehouse, id)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil
+	}
+
+	return &warehouse
+}
+
+func (r *WarehouseRepository) GetByCode(code string) *entity.Warehouse {
+	var warehouse entity.Warehouse
+	result := r.db.Where("Code = ?", code).First(&warehouse)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil
+	}
+
+	return &warehouse
+}
+
+func (r *WarehouseRepository) GetDefault() *entity.Warehouse {
+	var warehouse entity.Warehouse
+	result := r.db.Where("IsDefault = ?", true).First(&warehouse)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil
+	}
+
+	return &warehouse
+}
+
+func (r *WarehouseRepository) Create(c *entity.Warehouse) error {
+	result := r.db.Create(&c)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+// SetDefault makes the warehouse the only default one.
+func (r *WarehouseRepository) SetDefault(id uint32) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&entity.Warehouse{}).Where("IsDefault = ? AND ID <> ?", true, id).
+			Update("IsDefault", false).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&entity.Warehouse{}).Where("ID = ?", id).Update("IsDefault", true).Error
+	})
+}
@@ -0,0 +1,115 @@
+package repo
+
+import (
+	"errors"
+
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"gorm.io/gorm"
+)
+
+type WishlistRepository struct {
+	db *gorm.DB
+}
+
+func NewWishlistRepository(db *gorm.DB) *WishlistRepository {
+	return &WishlistRepository{
+		db: db,
+	}
+}
+
+// GetAll returns the wishlist of a user, last added first.
+func (r *WishlistRepository) GetAll(userName string) []entity.WishlistItem {
+	var items []entity.WishlistItem
+	r.db.Where("UserName = ?", userName).
+		Preload("Product").
+		Preload("Product.Category").
+		Preload("Product.SalePrice", activeSalePrice).
+		Preload("Product.Components.Product").
+		Preload("Product.File").
+		Order("CreatedAt DESC, ProductID").
+		Find(&items)
+
+	return items
+}
+
+func (r *WishlistRepository) Get(userName string, productId uint32) *entity.WishlistItem {
+	var item entity.WishlistItem
+	result := r.db.Where("UserName = ? AND ProductID = ?", userName, productId).First(&item)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil
+	}
+
+	return &item
+}
+
+func (r *WishlistRepository) Create(c *entity.WishlistItem) error {
+	result := r.db.Omit("Product").Create(&c)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+func (r *WishlistRepository) Delete(c *entity.WishlistItem) error {
+	result := r.db.Where("UserName = ? AND ProductID = ?", c.UserName, c.ProductID).Delete(&entity.WishlistItem{})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+// GetSubscription returns the back in stock subscription of a user to a
+// product.
+func (r *WishlistRepository) GetSubscription(userName string, productId uint32) *entity.StockSubscription {
+	var subscription entity.StockSubscription
+	result := r.db.Where("UserName = ? AND ProductID = ?", userName, productId).First(&subscription)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil
+	}
+
+	return &subscription
+}
+
+// GetSubscriptions returns the back in stock subscriptions to a product,
+// oldest first.
+func (r *WishlistRepository) GetSubscriptions(productId uint32) []entity.StockSubscription {
+	var subscriptions []entity.StockSubscription
+	r.db.Where("ProductID = ?", productId).Order("CreatedAt, UserName").Find(&subscriptions)
+
+	return subscriptions
+}
+
+// GetSubscribedProductIds returns the ids of the products users are waiting
+// for.
+func (r *WishlistRepository) GetSubscribedProductIds() []uint32 {
+	var ids []uint32
+	r.db.Model(&entity.StockSubscription{}).Distinct("ProductID").Order("ProductID").Pluck("ProductID", &ids)
+
+	return ids
+}
+
+func (r *WishlistRepository) CreateSubscription(c *entity.StockSubscription) error {
+	result := r.db.Create(&c)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+func (r *WishlistRepository) DeleteSubscription(c *entity.StockSubscription) error {
+	result := r.db.Where("UserName = ? AND ProductID = ?", c.UserName, c.ProductID).Delete(&entity.StockSubscription{})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"github.com/bestetufan/beste-store/internal/domain/repo"
+	"github.com/bestetufan/beste-store/pkg/spreadsheet"
+)
+
+// categoryImportHeaders maps accepted header titles to columns.
+var categoryImportHeaders = map[string]string{
+	"name":      "name",
+	"category":  "name",
+	"ad":        "name",
+	"adı":       "name",
+	"kategori":  "name",
+	"isactive":  "is_active",
+	"is_active": "is_active",
+	"active":    "is_active",
+	"aktif":     "is_active",
+	"durum":     "is_active",
+}
+
+var categoryImportBooleans = map[string]bool{
+	"true": true, "1": true, "yes": true, "y": true, "evet": true, "e": true, "aktif": true,
+	"false": false, "0": false, "no": false, "n": false, "hayır": false, "hayir": false, "h": false, "pasif": false,
+}
+
+type categoryImportItem struct {
+	category *entity.Category
+	create   bool
+}
+
+// CreateBulkCategory imports categories from spreadsheet rows with a name and
+// an optional active flag (defaults to active). A header row is recognized by
+// its titles and may reorder the columns; without one the name is expected
+// first. Existing categories are skipped, or updated when updateExisting is
+// set. In dry-run mode nothing is written. In atomic mode all writes run in
+// one transaction and any invalid row rejects the whole file; otherwise each
+// row is written on its own and rows that fail are reported invalid.
+func (s *StoreService) CreateBulkCategory(rows []spreadsheet.Row, updateExisting bool, dryRun bool, atomic bool) (*ImportReport, error) {
+	if len(rows) == 0 {
+		return nil, errors.New("file is empty")
+	}
+
+	nameColumn, activeColumn, hasHeader := categoryImportHeader(rows[0])
+	if hasHeader {
+		rows = rows[1:]
+		if nameColumn < 0 {
+			return nil, errors.New("name column not found in header")
+		}
+	}
+
+	report := &ImportReport{DryRun: dryRun, Atomic: atomic}
+	items := make([]*categoryImportItem, 0, len(rows))
+	seen := make(map[string]int)
+
+	for _, row := range rows {
+		if row.IsEmpty() {
+			continue
+		}
+
+		name := row.Get(nameColumn)
+		result := ImportRow{Line: row.Line, Key: name, Action: ImportActionInvalid}
+
+		active, err := parseCategoryActive(row.Get(activeColumn))
+		switch line, duplicate := seen[strings.ToLower(name)]; {
+		case len(name) == 0:
+			result.Reason = "name is required"
+		case len(name) > 50:
+			result.Reason = "name is longer than 50 characters"
+		case err != nil:
+			result.Reason = err.Error()
+		case duplicate:
+			result.Reason = fmt.Sprintf("duplicate name, first seen on line %d", line)
+		}
+		if result.Reason != "" {
+			report.add(result)
+			items = append(items, nil)
+			continue
+		}
+		seen[strings.ToLower(name)] = row.Line
+
+		var item *categoryImportItem
+		existing := s.categoryRepo.GetByName(name)
+		switch {
+		case existing == nil:
+			result.Action = ImportActionCreated
+			item = &categoryImportItem{category: entity.NewCategory(name, active), create: true}
+		case !updateExisting:
+			result.Action, result.Reason = ImportActionSkipped, "category already exists"
+		case existing.IsActive == active:
+			result.Action, result.Reason = ImportActionSkipped, "category is unchanged"
+		default:
+			result.Action = ImportActionUpdated
+			existing.IsActive = active
+			item = &categoryImportItem{category: existing}
+		}
+		report.add(result)
+		items = append(items, item)
+	}
+
+	if dryRun || (atomic && report.Invalid > 0) {
+		return report, nil
+	}
+
+	if atomic {
+		failed := -1
+		err := s.categoryRepo.Transaction(func(tx *repo.CategoryRepository) error {
+			for i, item := range items {
+				if err := saveCategoryImportItem(tx, item); err != nil {
+					failed = i
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			report.fail(failed, err.Error())
+			return report, nil
+		}
+		report.Committed = true
+
+		return report, nil
+	}
+
+	for i, item := range items {
+		if err := saveCategoryImportItem(&s.categoryRepo, item); err != nil {
+			report.fail(i, err.Error())
+		}
+	}
+	report.Committed = true
+
+	return report, nil
+}
+
+func saveCategoryImportItem(r *repo.CategoryRepository, item *categoryImportItem) error {
+	if item == nil {
+		return nil
+	}
+	var err error
+	if item.create {
+		err = r.Create(item.category)
+	} else {
+		err = r.Update(*item.category)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to save category: %s", item.category.Name)
+	}
+	return nil
+}
+
+// categoryImportHeader reports whether the row is a header and returns the
+// name and active column indexes. Without a header the columns are 0 and 1.
+func categoryImportHeader(row spreadsheet.Row) (int, int, bool) {
+	nameColumn, activeColumn, hasHeader := -1, -1, false
+	for i := range row.Cells {
+		switch categoryImportHeaders[strings.ToLower(row.Get(i))] {
+		case "name":
+			nameColumn, hasHeader = i, true
+		case "is_active":
+			activeColumn, hasHeader = i, true
+		}
+	}
+
+	if !hasHeader {
+		return 0, 1, false
+	}
+	return nameColumn, activeColumn, true
+}
+
+func parseCategoryActive(value string) (bool, error) {
+	if len(value) == 0 {
+		return true, nil
+	}
+	active, ok := categoryImportBooleans[strings.ToLower(value)]
+	if !ok {
+		return false, fmt.Errorf("invalid active value: %q", value)
+	}
+	return active, nil
+}
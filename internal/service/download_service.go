@@ -0,0 +1,233 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"github.com/bestetufan/beste-store/internal/domain/repo"
+	"github.com/bestetufan/beste-store/pkg/storage"
+	"github.com/google/uuid"
+)
+
+// Errors of download links that can not be used.
+var (
+	ErrDownloadInvalid = errors.New("invalid download link")
+	ErrDownloadExpired = errors.New("download link has expired")
+	ErrDownloadLimit   = errors.New("download limit reached")
+)
+
+// DownloadService keeps the files of digital products in a storage that is
+// not served publicly and hands them out through signed links to the
+// customers who ordered them. A link is signed with HMAC-SHA256 over the
+// download, its owner and its expiry; it can be used maxCount times within
+// ttl of the order.
+type DownloadService struct {
+	storage      storage.Storage
+	downloadRepo repo.DownloadRepository
+	productRepo  repo.ProductRepository
+	baseURL      string
+	secret       []byte
+	ttl          time.Duration
+	maxCount     int
+	maxSize      int64
+}
+
+func NewDownloadService(st storage.Storage, dr repo.DownloadRepository, pr repo.ProductRepository, baseURL string,
+	secret string, ttl time.Duration, maxCount int, maxSize int64) *DownloadService {
+	return &DownloadService{
+		storage:      st,
+		downloadRepo: dr,
+		productRepo:  pr,
+		baseURL:      strings.TrimSuffix(baseURL, "/"),
+		secret:       []byte(secret),
+		ttl:          ttl,
+		maxCount:     maxCount,
+		maxSize:      maxSize,
+	}
+}
+
+// UploadProductFile stores the file of a digital product, replacing the one
+// it had. The file is streamed to the storage; its size is checked up front.
+func (s *DownloadService) UploadProductFile(productId uint32, file io.ReadSeeker, fileName string,
+	userName string) (*entity.ProductFile, error) {
+	product := s.productRepo.GetById(productId)
+	if product == nil {
+		return nil, errors.New("product not found")
+	}
+	if !product.IsDigital {
+		return nil, fmt.Errorf("%s is not digital", product.Sku)
+	}
+	before := *product
+
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, errors.New("unable to read file")
+	}
+	if size > s.maxSize {
+		return nil, fmt.Errorf("file is larger than %d bytes", s.maxSize)
+	}
+	if size == 0 {
+		return nil, errors.New("file is empty")
+	}
+	head := make([]byte, 512)
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, errors.New("unable to read file")
+	}
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, errors.New("unable to read file")
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, errors.New("unable to read file")
+	}
+
+	fileName = strings.TrimSpace(filepath.Base(fileName))
+	ext := strings.ToLower(filepath.Ext(fileName))
+	contentType := mime.TypeByExtension(ext)
+	if len(contentType) == 0 {
+		contentType = http.DetectContentType(head[:n])
+	}
+
+	key := fmt.Sprintf("products/%d/%s%s", productId, uuid.NewString(), ext)
+	if err := s.storage.Put(key, file, contentType); err != nil {
+		_ = s.storage.Delete(key)
+		return nil, errors.New("unable to store file")
+	}
+
+	productFile, err := entity.NewProductFile(productId, key, fileName, contentType, size)
+	if err != nil {
+		_ = s.storage.Delete(key)
+		return nil, err
+	}
+	if err := s.productRepo.SetFile(productFile); err != nil {
+		_ = s.storage.Delete(key)
+		return nil, errors.New("unable to save file")
+	}
+	if before.File != nil {
+		_ = s.storage.Delete(before.File.Key)
+	}
+
+	product.File = productFile
+	if err := recordProductChange(&s.productRepo, userName, entity.RevisionActionUpdated, &before, product); err != nil {
+		return nil, err
+	}
+
+	return productFile, nil
+}
+
+// DeleteProductFile removes the file of a product from the storage before
+// the product is purged.
+func (s *DownloadService) DeleteProductFile(productId uint32) error {
+	file := s.productRepo.GetFile(productId)
+	if file == nil {
+		return nil
+	}
+	if err := s.storage.Delete(file.Key); err != nil {
+		return errors.New("unable to delete file")
+	}
+	return nil
+}
+
+// Grant lets the customer of an order download the files of the digital
+// products ordered.
+func (s *DownloadService) Grant(orderId string, userName string, productIds []uint32) error {
+	expiresAt := time.Now().Add(s.ttl)
+	downloads := make([]*entity.Download, 0, len(productIds))
+	for _, productId := range productIds {
+		download, err := entity.NewDownload(uuid.NewString(), orderId, productId, userName, expiresAt, s.maxCount)
+		if err != nil {
+			return err
+		}
+		downloads = append(downloads, download)
+	}
+
+	if err := s.downloadRepo.Create(downloads); err != nil {
+		return errors.New("unable to create downloads")
+	}
+	return nil
+}
+
+// Revoke invalidates the download links of an order.
+func (s *DownloadService) Revoke(orderId string) error {
+	if err := s.downloadRepo.DeleteByOrderId(orderId); err != nil {
+		return errors.New("unable to revoke downloads")
+	}
+	return nil
+}
+
+// SetOrderLinks sets the download links of the digital products of an order.
+func (s *DownloadService) SetOrderLinks(order *entity.Order) {
+	downloads := s.downloadRepo.GetByOrderId(order.ID)
+	for _, item := range order.Items {
+		for i := range downloads {
+			if downloads[i].ProductID == item.ProductID {
+				item.Download = s.link(&downloads[i])
+			}
+		}
+	}
+}
+
+// Open checks a download link and counts the download. The caller must close
+// the returned content.
+func (s *DownloadService) Open(id string, expires string, signature string) (*entity.ProductFile, io.ReadCloser, error) {
+	download := s.downloadRepo.GetById(id)
+	if download == nil {
+		return nil, nil, ErrDownloadInvalid
+	}
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || !hmac.Equal([]byte(signature), []byte(s.sign(download, unix))) {
+		return nil, nil, ErrDownloadInvalid
+	}
+	now := time.Now()
+	if now.Unix() >= unix || !now.Before(download.ExpiresAt) {
+		return nil, nil, ErrDownloadExpired
+	}
+
+	file := s.productRepo.GetFile(download.ProductID)
+	if file == nil {
+		return nil, nil, errors.New("file not found")
+	}
+	content, err := s.storage.Get(file.Key)
+	if err != nil {
+		return nil, nil, errors.New("unable to read file")
+	}
+
+	counted, err := s.downloadRepo.Count(download.ID)
+	if err != nil || !counted {
+		content.Close()
+		if err != nil {
+			return nil, nil, errors.New("unable to count download")
+		}
+		return nil, nil, ErrDownloadLimit
+	}
+
+	return file, content, nil
+}
+
+func (s *DownloadService) link(download *entity.Download) *entity.DownloadLink {
+	expires := download.ExpiresAt.Unix()
+	return &entity.DownloadLink{
+		URL:       fmt.Sprintf("%s/%s?expires=%d&signature=%s", s.baseURL, download.ID, expires, s.sign(download, expires)),
+		ExpiresAt: download.ExpiresAt,
+		Remaining: download.Remaining(),
+	}
+}
+
+// sign returns the hex encoded signature of a link to download until
+// expires, in Unix time.
+func (s *DownloadService) sign(download *entity.Download, expires int64) string {
+	mac := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(mac, "%s\n%s\n%d", download.ID, download.UserName, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,353 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"github.com/bestetufan/beste-store/internal/domain/repo"
+	"github.com/bestetufan/beste-store/pkg/money"
+	"github.com/bestetufan/beste-store/pkg/spreadsheet"
+)
+
+// ExchangeService maintains the exchange rates of the store and converts
+// prices into the currency a customer asked for.
+//
+// Rates are relative to the default currency of the store. A conversion
+// between two other currencies goes through it with the exact ratio of their
+// rates. Every price is converted on its own and rounded half away from zero
+// to the minor unit of the target currency; totals are the sum of the
+// converted prices, so they always match their lines.
+type ExchangeService struct {
+	exchangeRateRepo repo.ExchangeRateRepository
+	currency         string
+}
+
+func NewExchangeService(er repo.ExchangeRateRepository, currency string) *ExchangeService {
+	return &ExchangeService{
+		exchangeRateRepo: er,
+		currency:         currency,
+	}
+}
+
+func (s *ExchangeService) GetRates() []entity.ExchangeRate {
+	return s.exchangeRateRepo.GetAll()
+}
+
+// SetRate creates or replaces the rate of a currency.
+func (s *ExchangeService) SetRate(currency string, rate string, userName string) (*entity.ExchangeRate, error) {
+	exchangeRate, err := s.newRate(currency, rate, userName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.exchangeRateRepo.Save(exchangeRate); err != nil {
+		return nil, errors.New("an unknown error occurred during operation")
+	}
+
+	return exchangeRate, nil
+}
+
+func (s *ExchangeService) newRate(currency string, rate string, userName string) (*entity.ExchangeRate, error) {
+	exchangeRate, err := entity.NewExchangeRate(currency, rate, userName)
+	if err != nil {
+		return nil, err
+	}
+	if exchangeRate.Currency == s.currency {
+		return nil, fmt.Errorf("%s is the default currency", s.currency)
+	}
+	return exchangeRate, nil
+}
+
+func (s *ExchangeService) DeleteRate(currency string) error {
+	currency = strings.ToUpper(currency)
+	if s.exchangeRateRepo.GetByCurrency(currency) == nil {
+		return errors.New("exchange rate not found")
+	}
+
+	if err := s.exchangeRateRepo.DeleteByCurrency(currency); err != nil {
+		return errors.New("an unknown error occurred during operation")
+	}
+
+	return nil
+}
+
+// ImportRates creates or replaces exchange rates from spreadsheet rows with
+// a currency and rate header. Rows with an unchanged rate are skipped. In
+// dry-run mode nothing is written; in atomic mode any invalid row rejects the
+// whole file.
+func (s *ExchangeService) ImportRates(rows []spreadsheet.Row, dryRun bool, atomic bool, userName string) (*ImportReport, error) {
+	if len(rows) == 0 {
+		return nil, errors.New("file is empty")
+	}
+
+	currencyColumn, rateColumn := -1, -1
+	for i, title := range rows[0].Cells {
+		switch strings.ToLower(strings.TrimSpace(title)) {
+		case "currency":
+			currencyColumn = i
+		case "rate":
+			rateColumn = i
+		}
+	}
+	if currencyColumn < 0 || rateColumn < 0 {
+		return nil, errors.New("currency and rate columns are required")
+	}
+
+	report := &ImportReport{DryRun: dryRun, Atomic: atomic}
+	items := make([]*entity.ExchangeRate, 0, len(rows)-1)
+	seen := make(map[string]int)
+
+	for _, row := range rows[1:] {
+		if row.IsEmpty() {
+			continue
+		}
+
+		result := ImportRow{Line: row.Line, Key: row.Get(currencyColumn), Action: ImportActionInvalid}
+		exchangeRate, err := s.newRate(row.Get(currencyColumn), row.Get(rateColumn), userName)
+		if err != nil {
+			result.Reason = err.Error()
+			report.add(result)
+			items = append(items, nil)
+			continue
+		}
+		result.Key = exchangeRate.Currency
+
+		if line, ok := seen[exchangeRate.Currency]; ok {
+			result.Reason = fmt.Sprintf("duplicate currency, first seen on line %d", line)
+			report.add(result)
+			items = append(items, nil)
+			continue
+		}
+		seen[exchangeRate.Currency] = row.Line
+
+		existing := s.exchangeRateRepo.GetByCurrency(exchangeRate.Currency)
+		switch {
+		case existing == nil:
+			result.Action = ImportActionCreated
+		case existing.Ratio().Cmp(exchangeRate.Ratio()) == 0:
+			result.Action, result.Reason = ImportActionSkipped, "rate is unchanged"
+			exchangeRate = nil
+		default:
+			result.Action = ImportActionUpdated
+		}
+		report.add(result)
+		items = append(items, exchangeRate)
+	}
+
+	if dryRun || (atomic && report.Invalid > 0) {
+		return report, nil
+	}
+
+	failed := -1
+	err := s.exchangeRateRepo.Transaction(func(tx *repo.ExchangeRateRepository) error {
+		for i, item := range items {
+			if item == nil {
+				continue
+			}
+			if err := tx.Save(item); err != nil {
+				failed = i
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		report.fail(failed, "unable to save exchange rate")
+		return report, nil
+	}
+	report.Committed = true
+
+	return report, nil
+}
+
+// Rate returns the value of one unit of from in to.
+func (s *ExchangeService) Rate(from string, to string) (*big.Rat, error) {
+	c, err := s.converter(to)
+	if err != nil {
+		return nil, err
+	}
+	return c.rate(from)
+}
+
+// Convert returns price in currency. An empty currency leaves it unchanged.
+func (s *ExchangeService) Convert(price money.Money, currency string) (money.Money, error) {
+	if len(currency) == 0 {
+		return price, nil
+	}
+	c, err := s.converter(currency)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return c.convert(price)
+}
+
+// ConvertProduct converts the list and sale prices of a product in place.
+// An empty currency leaves them unchanged.
+func (s *ExchangeService) ConvertProduct(product *entity.Product, currency string) error {
+	if len(currency) == 0 {
+		return nil
+	}
+	c, err := s.converter(currency)
+	if err != nil {
+		return err
+	}
+	return c.convertProduct(product)
+}
+
+// ConvertProducts is ConvertProduct for a list of products.
+func (s *ExchangeService) ConvertProducts(products []entity.Product, currency string) error {
+	if len(currency) == 0 {
+		return nil
+	}
+	c, err := s.converter(currency)
+	if err != nil {
+		return err
+	}
+
+	for i := range products {
+		if err := c.convertProduct(&products[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ConvertBasket converts the prices of the products in a basket in place.
+// An empty currency leaves them unchanged.
+func (s *ExchangeService) ConvertBasket(basket *entity.Basket, currency string) error {
+	if len(currency) == 0 {
+		return nil
+	}
+	c, err := s.converter(currency)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range basket.Items {
+		if item.Product != nil {
+			if err := c.convertProduct(item.Product); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ConvertOrders converts the current prices of the products of orders in
+// place. Item prices stay in the currency the orders were charged in, at the
+// exchange rate recorded when they were placed. An empty currency leaves them
+// unchanged.
+func (s *ExchangeService) ConvertOrders(orders []entity.Order, currency string) error {
+	if len(currency) == 0 {
+		return nil
+	}
+	c, err := s.converter(currency)
+	if err != nil {
+		return err
+	}
+
+	for i := range orders {
+		for _, item := range orders[i].Items {
+			if item.Product != nil {
+				if err := c.convertProduct(item.Product); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ConvertPriceHistory converts the prices of a price history in place. An
+// empty currency leaves it unchanged.
+func (s *ExchangeService) ConvertPriceHistory(history *PriceHistory, currency string) error {
+	if len(currency) == 0 {
+		return nil
+	}
+	c, err := s.converter(currency)
+	if err != nil {
+		return err
+	}
+
+	if history.CurrentPrice, err = c.convert(history.CurrentPrice); err != nil {
+		return err
+	}
+	if history.LowestPrice, err = c.convert(history.LowestPrice); err != nil {
+		return err
+	}
+	for i := range history.Prices {
+		if history.Prices[i].Price, err = c.convert(history.Prices[i].Price); err != nil {
+			return err
+		}
+	}
+	for _, p := range history.SalePrices {
+		if p.Price, err = c.convert(p.Price); err != nil {
+			return err
+		}
+	}
+	history.Currency = c.currency
+	return nil
+}
+
+// converter loads the rates once for converting many prices into currency.
+func (s *ExchangeService) converter(currency string) (*converter, error) {
+	currency = strings.ToUpper(currency)
+	if !money.Valid(currency) {
+		return nil, fmt.Errorf("unknown currency: %q", currency)
+	}
+
+	rates := map[string]*big.Rat{s.currency: big.NewRat(1, 1)}
+	for _, r := range s.exchangeRateRepo.GetAll() {
+		rates[r.Currency] = r.Ratio()
+	}
+
+	c := &converter{currency: currency, rates: rates}
+	if _, ok := rates[currency]; !ok {
+		return nil, fmt.Errorf("no exchange rate for %s", currency)
+	}
+	return c, nil
+}
+
+type converter struct {
+	currency string
+	rates    map[string]*big.Rat
+}
+
+func (c *converter) rate(from string) (*big.Rat, error) {
+	if from == c.currency {
+		return big.NewRat(1, 1), nil
+	}
+	rate, ok := c.rates[from]
+	if !ok {
+		return nil, fmt.Errorf("no exchange rate for %s", from)
+	}
+	return new(big.Rat).Quo(rate, c.rates[c.currency]), nil
+}
+
+func (c *converter) convert(price money.Money) (money.Money, error) {
+	if price.Currency == c.currency || (price.Currency == "" && price.IsZero()) {
+		return money.New(price.Amount, c.currency), nil
+	}
+	rate, err := c.rate(price.Currency)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return price.Convert(c.currency, rate), nil
+}
+
+func (c *converter) convertProduct(p *entity.Product) error {
+	var err error
+	if p.UnitPrice, err = c.convert(p.UnitPrice); err != nil {
+		return err
+	}
+	if p.SalePrice != nil {
+		if p.SalePrice.Price, err = c.convert(p.SalePrice.Price); err != nil {
+			return err
+		}
+	}
+	p.Currency = c.currency
+	return nil
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"github.com/bestetufan/beste-store/pkg/spreadsheet"
+)
+
+const exportBatchSize = 500
+
+type (
+	productColumn struct {
+		name  string
+		value func(p *entity.Product) interface{}
+	}
+
+	categoryColumn struct {
+		name  string
+		value func(c *entity.Category) interface{}
+	}
+
+	orderColumn struct {
+		name  string
+		value func(o *entity.Order) interface{}
+	}
+)
+
+var productExportColumns = []productColumn{
+	{"id", func(p *entity.Product) interface{} { return p.ID }},
+	{"sku", func(p *entity.Product) interface{} { return p.Sku }},
+	{"gtin", func(p *entity.Product) interface{} {
+		if p.GTIN == nil {
+			return nil
+		}
+		return *p.GTIN
+	}},
+	{"name", func(p *entity.Product) interface{} { return p.Name }},
+	{"unit_price", func(p *entity.Product) interface{} { return json.Number(p.UnitPrice.String()) }},
+	{"currency", func(p *entity.Product) interface{} { return p.UnitPrice.Currency }},
+	{"sale_price", func(p *entity.Product) interface{} {
+		if p.SalePrice == nil {
+			return nil
+		}
+		return json.Number(p.SalePrice.Price.String())
+	}},
+	{"unit", func(p *entity.Product) interface{} { return p.Unit }},
+	{"quantity_step", func(p *entity.Product) interface{} { return json.Number(p.QuantityStep.String()) }},
+	{"min_quantity", func(p *entity.Product) interface{} { return json.Number(p.MinQuantity.String()) }},
+	{"quantity", func(p *entity.Product) interface{} { return json.Number(p.Quantity.String()) }},
+	{"reorder_point", func(p *entity.Product) interface{} { return json.Number(p.ReorderPoint.String()) }},
+	{"reorder_quantity", func(p *entity.Product) interface{} { return json.Number(p.ReorderQuantity.String()) }},
+	{"category_id", func(p *entity.Product) interface{} { return p.CategoryID }},
+	{"category", func(p *entity.Product) interface{} { return p.Category.Name }},
+	{"status", func(p *entity.Product) interface{} { return p.Status }},
+	{"publish_at", func(p *entity.Product) interface{} {
+		if p.PublishAt == nil {
+			return nil
+		}
+		return *p.PublishAt
+	}},
+	{"created_at", func(p *entity.Product) interface{} { return p.CreatedAt }},
+	{"updated_at", func(p *entity.Product) interface{} { return p.UpdatedAt }},
+}
+
+var categoryExportColumns = []categoryColumn{
+	{"id", func(c *entity.Category) interface{} { return c.ID }},
+	{"name", func(c *entity.Category) interface{} { return c.Name }},
+	{"is_active", func(c *entity.Category) interface{} { return c.IsActive }},
+	{"created_at", func(c *entity.Category) interface{} { return c.CreatedAt }},
+	{"updated_at", func(c *entity.Category) interface{} { return c.UpdatedAt }},
+}
+
+// Payment card fields are never exported.
+var orderExportColumns = []orderColumn{
+	{"id", func(o *entity.Order) interface{} { return o.ID }},
+	{"user_name", func(o *entity.Order) interface{} { return o.UserName }},
+	{"status", func(o *entity.Order) interface{} { return o.Status }},
+	{"name", func(o *entity.Order) interface{} { return o.Name }},
+	{"address", func(o *entity.Order) interface{} { return o.Address }},
+	{"phone_number", func(o *entity.Order) interface{} { return o.PhoneNumber }},
+	{"item_count", func(o *entity.Order) interface{} { return len(o.Items) }},
+	{"product_ids", func(o *entity.Order) interface{} {
+		ids := make([]string, len(o.Items))
+		for i, item := range o.Items {
+			ids[i] = fmt.Sprint(item.ProductID)
+		}
+		return strings.Join(ids, ",")
+	}},
+	{"created_at", func(o *entity.Order) interface{} { return o.CreatedAt }},
+	{"updated_at", func(o *entity.Order) interface{} { return o.UpdatedAt }},
+}
+
+// ExportProducts streams the products matching the filter to w. columns
+// selects and orders the exported columns (all when empty); "attr.<code>"
+// columns export attribute values in the format accepted by ImportProducts.
+func (s *StoreService) ExportProducts(w spreadsheet.Writer, filter entity.ProductFilter, columns []string) error {
+	if len(columns) == 0 {
+		for _, c := range productExportColumns {
+			columns = append(columns, c.name)
+		}
+	}
+
+	selected := make([]productColumn, 0, len(columns))
+	for _, name := range columns {
+		selected = append(selected, productColumn{name: name})
+		if code := strings.TrimPrefix(name, productImportAttributePrefix); code != name {
+			selected[len(selected)-1].value = attributeValue(code)
+			continue
+		}
+		for _, c := range productExportColumns {
+			if c.name == name {
+				selected[len(selected)-1].value = c.value
+			}
+		}
+		if selected[len(selected)-1].value == nil {
+			return fmt.Errorf("unknown column: %s", name)
+		}
+	}
+
+	if err := w.WriteHeader(columns); err != nil {
+		return err
+	}
+
+	err := s.productRepo.FindInBatches(filter, exportBatchSize, func(products []entity.Product) error {
+		for i := range products {
+			values := make([]interface{}, len(selected))
+			for j, c := range selected {
+				values[j] = c.value(&products[i])
+			}
+			if err := w.Write(values); err != nil {
+				return err
+			}
+		}
+		return w.Flush()
+	})
+	if err != nil {
+		return err
+	}
+
+	return w.Close()
+}
+
+func (s *StoreService) ExportCategories(w spreadsheet.Writer, onlyActives bool, columns []string) error {
+	if len(columns) == 0 {
+		for _, c := range categoryExportColumns {
+			columns = append(columns, c.name)
+		}
+	}
+
+	selected := make([]categoryColumn, 0, len(columns))
+	for _, name := range columns {
+		found := false
+		for _, c := range categoryExportColumns {
+			if c.name == name {
+				selected, found = append(selected, c), true
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown column: %s", name)
+		}
+	}
+
+	if err := w.WriteHeader(columns); err != nil {
+		return err
+	}
+
+	err := s.categoryRepo.FindInBatches(onlyActives, exportBatchSize, func(categories []entity.Category) error {
+		for i := range categories {
+			values := make([]interface{}, len(selected))
+			for j, c := range selected {
+				values[j] = c.value(&categories[i])
+			}
+			if err := w.Write(values); err != nil {
+				return err
+			}
+		}
+		return w.Flush()
+	})
+	if err != nil {
+		return err
+	}
+
+	return w.Close()
+}
+
+func (s *StoreService) ExportOrders(w spreadsheet.Writer, userName string, status string, columns []string) error {
+	if len(columns) == 0 {
+		for _, c := range orderExportColumns {
+			columns = append(columns, c.name)
+		}
+	}
+
+	selected := make([]orderColumn, 0, len(columns))
+	for _, name := range columns {
+		found := false
+		for _, c := range orderExportColumns {
+			if c.name == name {
+				selected, found = append(selected, c), true
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown column: %s", name)
+		}
+	}
+
+	if err := w.WriteHeader(columns); err != nil {
+		return err
+	}
+
+	err := s.orderRepo.FindInBatches(userName, status, exportBatchSize, func(orders []entity.Order) error {
+		for i := range orders {
+			values := make([]interface{}, len(selected))
+			for j, c := range selected {
+				values[j] = c.value(&orders[i])
+			}
+			if err := w.Write(values); err != nil {
+				return err
+			}
+		}
+		return w.Flush()
+	})
+	if err != nil {
+		return err
+	}
+
+	return w.Close()
+}
+
+func attributeValue(code string) func(p *entity.Product) interface{} {
+	return func(p *entity.Product) interface{} {
+		for _, a := range p.Attributes {
+			if a.Attribute != nil && a.Attribute.Code == code {
+				return a.Value
+			}
+		}
+		return nil
+	}
+}
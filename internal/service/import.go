@@ -0,0 +1,62 @@
+package service
+
+const (
+	ImportActionCreated = "created"
+	ImportActionUpdated = "updated"
+	ImportActionSkipped = "skipped"
+	ImportActionInvalid = "invalid"
+)
+
+type (
+	// ImportReport describes the outcome of a bulk import, row by row.
+	ImportReport struct {
+		DryRun    bool        `json:"dry_run"`
+		Atomic    bool        `json:"atomic"`
+		Committed bool        `json:"committed"`
+		Total     int         `json:"total"`
+		Created   int         `json:"created"`
+		Updated   int         `json:"updated"`
+		Skipped   int         `json:"skipped"`
+		Invalid   int         `json:"invalid"`
+		Rows      []ImportRow `json:"rows"`
+	}
+
+	ImportRow struct {
+		Line   int    `json:"line"`
+		Key    string `json:"key"`
+		Action string `json:"action"`
+		Reason string `json:"reason,omitempty"`
+	}
+)
+
+func (r *ImportReport) add(row ImportRow) {
+	r.Total++
+	switch row.Action {
+	case ImportActionCreated:
+		r.Created++
+	case ImportActionUpdated:
+		r.Updated++
+	case ImportActionSkipped:
+		r.Skipped++
+	case ImportActionInvalid:
+		r.Invalid++
+	}
+	r.Rows = append(r.Rows, row)
+}
+
+// fail turns a planned row into an invalid one after a write error.
+func (r *ImportReport) fail(i int, reason string) {
+	switch r.Rows[i].Action {
+	case ImportActionCreated:
+		r.Created--
+	case ImportActionUpdated:
+		r.Updated--
+	case ImportActionSkipped:
+		r.Skipped--
+	case ImportActionInvalid:
+		return
+	}
+	r.Invalid++
+	r.Rows[i].Action = ImportActionInvalid
+	r.Rows[i].Reason = reason
+}
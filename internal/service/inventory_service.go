@@ -0,0 +1,753 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"github.com/bestetufan/beste-store/internal/domain/repo"
+	"github.com/bestetufan/beste-store/pkg/measure"
+)
+
+// InventoryService keeps the stock of products per warehouse through the
+// stock ledger. Every change of stock is a movement; the stock levels of the
+// warehouses and the quantity of the product follow from the movements.
+//
+// Items in baskets are reserved for reservationTTL, renewed whenever the
+// basket changes. Reserved stock stays on hand but is not available to
+// others; expired reservations are released by a background job. Ordering
+// turns reservations into sale movements referencing the order, in the same
+// warehouses, and canceling the order returns them.
+//
+// Products of categories that track lots receive stock in lots with an
+// expiry date. Stock leaves a warehouse first-expired-first-out: from the
+// lots expiring first, then from the units not in any lot, e.g. stock kept
+// before the category tracked lots. Expired lots can not be sold; a
+// background job writes them off.
+//
+// Units of serialized products are registered by serial number when they are
+// received and assigned to the items of orders when they are fulfilled.
+//
+// Every movement queues its product for the stock alert check.
+type InventoryService struct {
+	warehouseRepo     repo.WarehouseRepository
+	stockRepo         repo.StockRepository
+	productRepo       repo.ProductRepository
+	stockAlertService StockAlertService
+	reservationTTL    time.Duration
+}
+
+// StockReceipt is a quantity of a product coming into a warehouse. Products
+// tracked by lot come in lot LotNumber, which needs ExpiresAt when it is new;
+// serialized products come with the Serials of the units.
+type StockReceipt struct {
+	ProductID uint32
+	Quantity  measure.Quantity
+	LotNumber string
+	ExpiresAt *time.Time
+	Serials   []string
+}
+
+func NewInventoryService(wr repo.WarehouseRepository, sr repo.StockRepository, pr repo.ProductRepository,
+	as StockAlertService, reservationTTL time.Duration) *InventoryService {
+	return &InventoryService{
+		warehouseRepo:     wr,
+		stockRepo:         sr,
+		productRepo:       pr,
+		stockAlertService: as,
+		reservationTTL:    reservationTTL,
+	}
+}
+
+func (s *InventoryService) GetWarehouses() []entity.Warehouse {
+	return s.warehouseRepo.GetAll()
+}
+
+// CreateWarehouse adds a warehouse. The first warehouse becomes the default
+// one.
+func (s *InventoryService) CreateWarehouse(warehouse *entity.Warehouse) error {
+	if s.warehouseRepo.GetByCode(warehouse.Code) != nil {
+		return errors.New("warehouse with same code already exist in database")
+	}
+	if s.warehouseRepo.GetDefault() == nil {
+		warehouse.IsDefault = true
+	}
+
+	if err := s.warehouseRepo.Create(warehouse); err != nil {
+		return errors.New("an unknown error occurred during operation")
+	}
+
+	return nil
+}
+
+func (s *InventoryService) SetDefaultWarehouse(warehouseId uint32) error {
+	if s.warehouseRepo.GetById(warehouseId) == nil {
+		return errors.New("warehouse not found")
+	}
+
+	if err := s.warehouseRepo.SetDefault(warehouseId); err != nil {
+		return errors.New("an unknown error occurred during operation")
+	}
+
+	return nil
+}
+
+func (s *InventoryService) GetStockLevels(productId uint32) []entity.StockLevel {
+	return s.stockRepo.GetLevels(productId)
+}
+
+func (s *InventoryService) GetMovements(filter entity.StockMovementFilter, pageIndex, pageSize int) ([]entity.StockMovement, int) {
+	return s.stockRepo.GetMovements(filter, pageIndex, pageSize)
+}
+
+func (s *InventoryService) GetLots(productId uint32) []entity.StockLot {
+	return s.stockRepo.GetLots(productId)
+}
+
+// GetExpiringLots returns the lots with stock left that expire within days,
+// first expiring first.
+func (s *InventoryService) GetExpiringLots(days int, pageIndex, pageSize int) ([]entity.StockLot, int) {
+	return s.stockRepo.GetExpiringLots(time.Now().AddDate(0, 0, days), pageIndex, pageSize)
+}
+
+// Adjust posts an adjustment of the stock of a product in a warehouse, or in
+// the default warehouse when warehouseId is zero. Stock added to a product
+// tracked by lot needs a lot; stock removed without a lot is taken from the
+// units not in any lot. Units of serialized products added or removed are
+// given by their serials.
+func (s *InventoryService) Adjust(productId uint32, warehouseId uint32, quantity measure.Quantity, lotNumber string,
+	expiresAt *time.Time, serials []string, note string, userName string) (*entity.StockMovement, error) {
+	product := s.productRepo.GetById(productId)
+	if product == nil {
+		return nil, errors.New("product not found")
+	}
+	if err := product.CheckStockQuantity(quantity); err != nil {
+		return nil, err
+	}
+
+	warehouse, err := s.warehouse(warehouseId)
+	if err != nil {
+		return nil, err
+	}
+
+	movement, err := entity.NewStockMovement(productId, warehouse.ID, entity.StockMovementAdjustment, quantity, "",
+		note, userName)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.stockRepo.Transaction(func(tx *repo.StockRepository) error {
+		if len(lotNumber) > 0 || quantity > 0 && product.TracksLots() {
+			lot, err := s.lot(tx, product, warehouse.ID, lotNumber, expiresAt, quantity > 0)
+			if err != nil {
+				return err
+			}
+			movement.LotID = &lot.ID
+		}
+		if quantity > 0 && (len(serials) > 0 || product.IsSerialized()) {
+			if err := registerSerials(tx, product, warehouse.ID, quantity, serials, "", userName); err != nil {
+				return err
+			}
+		}
+		if quantity < 0 && (len(serials) > 0 || product.IsSerialized()) {
+			if err := removeSerials(tx, product, warehouse.ID, -quantity, serials); err != nil {
+				return err
+			}
+		}
+		return s.post(tx, movement)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return movement, nil
+}
+
+// AdjustTo adjusts the total stock of a product to quantity. Stock is added
+// to the default warehouse and taken from it first.
+func (s *InventoryService) AdjustTo(productId uint32, quantity measure.Quantity, note string, userName string) error {
+	product := s.productRepo.GetById(productId)
+	if product == nil {
+		return errors.New("product not found")
+	}
+	if quantity < 0 {
+		return errors.New("quantity can not be negative")
+	}
+	if err := product.CheckStockQuantity(quantity); err != nil {
+		return err
+	}
+
+	delta := quantity - product.Quantity
+	if delta == 0 {
+		return nil
+	}
+	if err := checkAdjustTo(product, product.TracksLots(), delta); err != nil {
+		return err
+	}
+
+	return s.stockRepo.Transaction(func(tx *repo.StockRepository) error {
+		if delta > 0 {
+			warehouse, err := s.warehouse(0)
+			if err != nil {
+				return err
+			}
+			return s.move(tx, productId, warehouse.ID, entity.StockMovementAdjustment, delta, "", note, userName)
+		}
+
+		// Reserved units are not taken; the stock can not go below them.
+		allocations := s.allocate(productId, -delta)
+		if allocated(allocations) < -delta {
+			return errors.New("not enough stock")
+		}
+		for _, a := range allocations {
+			if err := s.take(tx, productId, a.warehouseId, entity.StockMovementAdjustment, a.quantity, "", note,
+				userName, false); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// checkAdjustTo checks that the stock of a product can be changed by delta
+// without naming the units: units of serialized products are only added and
+// removed with their serials, by Adjust, and units of products tracked by lot
+// are only added into a lot, by Adjust or a purchase order receipt.
+func checkAdjustTo(product *entity.Product, tracksLots bool, delta measure.Quantity) error {
+	if delta != 0 && product.IsSerialized() {
+		return errors.New("stock of serialized products is adjusted with serial numbers through POST /stock/adjustment")
+	}
+	if delta > 0 && tracksLots {
+		return errors.New("stock of products tracked by lot is added with a lot number through POST /stock/adjustment or a purchase order receipt")
+	}
+	return nil
+}
+
+// SetReservation makes the reservation of a product for a basket quantity
+// units, reserving more or releasing some, and renews the expiry of all
+// reservations of the basket. More units are reserved from the default
+// warehouse first and then from the warehouses with the most available stock.
+func (s *InventoryService) SetReservation(productId uint32, basketId string, quantity measure.Quantity) error {
+	reservations := s.stockRepo.GetReservations(productId, basketId)
+	var reserved measure.Quantity
+	for _, r := range reservations {
+		reserved += r.Quantity
+	}
+
+	expiresAt := time.Now().Add(s.reservationTTL)
+	err := s.stockRepo.Transaction(func(tx *repo.StockRepository) error {
+		if quantity < reserved {
+			return release(tx, reservations, reserved-quantity)
+		}
+		if quantity == reserved {
+			return nil
+		}
+
+		allocations := s.allocate(productId, quantity-reserved)
+		if allocated(allocations) < quantity-reserved {
+			return repo.ErrNegativeStock
+		}
+		for _, a := range allocations {
+			reservation, err := entity.NewStockReservation(productId, a.warehouseId, basketId, a.quantity, expiresAt)
+			if err != nil {
+				return err
+			}
+			if err := tx.AddReservation(reservation); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, repo.ErrNegativeStock) {
+		return errors.New("not enough stock")
+	}
+	if err != nil {
+		return errors.New("unable to update product stock info")
+	}
+
+	if err := s.stockRepo.ExtendReservations(basketId, expiresAt); err != nil {
+		return errors.New("unable to update product stock info")
+	}
+	return nil
+}
+
+// Release gives back the reservation of a product for a basket and returns
+// how many units were released.
+func (s *InventoryService) Release(productId uint32, basketId string) (measure.Quantity, error) {
+	reservations := s.stockRepo.GetReservations(productId, basketId)
+	var released measure.Quantity
+	for _, r := range reservations {
+		released += r.Quantity
+	}
+
+	if err := release(&s.stockRepo, reservations, released); err != nil {
+		return 0, errors.New("unable to update product stock info")
+	}
+	return released, nil
+}
+
+// Unreserve gives back up to quantity units of the reservation of a product
+// for a basket, e.g. those of a bundle taken out of it.
+func (s *InventoryService) Unreserve(productId uint32, basketId string, quantity measure.Quantity) error {
+	reservations := s.stockRepo.GetReservations(productId, basketId)
+	if err := release(&s.stockRepo, reservations, quantity); err != nil {
+		return errors.New("unable to update product stock info")
+	}
+	return nil
+}
+
+// ReleaseExpired gives back the reservations that have expired and returns
+// how many were released. Baskets keep their items; they are reserved again
+// when they are changed or ordered.
+func (s *InventoryService) ReleaseExpired() (int, error) {
+	count := 0
+	for _, r := range s.stockRepo.GetExpiredReservations(time.Now()) {
+		reservation := r
+		if err := s.stockRepo.ReleaseReservation(&reservation, reservation.Quantity); err != nil {
+			return count, fmt.Errorf("reservation %d: %w", reservation.ID, err)
+		}
+		count++
+	}
+	return count, nil
+}
+
+// Sell turns the reservation of a product for a basket into a sale for an
+// order, in the warehouses the product was reserved in. It fails when the
+// stock left there is in expired lots.
+func (s *InventoryService) Sell(productId uint32, basketId string, orderId string, userName string) error {
+	reservations := s.stockRepo.GetReservations(productId, basketId)
+
+	return s.stockRepo.Transaction(func(tx *repo.StockRepository) error {
+		for i := range reservations {
+			r := &reservations[i]
+			n := r.Quantity
+			if err := tx.ReleaseReservation(r, n); err != nil {
+				return errors.New("unable to update product stock info")
+			}
+			if err := s.take(tx, productId, r.WarehouseID, entity.StockMovementSale, n,
+				entity.OrderReference(orderId), "", userName, true); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Return puts the units of a product sold for an order back into the
+// warehouses and lots they were sold from. Units already returned are
+// skipped.
+func (s *InventoryService) Return(productId uint32, orderId string, userName string) error {
+	reference := entity.OrderReference(orderId)
+	sold := s.stockRepo.GetNetByLot(productId, reference, entity.StockMovementSale, entity.StockMovementReturn)
+
+	return s.stockRepo.Transaction(func(tx *repo.StockRepository) error {
+		for _, net := range sold {
+			if net.Quantity >= 0 {
+				continue
+			}
+			movement, err := entity.NewStockMovement(productId, net.WarehouseID, entity.StockMovementReturn,
+				-net.Quantity, reference, "", userName)
+			if err != nil {
+				return err
+			}
+			movement.LotID = net.LotID
+			if err := s.post(tx, movement); err != nil {
+				return err
+			}
+		}
+		if err := tx.ReturnSerials(productId, orderId); err != nil {
+			return errors.New("unable to update product stock info")
+		}
+		return nil
+	})
+}
+
+// Receive posts receipts of products into a warehouse in one transaction.
+func (s *InventoryService) Receive(warehouseId uint32, receipts []StockReceipt, reference string,
+	userName string) error {
+	warehouse, err := s.warehouse(warehouseId)
+	if err != nil {
+		return err
+	}
+
+	return s.stockRepo.Transaction(func(tx *repo.StockRepository) error {
+		for _, receipt := range receipts {
+			if receipt.Quantity <= 0 {
+				continue
+			}
+			product := s.productRepo.GetById(receipt.ProductID)
+			if product == nil {
+				return fmt.Errorf("product %d not found", receipt.ProductID)
+			}
+			if err := product.CheckStockQuantity(receipt.Quantity); err != nil {
+				return err
+			}
+
+			movement, err := entity.NewStockMovement(product.ID, warehouse.ID, entity.StockMovementReceipt,
+				receipt.Quantity, reference, "", userName)
+			if err != nil {
+				return err
+			}
+			if len(receipt.LotNumber) > 0 || product.TracksLots() {
+				lot, err := s.lot(tx, product, warehouse.ID, receipt.LotNumber, receipt.ExpiresAt, true)
+				if err != nil {
+					return err
+				}
+				movement.LotID = &lot.ID
+			}
+			if len(receipt.Serials) > 0 || product.IsSerialized() {
+				if err := registerSerials(tx, product, warehouse.ID, receipt.Quantity, receipt.Serials, reference,
+					userName); err != nil {
+					return err
+				}
+			}
+			if err := s.post(tx, movement); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *InventoryService) GetSerials(productId uint32, status string, pageIndex, pageSize int) ([]entity.SerialNumber, int) {
+	return s.stockRepo.GetSerials(productId, status, pageIndex, pageSize)
+}
+
+// GetSoldSerial returns the unit with the given serial number sold last.
+func (s *InventoryService) GetSoldSerial(serial string) *entity.SerialNumber {
+	return s.stockRepo.GetSoldSerial(strings.TrimSpace(serial))
+}
+
+// GetOrderSerials returns the serial numbers of the units sold for an order
+// by product id.
+func (s *InventoryService) GetOrderSerials(orderId string) map[uint32][]string {
+	serials := make(map[uint32][]string)
+	for _, serial := range s.stockRepo.GetSerialsByOrder(orderId) {
+		serials[serial.ProductID] = append(serials[serial.ProductID], serial.Serial)
+	}
+	return serials
+}
+
+// AssignSerials assigns units of serialized products sold for an order to
+// it, serials by product id. The units must be in stock in the warehouses
+// the products were sold from.
+func (s *InventoryService) AssignSerials(orderId string, serials map[uint32][]string) error {
+	reference := entity.OrderReference(orderId)
+	now := time.Now()
+
+	return s.stockRepo.Transaction(func(tx *repo.StockRepository) error {
+		for _, productId := range sortedKeys(serials) {
+			sold := tx.GetNetByReference(productId, reference, entity.StockMovementSale, entity.StockMovementReturn)
+			for _, number := range serials[productId] {
+				serial := tx.GetSerial(productId, strings.TrimSpace(number))
+				if serial == nil {
+					return fmt.Errorf("serial %s not found", number)
+				}
+				if !takeSoldUnit(sold, serial.WarehouseID) {
+					return fmt.Errorf("serial %s is not in a warehouse the order was sold from", serial.Serial)
+				}
+				if err := serial.Sell(orderId, now); err != nil {
+					return err
+				}
+				if err := tx.UpdateSerial(serial); err != nil {
+					return errors.New("unable to update product stock info")
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// takeSoldUnit counts a unit assigned to a serial against the net quantities
+// sold by warehouse, negative for sales. It reports false when no unit sold
+// from the warehouse is left.
+func takeSoldUnit(sold map[uint32]measure.Quantity, warehouseId uint32) bool {
+	if sold[warehouseId] > -measure.Unit {
+		return false
+	}
+	sold[warehouseId] += measure.Unit
+	return true
+}
+
+// Received returns the quantities of products received with the given
+// reference, by product id.
+func (s *InventoryService) Received(productIds []uint32, reference string) map[uint32]measure.Quantity {
+	received := make(map[uint32]measure.Quantity, len(productIds))
+	for _, productId := range productIds {
+		for _, quantity := range s.stockRepo.GetNetByReference(productId, reference, entity.StockMovementReceipt) {
+			received[productId] += quantity
+		}
+	}
+	return received
+}
+
+// ExpireLots writes off the stock of expired lots and returns how many lots
+// were written off. Reservations the stock left can no longer cover are
+// released, newest first; their baskets are reserved again when they are
+// changed or ordered.
+func (s *InventoryService) ExpireLots() (int, error) {
+	count := 0
+	for _, l := range s.stockRepo.GetExpiredLots(time.Now()) {
+		lot := l
+		err := s.stockRepo.Transaction(func(tx *repo.StockRepository) error {
+			if level := tx.GetLevel(lot.ProductID, lot.WarehouseID); level != nil {
+				if short := lot.Quantity - level.Available(); short > 0 {
+					if err := release(tx, tx.GetReservationsIn(lot.ProductID, lot.WarehouseID), short); err != nil {
+						return err
+					}
+				}
+			}
+
+			movement, err := entity.NewStockMovement(lot.ProductID, lot.WarehouseID, entity.StockMovementExpiry,
+				-lot.Quantity, "", fmt.Sprintf("lot %s expired", lot.Number), "")
+			if err != nil {
+				return err
+			}
+			movement.LotID = &lot.ID
+			return s.post(tx, movement)
+		})
+		if err != nil {
+			return count, fmt.Errorf("lot %d: %w", lot.ID, err)
+		}
+		count++
+	}
+	return count, nil
+}
+
+// take posts movements taking quantity units of a product out of a
+// warehouse, first-expired-first-out: from its lots expiring first, then
+// from the units not in any lot. Expired lots are skipped when selling.
+func (s *InventoryService) take(tx *repo.StockRepository, productId uint32, warehouseId uint32, movementType string,
+	quantity measure.Quantity, reference string, note string, userName string, selling bool) error {
+	now := time.Now()
+	for _, l := range tx.GetLotsIn(productId, warehouseId) {
+		lot := l
+		if quantity == 0 {
+			return nil
+		}
+		if selling && lot.IsExpired(now) {
+			continue
+		}
+
+		n := measure.Min(lot.Quantity, quantity)
+		movement, err := entity.NewStockMovement(productId, warehouseId, movementType, -n, reference, note, userName)
+		if err != nil {
+			return err
+		}
+		movement.LotID = &lot.ID
+		if err := s.post(tx, movement); err != nil {
+			return err
+		}
+		quantity -= n
+	}
+	if quantity == 0 {
+		return nil
+	}
+	return s.move(tx, productId, warehouseId, movementType, -quantity, reference, note, userName)
+}
+
+// lot returns the lot of a product in a warehouse with the given number,
+// creating it when it is new and create is set. expiresAt, when given, must
+// match the expiry date of an existing lot.
+func (s *InventoryService) lot(tx *repo.StockRepository, product *entity.Product, warehouseId uint32,
+	number string, expiresAt *time.Time, create bool) (*entity.StockLot, error) {
+	if !product.TracksLots() {
+		return nil, fmt.Errorf("%s is not tracked by lot", product.Sku)
+	}
+	number = strings.TrimSpace(number)
+	if len(number) == 0 {
+		return nil, fmt.Errorf("lot number is required for %s", product.Sku)
+	}
+
+	if lot := tx.GetLot(product.ID, warehouseId, number); lot != nil {
+		if expiresAt != nil && !sameDay(lot.ExpiresAt, *expiresAt) {
+			return nil, fmt.Errorf("lot %s expires on %s", number, lot.ExpiresAt.Local().Format(entity.ExpiryDateLayout))
+		}
+		return lot, nil
+	}
+	if !create {
+		return nil, fmt.Errorf("lot %s not found", number)
+	}
+	if expiresAt == nil {
+		return nil, fmt.Errorf("expiry date is required for new lot %s", number)
+	}
+
+	lot, err := entity.NewStockLot(product.ID, warehouseId, number, *expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if lot.IsExpired(time.Now()) {
+		return nil, fmt.Errorf("lot %s is expired", number)
+	}
+	if err := tx.CreateLot(lot); err != nil {
+		return nil, errors.New("unable to update product stock info")
+	}
+	return lot, nil
+}
+
+func (s *InventoryService) move(tx *repo.StockRepository, productId uint32, warehouseId uint32, movementType string,
+	quantity measure.Quantity, reference string, note string, userName string) error {
+	movement, err := entity.NewStockMovement(productId, warehouseId, movementType, quantity, reference, note, userName)
+	if err != nil {
+		return err
+	}
+	return s.post(tx, movement)
+}
+
+func (s *InventoryService) post(tx *repo.StockRepository, movement *entity.StockMovement) error {
+	err := tx.AddMovement(movement)
+	if errors.Is(err, repo.ErrNegativeStock) {
+		return errors.New("not enough stock")
+	}
+	if err != nil {
+		return errors.New("unable to update product stock info")
+	}
+	s.stockAlertService.Queue(movement.ProductID)
+	return nil
+}
+
+// CheckStock queues a product for the stock alert check, e.g. after its
+// reorder point or status changed.
+func (s *InventoryService) CheckStock(productId uint32) {
+	s.stockAlertService.Queue(productId)
+}
+
+// warehouse returns the warehouse with the given id, or the default
+// warehouse when id is zero.
+func (s *InventoryService) warehouse(id uint32) (*entity.Warehouse, error) {
+	if id == 0 {
+		if warehouse := s.warehouseRepo.GetDefault(); warehouse != nil {
+			return warehouse, nil
+		}
+		return nil, errors.New("no default warehouse")
+	}
+
+	if warehouse := s.warehouseRepo.GetById(id); warehouse != nil {
+		return warehouse, nil
+	}
+	return nil, errors.New("warehouse not found")
+}
+
+// registerSerials registers the serials of quantity units of a product
+// received into a warehouse.
+func registerSerials(tx *repo.StockRepository, product *entity.Product, warehouseId uint32, quantity measure.Quantity,
+	serials []string, reference string, userName string) error {
+	if !quantity.IsWhole() || len(serials) != quantity.Whole() {
+		return fmt.Errorf("%s serial numbers are required for %s", quantity, product.Sku)
+	}
+
+	for _, number := range serials {
+		serial, err := entity.NewSerialNumber(product, warehouseId, number, reference, userName)
+		if err != nil {
+			return err
+		}
+		if tx.GetSerial(product.ID, serial.Serial) != nil {
+			return fmt.Errorf("serial %s is already registered", serial.Serial)
+		}
+		if err := tx.CreateSerial(serial); err != nil {
+			return errors.New("unable to update product stock info")
+		}
+	}
+	return nil
+}
+
+// removeSerials takes the units of a product with the given serials out of
+// stock in a warehouse.
+func removeSerials(tx *repo.StockRepository, product *entity.Product, warehouseId uint32, quantity measure.Quantity,
+	serials []string) error {
+	if !quantity.IsWhole() || len(serials) != quantity.Whole() {
+		return fmt.Errorf("%s serial numbers are required for %s", quantity, product.Sku)
+	}
+
+	for _, number := range serials {
+		serial := tx.GetSerial(product.ID, strings.TrimSpace(number))
+		if serial == nil || serial.WarehouseID != warehouseId {
+			return fmt.Errorf("serial %s not found", number)
+		}
+		if err := serial.Remove(); err != nil {
+			return err
+		}
+		if err := tx.UpdateSerial(serial); err != nil {
+			return errors.New("unable to update product stock info")
+		}
+	}
+	return nil
+}
+
+// release gives back quantity units of the given reservations, newest first.
+func release(tx *repo.StockRepository, reservations []entity.StockReservation, quantity measure.Quantity) error {
+	for i := range reservations {
+		n := measure.Min(reservations[i].Quantity, quantity)
+		if n <= 0 {
+			break
+		}
+		if err := tx.ReleaseReservation(&reservations[i], n); err != nil {
+			return err
+		}
+		quantity -= n
+	}
+	return nil
+}
+
+type allocation struct {
+	warehouseId uint32
+	quantity    measure.Quantity
+}
+
+// allocate picks the warehouses to take quantity units of a product from:
+// the default warehouse first, then the ones with the most available stock.
+// The result covers less than quantity when there is not enough stock.
+func (s *InventoryService) allocate(productId uint32, quantity measure.Quantity) []allocation {
+	levels := s.stockRepo.GetLevels(productId)
+	sort.SliceStable(levels, func(i, j int) bool {
+		di := levels[i].Warehouse != nil && levels[i].Warehouse.IsDefault
+		dj := levels[j].Warehouse != nil && levels[j].Warehouse.IsDefault
+		if di != dj {
+			return di
+		}
+		return levels[i].Available() > levels[j].Available()
+	})
+
+	var allocations []allocation
+	for _, level := range levels {
+		n := measure.Min(level.Available(), quantity-allocated(allocations))
+		if n <= 0 {
+			continue
+		}
+		allocations = append(allocations, allocation{level.WarehouseID, n})
+	}
+	return allocations
+}
+
+func allocated(allocations []allocation) measure.Quantity {
+	var total measure.Quantity
+	for _, a := range allocations {
+		total += a.quantity
+	}
+	return total
+}
+
+func sortedIds(quantities map[uint32]measure.Quantity) []uint32 {
+	ids := make([]uint32, 0, len(quantities))
+	for id := range quantities {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func sortedKeys(serials map[uint32][]string) []uint32 {
+	ids := make([]uint32, 0, len(serials))
+	for id := range serials {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Local().Format(entity.ExpiryDateLayout) == b.Local().Format(entity.ExpiryDateLayout)
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/bestetufan/beste-store/pkg/measure"
+)
+
+func TestTakeSoldUnit(t *testing.T) {
+	const warehouseA, warehouseB = 1, 2
+
+	tests := []struct {
+		name       string
+		sold       map[uint32]measure.Quantity
+		warehouses []uint32
+		want       []bool
+	}{
+		{
+			name:       "one unit from each warehouse",
+			sold:       map[uint32]measure.Quantity{warehouseA: -measure.Unit, warehouseB: -measure.Unit},
+			warehouses: []uint32{warehouseA, warehouseB},
+			want:       []bool{true, true},
+		},
+		{
+			name:       "two serials in a warehouse one unit was sold from",
+			sold:       map[uint32]measure.Quantity{warehouseA: -measure.Unit, warehouseB: -measure.Unit},
+			warehouses: []uint32{warehouseA, warehouseA},
+			want:       []bool{true, false},
+		},
+		{
+			name:       "several units from a warehouse",
+			sold:       map[uint32]measure.Quantity{warehouseA: -3 * measure.Unit},
+			warehouses: []uint32{warehouseA, warehouseA, warehouseA, warehouseA},
+			want:       []bool{true, true, true, false},
+		},
+		{
+			name:       "warehouse nothing was sold from",
+			sold:       map[uint32]measure.Quantity{warehouseA: -measure.Unit},
+			warehouses: []uint32{warehouseB},
+			want:       []bool{false},
+		},
+		{
+			name:       "units returned",
+			sold:       map[uint32]measure.Quantity{},
+			warehouses: []uint32{warehouseA},
+			want:       []bool{false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, warehouseId := range tt.warehouses {
+				if got := takeSoldUnit(tt.sold, warehouseId); got != tt.want[i] {
+					t.Errorf("serial %d in warehouse %d: takeSoldUnit = %v, want %v", i+1, warehouseId, got,
+						tt.want[i])
+				}
+			}
+		})
+	}
+}
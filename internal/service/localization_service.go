@@ -0,0 +1,255 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"github.com/bestetufan/beste-store/internal/domain/repo"
+	"github.com/bestetufan/beste-store/pkg/locale"
+)
+
+// LocalizationService maintains the translations of products and categories
+// and translates them into the locale a customer asked for.
+//
+// Names stored on the records themselves are in the default locale of the
+// store. Translations can be added in the other supported locales; a field
+// with no translation in a locale is shown in the default locale.
+type LocalizationService struct {
+	translationRepo repo.TranslationRepository
+	productRepo     repo.ProductRepository
+	categoryRepo    repo.CategoryRepository
+	locale          string
+	locales         []string
+}
+
+// NewLocalizationService creates a service for the default locale and the
+// comma separated supported locales. Invalid tags are ignored; the default
+// locale is always supported.
+func NewLocalizationService(tr repo.TranslationRepository, pr repo.ProductRepository, cr repo.CategoryRepository,
+	defaultLocale string, locales string) *LocalizationService {
+	if tag, err := locale.Parse(defaultLocale); err == nil {
+		defaultLocale = tag
+	}
+	supported := []string{defaultLocale}
+	for _, tag := range strings.Split(locales, ",") {
+		tag, err := locale.Parse(tag)
+		if err != nil || containsLocale(supported, tag) {
+			continue
+		}
+		supported = append(supported, tag)
+	}
+
+	return &LocalizationService{
+		translationRepo: tr,
+		productRepo:     pr,
+		categoryRepo:    cr,
+		locale:          defaultLocale,
+		locales:         supported,
+	}
+}
+
+// Locales returns the supported locales, the default one first.
+func (s *LocalizationService) Locales() []string {
+	return s.locales
+}
+
+// Negotiate returns the supported locale best matching an Accept-Language
+// header, the default locale when none does.
+func (s *LocalizationService) Negotiate(acceptLanguage string) string {
+	return locale.Negotiate(acceptLanguage, s.locales, s.locale)
+}
+
+// GetTranslations returns the translations of a product or category.
+func (s *LocalizationService) GetTranslations(entityType string, id uint32) ([]entity.Translation, error) {
+	if err := s.checkEntity(entityType, id); err != nil {
+		return nil, err
+	}
+	return s.translationRepo.GetAll(entityType, id), nil
+}
+
+// SetTranslation replaces the translations of a product or category in a
+// locale with the given field values.
+func (s *LocalizationService) SetTranslation(entityType string, id uint32, tag string, fields map[string]string,
+	userName string) ([]entity.Translation, error) {
+	if err := s.checkEntity(entityType, id); err != nil {
+		return nil, err
+	}
+	tag, err := s.translationLocale(tag)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, errors.New("no fields given")
+	}
+
+	translations := make([]*entity.Translation, 0, len(fields))
+	for field, value := range fields {
+		translation, err := entity.NewTranslation(entityType, id, tag, field, value, userName)
+		if err != nil {
+			return nil, err
+		}
+		translations = append(translations, translation)
+	}
+	if err := s.translationRepo.Replace(entityType, id, tag, translations); err != nil {
+		return nil, errors.New("an unknown error occurred during operation")
+	}
+
+	result := make([]entity.Translation, 0, len(translations))
+	for _, translation := range translations {
+		result = append(result, *translation)
+	}
+	return result, nil
+}
+
+// DeleteTranslation removes the translations of a product or category in a
+// locale.
+func (s *LocalizationService) DeleteTranslation(entityType string, id uint32, tag string) error {
+	if err := s.checkEntity(entityType, id); err != nil {
+		return err
+	}
+	tag, err := s.translationLocale(tag)
+	if err != nil {
+		return err
+	}
+
+	count, err := s.translationRepo.Delete(entityType, id, tag)
+	if err != nil {
+		return errors.New("an unknown error occurred during operation")
+	}
+	if count == 0 {
+		return errors.New("translation not found")
+	}
+	return nil
+}
+
+// TranslateProduct translates the name of a product, of its category and of
+// its bundle components in place. The default locale leaves them unchanged.
+func (s *LocalizationService) TranslateProduct(product *entity.Product, tag string) {
+	s.translateProducts([]*entity.Product{product}, tag)
+}
+
+// TranslateProducts is TranslateProduct for a list of products.
+func (s *LocalizationService) TranslateProducts(products []entity.Product, tag string) {
+	list := make([]*entity.Product, 0, len(products))
+	for i := range products {
+		list = append(list, &products[i])
+	}
+	s.translateProducts(list, tag)
+}
+
+// TranslateCategory translates the name of a category in place. The default
+// locale leaves it unchanged.
+func (s *LocalizationService) TranslateCategory(category *entity.Category, tag string) {
+	s.translateCategories([]*entity.Category{category}, tag)
+}
+
+// TranslateCategories is TranslateCategory for a list of categories.
+func (s *LocalizationService) TranslateCategories(categories []entity.Category, tag string) {
+	list := make([]*entity.Category, 0, len(categories))
+	for i := range categories {
+		list = append(list, &categories[i])
+	}
+	s.translateCategories(list, tag)
+}
+
+func (s *LocalizationService) translateProducts(products []*entity.Product, tag string) {
+	if len(tag) == 0 || tag == s.locale || len(products) == 0 {
+		return
+	}
+
+	var all []*entity.Product
+	categories := make([]*entity.Category, 0, len(products))
+	for _, product := range products {
+		all = append(all, product)
+		categories = append(categories, &product.Category)
+		for _, component := range product.Components {
+			if component.Product != nil {
+				all = append(all, component.Product)
+			}
+		}
+	}
+
+	ids := make([]uint32, 0, len(all))
+	for _, product := range all {
+		ids = append(ids, product.ID)
+	}
+	names := translatedNames(s.translationRepo.GetByEntities(entity.TranslationProduct, ids, tag))
+	for _, product := range all {
+		if name, ok := names[product.ID]; ok {
+			product.Name = name
+		}
+	}
+
+	s.translateCategories(categories, tag)
+}
+
+func (s *LocalizationService) translateCategories(categories []*entity.Category, tag string) {
+	if len(tag) == 0 || tag == s.locale || len(categories) == 0 {
+		return
+	}
+
+	ids := make([]uint32, 0, len(categories))
+	for _, category := range categories {
+		ids = append(ids, category.ID)
+	}
+	names := translatedNames(s.translationRepo.GetByEntities(entity.TranslationCategory, ids, tag))
+	for _, category := range categories {
+		if name, ok := names[category.ID]; ok {
+			category.Name = name
+		}
+	}
+}
+
+func (s *LocalizationService) checkEntity(entityType string, id uint32) error {
+	switch entityType {
+	case entity.TranslationProduct:
+		if s.productRepo.GetById(id) == nil {
+			return errors.New("product not found")
+		}
+	case entity.TranslationCategory:
+		if s.categoryRepo.GetById(id) == nil {
+			return errors.New("category not found")
+		}
+	default:
+		return errors.New("unknown translation type")
+	}
+	return nil
+}
+
+// translationLocale checks that translations can be kept in a locale: it
+// must be supported and not the default one, whose values are kept on the
+// records.
+func (s *LocalizationService) translationLocale(tag string) (string, error) {
+	tag, err := locale.Parse(tag)
+	if err != nil {
+		return "", err
+	}
+	if tag == s.locale {
+		return "", fmt.Errorf("%s is the default locale", tag)
+	}
+	if !containsLocale(s.locales, tag) {
+		return "", fmt.Errorf("unsupported locale: %s", tag)
+	}
+	return tag, nil
+}
+
+func translatedNames(translations []entity.Translation) map[uint32]string {
+	names := make(map[uint32]string, len(translations))
+	for _, translation := range translations {
+		if translation.Field == entity.TranslationFieldName {
+			names[translation.EntityID] = translation.Value
+		}
+	}
+	return names
+}
+
+func containsLocale(locales []string, tag string) bool {
+	for _, l := range locales {
+		if l == tag {
+			return true
+		}
+	}
+	return false
+}
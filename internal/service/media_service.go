@@ -0,0 +1,239 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+
+	_ "image/gif"
+
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"github.com/bestetufan/beste-store/internal/domain/repo"
+	"github.com/bestetufan/beste-store/pkg/storage"
+	"github.com/bestetufan/beste-store/pkg/thumbnail"
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultThumbnailSize is the thumbnail size used when none is configured.
+	defaultThumbnailSize = 320
+	// maxImagePixels bounds the dimensions of uploaded images, which are
+	// decoded in memory to make thumbnails at 4 bytes per pixel or more.
+	maxImagePixels = 25000000
+)
+
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+type MediaService struct {
+	storage       storage.Storage
+	imageRepo     repo.ProductImageRepository
+	productRepo   repo.ProductRepository
+	maxSize       int64
+	thumbnailSize int
+}
+
+func NewMediaService(st storage.Storage, ir repo.ProductImageRepository, pr repo.ProductRepository,
+	maxSize int64, thumbnailSize int) *MediaService {
+	if thumbnailSize < 1 {
+		thumbnailSize = defaultThumbnailSize
+	}
+	return &MediaService{
+		storage:       st,
+		imageRepo:     ir,
+		productRepo:   pr,
+		maxSize:       maxSize,
+		thumbnailSize: thumbnailSize,
+	}
+}
+
+func (s *MediaService) URL(key string) string {
+	return s.storage.URL(key)
+}
+
+// UploadProductImage validates an uploaded image, stores it together with a
+// thumbnail and appends it to the product gallery. The first image of a
+// product becomes its primary image.
+func (s *MediaService) UploadProductImage(productId uint32, file io.Reader) (*entity.ProductImage, error) {
+	product := s.productRepo.GetById(productId)
+	if product == nil {
+		return nil, errors.New("product not found")
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, s.maxSize+1))
+	if err != nil {
+		return nil, errors.New("unable to read file")
+	}
+	if int64(len(data)) > s.maxSize {
+		return nil, fmt.Errorf("file is larger than %d bytes", s.maxSize)
+	}
+
+	contentType := http.DetectContentType(data)
+	ext, ok := imageExtensions[contentType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported file type: %s", contentType)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || config.Width < 1 || config.Height < 1 {
+		return nil, errors.New("unable to decode image")
+	}
+	if int64(config.Width)*int64(config.Height) > maxImagePixels {
+		return nil, fmt.Errorf("image is larger than %d pixels", maxImagePixels)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.New("unable to decode image")
+	}
+
+	var thumb bytes.Buffer
+	thumbContentType := "image/png"
+	if contentType == "image/jpeg" {
+		thumbContentType = contentType
+		err = jpeg.Encode(&thumb, thumbnail.Resize(img, s.thumbnailSize), &jpeg.Options{Quality: 85})
+	} else {
+		err = png.Encode(&thumb, thumbnail.Resize(img, s.thumbnailSize))
+	}
+	if err != nil {
+		return nil, errors.New("unable to create thumbnail")
+	}
+
+	name := uuid.NewString()
+	key := fmt.Sprintf("products/%d/%s%s", productId, name, ext)
+	thumbKey := fmt.Sprintf("products/%d/%s_thumb%s", productId, name, imageExtensions[thumbContentType])
+
+	if err := s.storage.Put(key, bytes.NewReader(data), contentType); err != nil {
+		return nil, errors.New("unable to store image")
+	}
+	if err := s.storage.Put(thumbKey, bytes.NewReader(thumb.Bytes()), thumbContentType); err != nil {
+		_ = s.storage.Delete(key)
+		return nil, errors.New("unable to store thumbnail")
+	}
+
+	bounds := img.Bounds()
+	productImage, _ := entity.NewProductImage(productId, key, thumbKey, contentType,
+		int64(len(data)), bounds.Dx(), bounds.Dy())
+	gallery := s.imageRepo.GetAllByProductId(productId)
+	productImage.Position = len(gallery)
+	productImage.IsPrimary = len(gallery) == 0
+
+	if err := s.imageRepo.Create(productImage); err != nil {
+		_ = s.storage.Delete(key)
+		_ = s.storage.Delete(thumbKey)
+		return nil, errors.New("unable to save image")
+	}
+
+	return productImage, nil
+}
+
+func (s *MediaService) DeleteProductImage(productId uint32, imageId uint32) error {
+	productImage := s.imageRepo.GetById(imageId)
+	if productImage == nil || productImage.ProductID != productId {
+		return errors.New("image not found")
+	}
+
+	if err := s.imageRepo.DeleteById(imageId); err != nil {
+		return errors.New("unable to delete image")
+	}
+
+	_ = s.storage.Delete(productImage.Key)
+	_ = s.storage.Delete(productImage.ThumbnailKey)
+
+	gallery := s.imageRepo.GetAllByProductId(productId)
+	primaryId := uint32(0)
+	if !productImage.IsPrimary {
+		for _, v := range gallery {
+			if v.IsPrimary {
+				primaryId = v.ID
+			}
+		}
+	}
+
+	return s.saveGallery(gallery, primaryId)
+}
+
+// DeleteProductImages removes the whole gallery of a product, files included.
+func (s *MediaService) DeleteProductImages(productId uint32) error {
+	for _, productImage := range s.imageRepo.GetAllByProductId(productId) {
+		if err := s.imageRepo.DeleteById(productImage.ID); err != nil {
+			return errors.New("unable to delete image")
+		}
+
+		_ = s.storage.Delete(productImage.Key)
+		_ = s.storage.Delete(productImage.ThumbnailKey)
+	}
+
+	return nil
+}
+
+// ReorderProductImages sets the gallery order. imageIds must list every image
+// of the product exactly once.
+func (s *MediaService) ReorderProductImages(productId uint32, imageIds []uint32) error {
+	gallery := s.imageRepo.GetAllByProductId(productId)
+	if len(imageIds) != len(gallery) {
+		return errors.New("image list must contain all product images")
+	}
+
+	byId := make(map[uint32]*entity.ProductImage, len(gallery))
+	primaryId := uint32(0)
+	for _, v := range gallery {
+		byId[v.ID] = v
+		if v.IsPrimary {
+			primaryId = v.ID
+		}
+	}
+
+	ordered := make([]*entity.ProductImage, 0, len(gallery))
+	for _, id := range imageIds {
+		v, ok := byId[id]
+		if !ok {
+			return errors.New("image list must contain all product images")
+		}
+		ordered = append(ordered, v)
+		delete(byId, id)
+	}
+
+	return s.saveGallery(ordered, primaryId)
+}
+
+func (s *MediaService) SetPrimaryProductImage(productId uint32, imageId uint32) error {
+	productImage := s.imageRepo.GetById(imageId)
+	if productImage == nil || productImage.ProductID != productId {
+		return errors.New("image not found")
+	}
+
+	return s.saveGallery(s.imageRepo.GetAllByProductId(productId), imageId)
+}
+
+// saveGallery renumbers positions in slice order and marks the image with
+// primaryId as primary, falling back to the first image.
+func (s *MediaService) saveGallery(gallery []*entity.ProductImage, primaryId uint32) error {
+	if len(gallery) == 0 {
+		return nil
+	}
+
+	found := false
+	for i, v := range gallery {
+		v.Position = i
+		v.IsPrimary = v.ID == primaryId
+		found = found || v.IsPrimary
+	}
+	if !found {
+		gallery[0].IsPrimary = true
+	}
+
+	if err := s.imageRepo.SaveGallery(gallery); err != nil {
+		return errors.New("unable to update image gallery")
+	}
+
+	return nil
+}
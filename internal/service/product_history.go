@@ -0,0 +1,110 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"github.com/bestetufan/beste-store/internal/domain/repo"
+	"github.com/bestetufan/beste-store/pkg/money"
+)
+
+// PriceHistory lists the list prices and sale prices of a product within a
+// period. The first list price may predate From: it is the price that was in
+// effect at From.
+type PriceHistory struct {
+	ProductID    uint32                `json:"product_id"`
+	From         time.Time             `json:"from"`
+	Currency     string                `json:"currency"`
+	CurrentPrice money.Money           `json:"current_price" swaggertype:"number"`
+	LowestPrice  money.Money           `json:"lowest_price" swaggertype:"number"`
+	Prices       []entity.ProductPrice `json:"prices"`
+	SalePrices   []*entity.SalePrice   `json:"sale_prices"`
+}
+
+func (s *StoreService) GetProductHistory(productId uint32, pageIndex, pageSize int) ([]entity.ProductRevision, int) {
+	return s.productRepo.GetRevisions(productId, pageIndex, pageSize)
+}
+
+// GetPriceHistory returns the prices of a product during the last given
+// number of days. LowestPrice is the prior price a discount is announced
+// against under "lowest price in the last 30 days" rules: the lowest list or
+// sale price in effect during the given number of days before the active sale
+// price started, the active sale price itself left out. Without an active sale
+// price it is the lowest price of the period.
+func (s *StoreService) GetPriceHistory(productId uint32, days int) (*PriceHistory, error) {
+	if days <= 0 {
+		return nil, errors.New("days must be greater than zero")
+	}
+
+	product := s.productRepo.GetById(productId)
+	if product == nil {
+		return nil, errors.New("product not found")
+	}
+
+	now := time.Now()
+	history := &PriceHistory{
+		ProductID:    productId,
+		From:         now.AddDate(0, 0, -days),
+		Currency:     product.UnitPrice.Currency,
+		CurrentPrice: product.EffectivePrice(),
+		LowestPrice:  product.UnitPrice,
+	}
+	history.Prices = s.productRepo.GetPrices(productId, history.From)
+	history.SalePrices = s.salePriceRepo.GetAllBetween(productId, history.From, now)
+
+	prices, salePrices, until := history.Prices, history.SalePrices, now
+	if product.SalePrice != nil {
+		until = product.SalePrice.StartsAt
+		from := until.AddDate(0, 0, -days)
+		prices = s.productRepo.GetPrices(productId, from)
+		salePrices = s.salePriceRepo.GetAllBetween(productId, from, until)
+	}
+
+	// Prices in another currency, from before a currency change, are not
+	// comparable and are left out of LowestPrice.
+	found := false
+	lower := func(price money.Money) {
+		if price.Currency != history.Currency {
+			return
+		}
+		if c, _ := price.Cmp(history.LowestPrice); !found || c < 0 {
+			history.LowestPrice, found = price, true
+		}
+	}
+	for _, p := range prices {
+		if p.CreatedAt.Before(until) {
+			lower(p.Price)
+		}
+	}
+	for _, p := range salePrices {
+		if product.SalePrice == nil || p.ID != product.SalePrice.ID {
+			lower(p.Price)
+		}
+	}
+
+	return history, nil
+}
+
+// recordProductChange writes a revision with the differences between two
+// versions of a product, and a price history entry when the price is new or
+// changed. before is nil for created products. Updates that change nothing
+// are not recorded.
+func recordProductChange(r *repo.ProductRepository, userName string, action string, before, after *entity.Product) error {
+	changes := entity.ProductChanges(before, after)
+	if action == entity.RevisionActionUpdated && len(changes) == 0 {
+		return nil
+	}
+
+	if err := r.AddRevision(entity.NewProductRevision(after.ID, action, userName, changes)); err != nil {
+		return errors.New("unable to save product history")
+	}
+
+	if before == nil || before.UnitPrice != after.UnitPrice {
+		if err := r.AddPrice(entity.NewProductPrice(after.ID, after.UnitPrice, userName)); err != nil {
+			return errors.New("unable to save price history")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,407 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"github.com/bestetufan/beste-store/internal/domain/repo"
+	"github.com/bestetufan/beste-store/pkg/measure"
+	"github.com/bestetufan/beste-store/pkg/spreadsheet"
+)
+
+const productImportAttributePrefix = "attr."
+
+var productImportColumns = []string{"name", "sku", "gtin", "unit_price", "currency", "unit", "quantity_step",
+	"min_quantity", "quantity", "reorder_point", "reorder_quantity", "category", "status", "publish_at"}
+
+type productImportItem struct {
+	product    *entity.Product
+	before     *entity.Product
+	attributes []*entity.ProductAttribute
+	replace    bool
+	create     bool
+	// stock is set when the quantity is given; it is posted to the stock
+	// ledger once the product is saved.
+	stock bool
+}
+
+// ImportProducts creates or updates products from spreadsheet rows, matching
+// existing products by SKU. The first row is the header; mapping maps column
+// names (name, sku, gtin, unit_price, currency, unit, quantity_step,
+// min_quantity, quantity, reorder_point, reorder_quantity, category, status,
+// publish_at) to header titles when they differ. Prices without currency are
+// in the currency of the product, or in the default currency for new
+// products. New products are drafts unless a status is given; the status of
+// existing products is not changed by imports. Columns titled "attr.<code>"
+// carry attribute values.
+// In dry-run mode nothing is written. In atomic mode either every row is
+// written or, when any row is invalid, none is.
+func (s *StoreService) ImportProducts(rows []spreadsheet.Row, mapping map[string]string, dryRun bool, atomic bool,
+	userName string) (*ImportReport, error) {
+	if len(rows) == 0 {
+		return nil, errors.New("file is empty")
+	}
+
+	columns, attributeColumns, err := productImportHeader(rows[0], mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ImportReport{DryRun: dryRun, Atomic: atomic}
+	items := make([]*productImportItem, 0, len(rows)-1)
+	seen := make(map[string]int)
+	seenGTIN := make(map[string]int)
+
+	for _, row := range rows[1:] {
+		if row.IsEmpty() {
+			continue
+		}
+
+		sku := row.Get(columns["sku"])
+		if line, ok := seen[strings.ToLower(sku)]; ok && len(sku) > 0 {
+			report.add(ImportRow{Line: row.Line, Key: sku, Action: ImportActionInvalid,
+				Reason: fmt.Sprintf("duplicate sku, first seen on line %d", line)})
+			items = append(items, nil)
+			continue
+		}
+		seen[strings.ToLower(sku)] = row.Line
+
+		item, err := s.parseProductImportRow(row, columns, attributeColumns)
+		if err != nil {
+			report.add(ImportRow{Line: row.Line, Key: sku, Action: ImportActionInvalid, Reason: err.Error()})
+			items = append(items, nil)
+			continue
+		}
+		if gtin := item.product.GTIN; gtin != nil {
+			if line, ok := seenGTIN[*gtin]; ok {
+				report.add(ImportRow{Line: row.Line, Key: sku, Action: ImportActionInvalid,
+					Reason: fmt.Sprintf("duplicate gtin, first seen on line %d", line)})
+				items = append(items, nil)
+				continue
+			}
+			seenGTIN[*gtin] = row.Line
+		}
+
+		action := ImportActionUpdated
+		if item.create {
+			action = ImportActionCreated
+		}
+		report.add(ImportRow{Line: row.Line, Key: sku, Action: action})
+		items = append(items, item)
+	}
+
+	if dryRun || (atomic && report.Invalid > 0) {
+		return report, nil
+	}
+
+	if atomic {
+		// The stock is moved in the same transaction, so that no product is
+		// saved when the stock of one can not be adjusted.
+		failed := -1
+		err := s.productRepo.Transaction(func(tx *repo.ProductRepository) error {
+			inventoryService := s.inventoryService
+			inventoryService.productRepo, inventoryService.stockRepo = *tx, *tx.Stock()
+
+			for i, item := range items {
+				if err := saveProductImportItem(tx, item, userName); err != nil {
+					failed = i
+					return err
+				}
+				if err := saveProductImportStock(&inventoryService, item, userName); err != nil {
+					failed = i
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			report.fail(failed, err.Error())
+			return report, nil
+		}
+		report.Committed = true
+
+		for _, item := range items {
+			if item != nil {
+				s.inventoryService.CheckStock(item.product.ID)
+			}
+		}
+		return report, nil
+	}
+
+	for i, item := range items {
+		if item == nil {
+			continue
+		}
+		if err := saveProductImportItem(&s.productRepo, item, userName); err != nil {
+			report.fail(i, err.Error())
+			continue
+		}
+		s.inventoryService.CheckStock(item.product.ID)
+		if err := saveProductImportStock(&s.inventoryService, item, userName); err != nil {
+			report.fail(i, err.Error())
+		}
+	}
+	report.Committed = true
+
+	return report, nil
+}
+
+func (s *StoreService) parseProductImportRow(row spreadsheet.Row, columns map[string]int,
+	attributeColumns map[string]int) (*productImportItem, error) {
+	sku := row.Get(columns["sku"])
+	if len(sku) == 0 {
+		return nil, errors.New("sku is required")
+	}
+
+	item := &productImportItem{product: s.productRepo.GetBySKU(sku)}
+	if item.product == nil && s.productRepo.GetDeletedBySKU(sku) != nil {
+		return nil, errors.New("product with same sku is in the trash")
+	}
+	if item.product == nil {
+		item.create = true
+		item.product = &entity.Product{Sku: sku, Status: entity.ProductStatusDraft, Unit: entity.UnitPiece,
+			QuantityStep: measure.Unit, MinQuantity: measure.Unit}
+	} else {
+		before := *item.product
+		item.before = &before
+	}
+	product := item.product
+
+	if v := cell(row, columns, "name"); len(v) > 0 {
+		product.Name = v
+	} else if item.create {
+		return nil, errors.New("name is required")
+	}
+
+	if v := cell(row, columns, "gtin"); len(v) > 0 {
+		if err := product.SetGTIN(v); err != nil {
+			return nil, err
+		}
+		if err := s.checkGTIN(product); err != nil {
+			return nil, err
+		}
+	}
+
+	currency := cell(row, columns, "currency")
+	if v := cell(row, columns, "unit_price"); len(v) > 0 {
+		if len(currency) == 0 {
+			currency = product.UnitPrice.Currency
+		}
+		price, err := s.ParsePrice(v, currency)
+		if err != nil {
+			return nil, fmt.Errorf("invalid unit_price: %q: %w", v, err)
+		}
+		if !item.create && price.Currency != item.before.UnitPrice.Currency && s.hasPendingSalePrices(product.ID) {
+			return nil, errors.New("currency can not be changed while sale prices are scheduled")
+		}
+		product.UnitPrice = price
+		product.Currency = price.Currency
+	} else if item.create {
+		return nil, errors.New("unit_price is required")
+	} else if len(currency) > 0 {
+		return nil, errors.New("unit_price is required when currency is given")
+	}
+
+	unit, step, min := product.Unit, product.QuantityStep, product.MinQuantity
+	if v := strings.ToLower(cell(row, columns, "unit")); len(v) > 0 {
+		unit = v
+	}
+	if v := cell(row, columns, "quantity_step"); len(v) > 0 {
+		n, err := measure.Parse(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quantity_step: %q", v)
+		}
+		step = n
+	}
+	if v := cell(row, columns, "min_quantity"); len(v) > 0 {
+		n, err := measure.Parse(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid min_quantity: %q", v)
+		}
+		min = n
+	}
+	if err := product.SetUnit(unit, step, min); err != nil {
+		return nil, err
+	}
+	if product.IsSerialized() && product.Unit != entity.UnitPiece {
+		return nil, errors.New("serialized products can only be sold by the piece")
+	}
+
+	if v := cell(row, columns, "quantity"); len(v) > 0 {
+		quantity, err := measure.Parse(v)
+		if err != nil || quantity < 0 {
+			return nil, fmt.Errorf("invalid quantity: %q", v)
+		}
+		if err := product.CheckStockQuantity(quantity); err != nil {
+			return nil, err
+		}
+		product.Quantity = quantity
+		item.stock = true
+	}
+
+	point, quantity := product.ReorderPoint, product.ReorderQuantity
+	if v := cell(row, columns, "reorder_point"); len(v) > 0 {
+		n, err := measure.Parse(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid reorder_point: %q", v)
+		}
+		point = n
+	}
+	if v := cell(row, columns, "reorder_quantity"); len(v) > 0 {
+		n, err := measure.Parse(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid reorder_quantity: %q", v)
+		}
+		quantity = n
+	}
+	if err := product.SetReorder(point, quantity); err != nil {
+		return nil, err
+	}
+
+	if v := cell(row, columns, "category"); len(v) > 0 {
+		category := s.findCategory(v)
+		if category == nil {
+			return nil, fmt.Errorf("category not found: %q", v)
+		}
+		product.CategoryID = category.ID
+		product.Category = *category
+	} else if item.create {
+		return nil, errors.New("category is required")
+	}
+
+	if item.stock {
+		var stock measure.Quantity
+		if !item.create {
+			stock = item.before.Quantity
+		}
+		if err := checkAdjustTo(product, product.TracksLots(), product.Quantity-stock); err != nil {
+			return nil, err
+		}
+	}
+
+	if status := strings.ToLower(cell(row, columns, "status")); len(status) > 0 && status != product.Status {
+		if !item.create {
+			return nil, errors.New("status of existing products can not be changed by import")
+		}
+		var publishAt *time.Time
+		if v := cell(row, columns, "publish_at"); len(v) > 0 {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid publish_at: %q", v)
+			}
+			publishAt = &t
+		}
+		if err := product.SetStatus(status, publishAt, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	// Attributes of another category do not apply; a product moved to another
+	// category gets the attributes of its row, checked against the new one.
+	if item.create || product.CategoryID != item.before.CategoryID || len(attributeColumns) > 0 {
+		values := make(map[string]string)
+		for code, i := range attributeColumns {
+			if v := row.Get(i); len(v) > 0 {
+				values[code] = v
+			}
+		}
+		attributes, err := s.buildProductAttributes(product.CategoryID, values)
+		if err != nil {
+			return nil, err
+		}
+		item.attributes = attributes
+		item.replace = true
+	}
+
+	return item, nil
+}
+
+// findCategory looks a category up by id when the value is numeric and by
+// name otherwise.
+func (s *StoreService) findCategory(value string) *entity.Category {
+	if id, err := strconv.ParseUint(value, 10, 32); err == nil {
+		if category := s.categoryRepo.GetById(uint32(id)); category != nil {
+			return category
+		}
+	}
+	return s.categoryRepo.GetByName(value)
+}
+
+func saveProductImportItem(r *repo.ProductRepository, item *productImportItem, userName string) error {
+	if item == nil {
+		return nil
+	}
+
+	if item.create {
+		if err := r.Create(item.product); err != nil {
+			return errors.New("unable to create product")
+		}
+	} else if err := r.Update(item.product); err != nil {
+		return errors.New("unable to update product")
+	}
+
+	if item.replace {
+		if err := r.SetAttributes(item.product.ID, item.attributes); err != nil {
+			return errors.New("unable to save product attributes")
+		}
+		item.product.Attributes = item.attributes
+	}
+
+	if item.create {
+		return recordProductChange(r, userName, entity.RevisionActionCreated, nil, item.product)
+	}
+	return recordProductChange(r, userName, entity.RevisionActionUpdated, item.before, item.product)
+}
+
+// saveProductImportStock adjusts the stock of a saved product to the
+// imported quantity with inventoryService.
+func saveProductImportStock(inventoryService *InventoryService, item *productImportItem, userName string) error {
+	if item == nil || !item.stock {
+		return nil
+	}
+	return inventoryService.AdjustTo(item.product.ID, item.product.Quantity, "import", userName)
+}
+
+// productImportHeader resolves the column index of every known field and of
+// every attribute column from the header row.
+func productImportHeader(header spreadsheet.Row, mapping map[string]string) (map[string]int, map[string]int, error) {
+	titles := make(map[string]int, len(header.Cells))
+	for i := range header.Cells {
+		titles[strings.ToLower(header.Get(i))] = i
+	}
+
+	columns := make(map[string]int)
+	for _, field := range productImportColumns {
+		title := field
+		if mapped, ok := mapping[field]; ok {
+			title = mapped
+		}
+		if i, ok := titles[strings.ToLower(title)]; ok {
+			columns[field] = i
+		}
+	}
+	if _, ok := columns["sku"]; !ok {
+		return nil, nil, errors.New("sku column not found in header")
+	}
+
+	attributeColumns := make(map[string]int)
+	for title, i := range titles {
+		if strings.HasPrefix(title, productImportAttributePrefix) {
+			attributeColumns[strings.TrimPrefix(title, productImportAttributePrefix)] = i
+		}
+	}
+
+	return columns, attributeColumns, nil
+}
+
+func cell(row spreadsheet.Row, columns map[string]int, field string) string {
+	i, ok := columns[field]
+	if !ok {
+		return ""
+	}
+	return row.Get(i)
+}
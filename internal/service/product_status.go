@@ -0,0 +1,67 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+)
+
+// PublisherUserName is recorded in the history of products published by the
+// scheduler.
+const PublisherUserName = "scheduler"
+
+// SetProductStatus moves a product through the publishing workflow. Products
+// that are no longer published are taken out of baskets.
+func (s *StoreService) SetProductStatus(productId uint32, status string, publishAt *time.Time,
+	userName string) (*entity.Product, error) {
+	product := s.productRepo.GetById(productId)
+	if product == nil {
+		return nil, errors.New("product not found")
+	}
+	before := *product
+
+	if err := product.SetStatus(status, publishAt, time.Now()); err != nil {
+		return nil, err
+	}
+
+	if !product.IsPublished() {
+		if err := s.takeOutOfBaskets(product); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.productRepo.Update(product); err != nil {
+		return nil, errors.New("an unknown error occurred during operation")
+	}
+	s.inventoryService.CheckStock(product.ID)
+
+	if err := recordProductChange(&s.productRepo, userName, entity.RevisionActionUpdated, &before, product); err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+// PublishScheduled publishes the scheduled products whose time has come and
+// returns how many were published.
+func (s *StoreService) PublishScheduled() (int, error) {
+	published := 0
+	for _, product := range s.productRepo.GetDueScheduled(time.Now()) {
+		before := product
+
+		if err := product.SetStatus(entity.ProductStatusPublished, product.PublishAt, time.Now()); err != nil {
+			return published, fmt.Errorf("product %d: %w", product.ID, err)
+		}
+		if err := s.productRepo.Update(&product); err != nil {
+			return published, fmt.Errorf("product %d: %w", product.ID, err)
+		}
+		if err := recordProductChange(&s.productRepo, PublisherUserName, entity.RevisionActionUpdated, &before, &product); err != nil {
+			return published, fmt.Errorf("product %d: %w", product.ID, err)
+		}
+		published++
+	}
+
+	return published, nil
+}
@@ -0,0 +1,442 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"github.com/bestetufan/beste-store/internal/domain/repo"
+	"github.com/bestetufan/beste-store/pkg/measure"
+	"github.com/bestetufan/beste-store/pkg/money"
+)
+
+// ProductSupplier is a supplier of a product with its cost price and the
+// margin the product makes at its current price. The margin is in the
+// currency of the product; it is missing when the cost price can not be
+// converted into it.
+type ProductSupplier struct {
+	SupplierID    uint32       `json:"supplier_id"`
+	SupplierName  string       `json:"supplier_name"`
+	SupplierSku   string       `json:"supplier_sku"`
+	CostPrice     money.Money  `json:"cost_price" swaggertype:"number"`
+	CostCurrency  string       `json:"cost_currency"`
+	Price         money.Money  `json:"price" swaggertype:"number"`
+	Currency      string       `json:"currency"`
+	Margin        *money.Money `json:"margin" swaggertype:"number"`
+	MarginPercent *json.Number `json:"margin_percent" swaggertype:"number"`
+}
+
+// PurchaseOrderLine is an item requested for a purchase order. An empty
+// UnitCost takes the cost price of the product at the supplier.
+type PurchaseOrderLine struct {
+	ProductID uint32
+	Quantity  measure.Quantity
+	UnitCost  string
+}
+
+// PurchasingService manages suppliers, the cost prices of their products and
+// purchase orders. Received items of purchase orders are posted to the stock
+// ledger as receipts referencing the purchase order; the received
+// quantities of a purchase order are always read back from the ledger.
+type PurchasingService struct {
+	supplierRepo      repo.SupplierRepository
+	purchaseOrderRepo repo.PurchaseOrderRepository
+	productRepo       repo.ProductRepository
+	inventoryService  InventoryService
+	exchangeService   ExchangeService
+}
+
+func NewPurchasingService(sr repo.SupplierRepository, por repo.PurchaseOrderRepository, pr repo.ProductRepository,
+	is InventoryService, es ExchangeService) *PurchasingService {
+	return &PurchasingService{
+		supplierRepo:      sr,
+		purchaseOrderRepo: por,
+		productRepo:       pr,
+		inventoryService:  is,
+		exchangeService:   es,
+	}
+}
+
+func (s *PurchasingService) GetSuppliers() []entity.Supplier {
+	return s.supplierRepo.GetAll()
+}
+
+func (s *PurchasingService) GetSupplier(supplierId uint32) *entity.Supplier {
+	return s.supplierRepo.GetById(supplierId)
+}
+
+func (s *PurchasingService) CreateSupplier(supplier *entity.Supplier) error {
+	if s.supplierRepo.GetByName(supplier.Name) != nil {
+		return errors.New("supplier with same name already exist in database")
+	}
+
+	if err := s.supplierRepo.Create(supplier); err != nil {
+		return errors.New("an unknown error occurred during operation")
+	}
+
+	return nil
+}
+
+func (s *PurchasingService) UpdateSupplier(supplier *entity.Supplier) error {
+	if existing := s.supplierRepo.GetByName(supplier.Name); existing != nil && existing.ID != supplier.ID {
+		return errors.New("supplier with same name already exist in database")
+	}
+
+	if err := s.supplierRepo.Update(supplier); err != nil {
+		return errors.New("an unknown error occurred during operation")
+	}
+
+	return nil
+}
+
+// DeleteSupplier deletes a supplier with its cost prices. Suppliers with
+// purchase orders are kept for their history.
+func (s *PurchasingService) DeleteSupplier(supplierId uint32) error {
+	if s.supplierRepo.GetById(supplierId) == nil {
+		return errors.New("supplier not found")
+	}
+	if s.purchaseOrderRepo.CountBySupplier(supplierId) > 0 {
+		return errors.New("supplier has purchase orders")
+	}
+
+	if err := s.supplierRepo.DeleteById(supplierId); err != nil {
+		return errors.New("an unknown error occurred during operation")
+	}
+
+	return nil
+}
+
+func (s *PurchasingService) GetSupplierProducts(supplierId uint32) []entity.SupplierProduct {
+	return s.supplierRepo.GetProducts(supplierId)
+}
+
+// SetSupplierProduct sets the cost price of a product at a supplier. The
+// price is in the currency of the supplier unless currency is given.
+func (s *PurchasingService) SetSupplierProduct(supplierId uint32, productId uint32, supplierSku string,
+	costPrice string, currency string) (*entity.SupplierProduct, error) {
+	supplier := s.supplierRepo.GetById(supplierId)
+	if supplier == nil {
+		return nil, errors.New("supplier not found")
+	}
+	if s.productRepo.GetById(productId) == nil {
+		return nil, errors.New("product not found")
+	}
+
+	if len(currency) == 0 {
+		currency = supplier.Currency
+	}
+	price, err := money.Parse(costPrice, strings.ToUpper(currency))
+	if err != nil {
+		return nil, err
+	}
+
+	supplierProduct, err := entity.NewSupplierProduct(supplierId, productId, supplierSku, price)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.supplierRepo.SaveProduct(supplierProduct); err != nil {
+		return nil, errors.New("an unknown error occurred during operation")
+	}
+
+	return supplierProduct, nil
+}
+
+func (s *PurchasingService) DeleteSupplierProduct(supplierId uint32, productId uint32) error {
+	if s.supplierRepo.GetProduct(supplierId, productId) == nil {
+		return errors.New("supplier product not found")
+	}
+
+	if err := s.supplierRepo.DeleteProduct(supplierId, productId); err != nil {
+		return errors.New("an unknown error occurred during operation")
+	}
+
+	return nil
+}
+
+// GetProductSuppliers returns the suppliers of a product with the margin of
+// the product at each of them.
+func (s *PurchasingService) GetProductSuppliers(productId uint32) ([]ProductSupplier, error) {
+	product := s.productRepo.GetById(productId)
+	if product == nil {
+		return nil, errors.New("product not found")
+	}
+
+	price := product.EffectivePrice()
+	suppliers := []ProductSupplier{}
+	for _, sp := range s.supplierRepo.GetProductSuppliers(productId) {
+		ps := ProductSupplier{
+			SupplierID:   sp.SupplierID,
+			SupplierSku:  sp.SupplierSku,
+			CostPrice:    sp.CostPrice,
+			CostCurrency: sp.CostPrice.Currency,
+			Price:        price,
+			Currency:     price.Currency,
+		}
+		if sp.Supplier != nil {
+			ps.SupplierName = sp.Supplier.Name
+		}
+
+		if cost, err := s.exchangeService.Convert(sp.CostPrice, price.Currency); err == nil {
+			margin := money.New(price.Amount-cost.Amount, price.Currency)
+			ps.Margin = &margin
+			if price.Amount > 0 {
+				percent := json.Number(big.NewRat(margin.Amount*100, price.Amount).FloatString(2))
+				ps.MarginPercent = &percent
+			}
+		}
+		suppliers = append(suppliers, ps)
+	}
+
+	return suppliers, nil
+}
+
+func (s *PurchasingService) GetPurchaseOrders(filter entity.PurchaseOrderFilter, pageIndex, pageSize int) ([]entity.PurchaseOrder, int) {
+	return s.purchaseOrderRepo.GetAll(filter, pageIndex, pageSize)
+}
+
+func (s *PurchasingService) GetPurchaseOrder(id uint32) *entity.PurchaseOrder {
+	return s.purchaseOrderRepo.GetById(id)
+}
+
+// CreatePurchaseOrder creates a draft purchase order to a supplier, received
+// into the given warehouse, or the default one when warehouseId is zero.
+func (s *PurchasingService) CreatePurchaseOrder(supplierId uint32, warehouseId uint32, note string,
+	lines []PurchaseOrderLine, userName string) (*entity.PurchaseOrder, error) {
+	supplier := s.supplierRepo.GetById(supplierId)
+	if supplier == nil {
+		return nil, errors.New("supplier not found")
+	}
+	warehouse, err := s.inventoryService.warehouse(warehouseId)
+	if err != nil {
+		return nil, err
+	}
+
+	order := entity.NewPurchaseOrder(supplier, warehouse.ID, note, userName)
+	if err := s.setItems(order, lines); err != nil {
+		return nil, err
+	}
+
+	if err := s.purchaseOrderRepo.Create(order); err != nil {
+		return nil, errors.New("an unknown error occurred during operation")
+	}
+
+	return s.purchaseOrderRepo.GetById(order.ID), nil
+}
+
+// UpdatePurchaseOrder replaces the warehouse, note and items of a draft.
+func (s *PurchasingService) UpdatePurchaseOrder(id uint32, warehouseId uint32, note string,
+	lines []PurchaseOrderLine) (*entity.PurchaseOrder, error) {
+	order := s.purchaseOrderRepo.GetById(id)
+	if order == nil {
+		return nil, errors.New("purchase order not found")
+	}
+	if !order.IsDraft() {
+		return nil, fmt.Errorf("purchase order is %s", order.Status)
+	}
+	warehouse, err := s.inventoryService.warehouse(warehouseId)
+	if err != nil {
+		return nil, err
+	}
+
+	order.WarehouseID, order.Warehouse, order.Note = warehouse.ID, nil, note
+	if err := s.setItems(order, lines); err != nil {
+		return nil, err
+	}
+
+	if err := s.purchaseOrderRepo.Update(order); err != nil {
+		return nil, errors.New("an unknown error occurred during operation")
+	}
+
+	return s.purchaseOrderRepo.GetById(order.ID), nil
+}
+
+// DraftPurchaseOrder creates a draft purchase order to a supplier for its
+// products that are low on stock. Each product is ordered in its suggested
+// reorder quantity, less what is already in open purchase orders.
+func (s *PurchasingService) DraftPurchaseOrder(supplierId uint32, warehouseId uint32,
+	userName string) (*entity.PurchaseOrder, error) {
+	supplier := s.supplierRepo.GetById(supplierId)
+	if supplier == nil {
+		return nil, errors.New("supplier not found")
+	}
+	warehouse, err := s.inventoryService.warehouse(warehouseId)
+	if err != nil {
+		return nil, err
+	}
+
+	low := make(map[uint32]bool)
+	for _, id := range s.productRepo.GetLowStockIds() {
+		low[id] = true
+	}
+	var products []entity.SupplierProduct
+	var productIds []uint32
+	for _, sp := range s.supplierRepo.GetProducts(supplierId) {
+		if low[sp.ProductID] && sp.Product != nil {
+			products = append(products, sp)
+			productIds = append(productIds, sp.ProductID)
+		}
+	}
+	onOrder := s.purchaseOrderRepo.GetOnOrder(productIds)
+
+	order := entity.NewPurchaseOrder(supplier, warehouse.ID, "drafted from low stock", userName)
+	for _, sp := range products {
+		quantity := sp.Product.SuggestedReorder() - onOrder[sp.ProductID]
+		if quantity <= 0 {
+			continue
+		}
+		unitCost, err := s.exchangeService.Convert(sp.CostPrice, order.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", sp.Product.Name, err)
+		}
+		if err := order.AddItem(sp.ProductID, quantity, unitCost); err != nil {
+			return nil, err
+		}
+	}
+	if len(order.Items) == 0 {
+		return nil, errors.New("no products of the supplier need to be reordered")
+	}
+
+	if err := s.purchaseOrderRepo.Create(order); err != nil {
+		return nil, errors.New("an unknown error occurred during operation")
+	}
+
+	return s.purchaseOrderRepo.GetById(order.ID), nil
+}
+
+// OrderPurchaseOrder marks a draft as sent to the supplier.
+func (s *PurchasingService) OrderPurchaseOrder(id uint32) (*entity.PurchaseOrder, error) {
+	order := s.purchaseOrderRepo.GetById(id)
+	if order == nil {
+		return nil, errors.New("purchase order not found")
+	}
+	if err := order.Order(time.Now()); err != nil {
+		return nil, err
+	}
+
+	if err := s.purchaseOrderRepo.Update(order); err != nil {
+		return nil, errors.New("an unknown error occurred during operation")
+	}
+
+	return order, nil
+}
+
+// CancelPurchaseOrder cancels a purchase order, or what has not been received
+// of it yet.
+func (s *PurchasingService) CancelPurchaseOrder(id uint32) (*entity.PurchaseOrder, error) {
+	order := s.purchaseOrderRepo.GetById(id)
+	if order == nil {
+		return nil, errors.New("purchase order not found")
+	}
+	if err := order.Cancel(); err != nil {
+		return nil, err
+	}
+
+	if err := s.purchaseOrderRepo.Update(order); err != nil {
+		return nil, errors.New("an unknown error occurred during operation")
+	}
+
+	return order, nil
+}
+
+// ReceivePurchaseOrder receives items of an ordered purchase order into its
+// warehouse. receipts give the quantities received, in lots for products
+// tracked by lot; when there are none, everything outstanding is received.
+func (s *PurchasingService) ReceivePurchaseOrder(id uint32, receipts []StockReceipt,
+	userName string) (*entity.PurchaseOrder, error) {
+	order := s.purchaseOrderRepo.GetById(id)
+	if order == nil {
+		return nil, errors.New("purchase order not found")
+	}
+	if !order.CanReceive() {
+		return nil, fmt.Errorf("purchase order is %s", order.Status)
+	}
+
+	reference := entity.PurchaseOrderReference(order.ID)
+	productIds := make([]uint32, 0, len(order.Items))
+	for _, item := range order.Items {
+		productIds = append(productIds, item.ProductID)
+	}
+	order.SetReceived(s.inventoryService.Received(productIds, reference), time.Now())
+
+	if len(receipts) == 0 {
+		for _, item := range order.Items {
+			receipts = append(receipts, StockReceipt{ProductID: item.ProductID, Quantity: item.Outstanding()})
+		}
+	}
+	quantities := make(map[uint32]measure.Quantity, len(receipts))
+	for _, receipt := range receipts {
+		if receipt.Quantity < 0 {
+			return nil, errors.New("quantity can not be negative")
+		}
+		quantities[receipt.ProductID] += receipt.Quantity
+	}
+	var total measure.Quantity
+	for productId, quantity := range quantities {
+		item := order.Item(productId)
+		if item == nil {
+			return nil, fmt.Errorf("product %d is not in the purchase order", productId)
+		}
+		if quantity > item.Outstanding() {
+			return nil, fmt.Errorf("only %s units of product %d are outstanding", item.Outstanding(), productId)
+		}
+		total += quantity
+	}
+	if total == 0 {
+		return nil, errors.New("nothing to receive")
+	}
+
+	if err := s.inventoryService.Receive(order.WarehouseID, receipts, reference, userName); err != nil {
+		return nil, err
+	}
+
+	order.SetReceived(s.inventoryService.Received(productIds, reference), time.Now())
+	if err := s.purchaseOrderRepo.Update(order); err != nil {
+		return nil, errors.New("unable to update purchase order")
+	}
+
+	return s.purchaseOrderRepo.GetById(order.ID), nil
+}
+
+// setItems replaces the items of a purchase order.
+func (s *PurchasingService) setItems(order *entity.PurchaseOrder, lines []PurchaseOrderLine) error {
+	order.Items = nil
+	for _, line := range lines {
+		product := s.productRepo.GetById(line.ProductID)
+		if product == nil {
+			return fmt.Errorf("product %d not found", line.ProductID)
+		}
+		if err := product.CheckStockQuantity(line.Quantity); err != nil {
+			return err
+		}
+
+		var unitCost money.Money
+		if len(line.UnitCost) > 0 {
+			cost, err := money.Parse(line.UnitCost, order.Currency)
+			if err != nil {
+				return err
+			}
+			unitCost = cost
+		} else {
+			sp := s.supplierRepo.GetProduct(order.SupplierID, line.ProductID)
+			if sp == nil {
+				return fmt.Errorf("unit_cost is required for product %d, which has no cost price at the supplier",
+					line.ProductID)
+			}
+			cost, err := s.exchangeService.Convert(sp.CostPrice, order.Currency)
+			if err != nil {
+				return err
+			}
+			unitCost = cost
+		}
+
+		if err := order.AddItem(line.ProductID, line.Quantity, unitCost); err != nil {
+			return err
+		}
+	}
+	return nil
+}
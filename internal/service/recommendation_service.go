@@ -0,0 +1,118 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"github.com/bestetufan/beste-store/internal/domain/repo"
+)
+
+// Sources of related products.
+const (
+	RelatedSourceCoPurchase = "co_purchase"
+	RelatedSourceBestseller = "bestseller"
+)
+
+// defaultRecommendationLimit is the number of related products kept for each
+// product when none is configured.
+const defaultRecommendationLimit = 10
+
+// RelatedProduct is a product recommended with another. Count is the number
+// of orders, or of customers, that bought both; it is zero for bestsellers
+// of the category filling in for missing co-purchase data.
+type RelatedProduct struct {
+	Product *entity.Product
+	Source  string
+	Count   int
+}
+
+// RecommendationService recommends products from the order history. Products
+// bought together and products customers also bought are precomputed by
+// Compute, keeping for each product the limit best related products bought
+// with it at least minCount times. Recommendations short of data are filled
+// up with the bestsellers of the category of the product.
+type RecommendationService struct {
+	recommendationRepo repo.RecommendationRepository
+	productRepo        repo.ProductRepository
+	minCount           int
+	limit              int
+}
+
+func NewRecommendationService(rr repo.RecommendationRepository, pr repo.ProductRepository, minCount int,
+	limit int) *RecommendationService {
+	if minCount < 1 {
+		minCount = 1
+	}
+	if limit < 1 {
+		limit = defaultRecommendationLimit
+	}
+	return &RecommendationService{
+		recommendationRepo: rr,
+		productRepo:        pr,
+		minCount:           minCount,
+		limit:              limit,
+	}
+}
+
+// Compute recomputes all recommendations from the orders that are not
+// canceled and returns how many were stored.
+func (s *RecommendationService) Compute() (int, error) {
+	var recommendations []*entity.ProductRecommendation
+	add := func(kind string, pairs []entity.ProductRecommendation) {
+		for i := range pairs {
+			pairs[i].Kind = kind
+			recommendations = append(recommendations, &pairs[i])
+		}
+	}
+	add(entity.RecommendationBoughtTogether, s.recommendationRepo.GetBoughtTogether(s.minCount, s.limit))
+	add(entity.RecommendationAlsoBought, s.recommendationRepo.GetAlsoBought(s.minCount, s.limit))
+
+	if err := s.recommendationRepo.Replace(recommendations); err != nil {
+		return 0, errors.New("unable to save recommendations")
+	}
+	return len(recommendations), nil
+}
+
+// GetRelated returns up to limit published products of the given kind of
+// recommendation for a product, best first, followed by bestsellers of its
+// category when there are not enough.
+func (s *RecommendationService) GetRelated(product *entity.Product, kind string, limit int) ([]RelatedProduct, error) {
+	if kind != entity.RecommendationBoughtTogether && kind != entity.RecommendationAlsoBought {
+		return nil, errors.New("unknown recommendation kind")
+	}
+	if limit <= 0 || limit > s.limit {
+		limit = s.limit
+	}
+
+	recommendations := s.recommendationRepo.GetByProduct(product.ID, kind, limit)
+	ids := make([]uint32, 0, limit)
+	counts := make(map[uint32]int, limit)
+	for _, recommendation := range recommendations {
+		ids = append(ids, recommendation.RelatedID)
+		counts[recommendation.RelatedID] = recommendation.Count
+	}
+	if len(ids) < limit {
+		exclude := append([]uint32{product.ID}, ids...)
+		ids = append(ids, s.recommendationRepo.GetBestsellers(product.CategoryID, exclude, limit-len(ids))...)
+	}
+
+	products := make(map[uint32]*entity.Product, len(ids))
+	for _, p := range s.productRepo.GetByIds(ids) {
+		p := p
+		products[p.ID] = &p
+	}
+
+	related := make([]RelatedProduct, 0, len(ids))
+	for _, id := range ids {
+		p, ok := products[id]
+		if !ok {
+			continue
+		}
+		source := RelatedSourceCoPurchase
+		if _, ok := counts[id]; !ok {
+			source = RelatedSourceBestseller
+		}
+		related = append(related, RelatedProduct{Product: p, Source: source, Count: counts[id]})
+	}
+	return related, nil
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"github.com/bestetufan/beste-store/internal/domain/repo"
+)
+
+// ReviewService takes the reviews of customers who received a product and
+// lets admins moderate them. The rating of a product is kept as the average
+// of its approved reviews.
+type ReviewService struct {
+	reviewRepo  repo.ReviewRepository
+	productRepo repo.ProductRepository
+	orderRepo   repo.OrderRepository
+}
+
+func NewReviewService(rr repo.ReviewRepository, pr repo.ProductRepository, or repo.OrderRepository) *ReviewService {
+	return &ReviewService{
+		reviewRepo:  rr,
+		productRepo: pr,
+		orderRepo:   or,
+	}
+}
+
+// GetReviews returns the reviews matching the filter, newest first.
+func (s *ReviewService) GetReviews(filter entity.ReviewFilter, pageIndex, pageSize int) ([]entity.Review, int) {
+	return s.reviewRepo.GetAll(filter, pageIndex, pageSize)
+}
+
+// CreateReview adds the review of a product by a user, to be shown once
+// approved. Users review a product once, after an order containing it has
+// been delivered.
+func (s *ReviewService) CreateReview(productId uint32, userName string, rating int, title string,
+	text string) (*entity.Review, error) {
+	if s.productRepo.GetById(productId) == nil {
+		return nil, errors.New("product not found")
+	}
+	if s.reviewRepo.GetByUser(productId, userName) != nil {
+		return nil, errors.New("product is already reviewed")
+	}
+	if !s.orderRepo.HasReceived(userName, productId) {
+		return nil, errors.New("only customers who received the product can review it")
+	}
+
+	review, err := entity.NewReview(productId, userName, rating, title, text)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.reviewRepo.Create(review); err != nil {
+		return nil, errors.New("unable to create review")
+	}
+
+	return review, nil
+}
+
+// ModerateReview approves or rejects a review and updates the rating of its
+// product.
+func (s *ReviewService) ModerateReview(id uint32, approved bool, userName string) (*entity.Review, error) {
+	review := s.reviewRepo.GetById(id)
+	if review == nil {
+		return nil, errors.New("review not found")
+	}
+
+	review.Moderate(approved, userName, time.Now())
+	if err := s.reviewRepo.Update(review); err != nil {
+		return nil, errors.New("unable to update review")
+	}
+
+	return review, nil
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"github.com/bestetufan/beste-store/internal/domain/repo"
+	"github.com/bestetufan/beste-store/pkg/measure"
+	"github.com/bestetufan/beste-store/pkg/notifier"
+)
+
+// LowStockProduct is a product whose stock is low or out. It is listed to
+// admins and sent as the data of stock alerts.
+type LowStockProduct struct {
+	ProductID       uint32           `json:"product_id"`
+	Sku             string           `json:"sku"`
+	Name            string           `json:"name"`
+	Status          string           `json:"status"`
+	Level           string           `json:"level"`
+	Unit            string           `json:"unit"`
+	Quantity        measure.Quantity `json:"quantity" swaggertype:"number"`
+	Reserved        measure.Quantity `json:"reserved" swaggertype:"number"`
+	ReorderPoint    measure.Quantity `json:"reorder_point" swaggertype:"number"`
+	ReorderQuantity measure.Quantity `json:"reorder_quantity" swaggertype:"number"`
+}
+
+// StockAlertService alerts when products run low on stock.
+//
+// Stock changes queue the product for a check, which a background job runs
+// with CheckQueued. A notification is sent when the stock on hand of a
+// product drops to its reorder point or runs out, and again only when it
+// moves from one of these levels to the other; the last alert of every
+// product is kept so restarts do not repeat them.
+type StockAlertService struct {
+	productRepo    repo.ProductRepository
+	stockAlertRepo repo.StockAlertRepository
+	notifier       notifier.Notifier
+	queue          *stockCheckQueue
+}
+
+func NewStockAlertService(pr repo.ProductRepository, ar repo.StockAlertRepository, n notifier.Notifier) *StockAlertService {
+	return &StockAlertService{
+		productRepo:    pr,
+		stockAlertRepo: ar,
+		notifier:       n,
+		queue:          &stockCheckQueue{all: true, ids: make(map[uint32]bool)},
+	}
+}
+
+func (s *StockAlertService) GetLowStock(pageIndex, pageSize int) ([]LowStockProduct, int) {
+	products, count := s.productRepo.GetLowStock(pageIndex, pageSize)
+
+	items := make([]LowStockProduct, 0, len(products))
+	for i := range products {
+		items = append(items, newLowStockProduct(&products[i]))
+	}
+	return items, count
+}
+
+// Queue marks products to be checked on the next run of CheckQueued.
+func (s *StockAlertService) Queue(productIds ...uint32) {
+	s.queue.add(productIds...)
+}
+
+// CheckQueued checks the queued products and returns how many alerts were
+// sent. The first run checks every product that is low on stock or has an
+// alert. Products that could not be checked stay queued.
+func (s *StockAlertService) CheckQueued(ctx context.Context) (int, error) {
+	ids, all := s.queue.take()
+	if all {
+		ids = mergeIds(ids, s.productRepo.GetLowStockIds(), s.stockAlertRepo.GetProductIds())
+	}
+
+	count := 0
+	for i, id := range ids {
+		sent, err := s.check(ctx, id)
+		if err != nil {
+			s.queue.add(ids[i:]...)
+			return count, fmt.Errorf("product %d: %w", id, err)
+		}
+		if sent {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *StockAlertService) check(ctx context.Context, productId uint32) (bool, error) {
+	product := s.productRepo.GetById(productId)
+	alert := s.stockAlertRepo.GetByProductId(productId)
+
+	level := ""
+	if product != nil {
+		level = product.StockAlertLevel()
+	}
+	if len(level) == 0 {
+		if alert == nil {
+			return false, nil
+		}
+		return false, s.stockAlertRepo.DeleteByProductId(productId)
+	}
+	if alert != nil && alert.Level == level {
+		return false, nil
+	}
+
+	if err := s.notifier.Notify(ctx, newStockAlertNotification(product)); err != nil {
+		return false, err
+	}
+	return true, s.stockAlertRepo.Save(entity.NewStockAlert(product))
+}
+
+func newLowStockProduct(product *entity.Product) LowStockProduct {
+	return LowStockProduct{
+		ProductID:       product.ID,
+		Sku:             product.Sku,
+		Name:            product.Name,
+		Status:          product.Status,
+		Level:           product.StockAlertLevel(),
+		Unit:            product.Unit,
+		Quantity:        product.Quantity,
+		Reserved:        product.Reserved,
+		ReorderPoint:    product.ReorderPoint,
+		ReorderQuantity: product.ReorderQuantity,
+	}
+}
+
+func newStockAlertNotification(product *entity.Product) notifier.Notification {
+	n := notifier.Notification{
+		Event: product.StockAlertLevel(),
+		Data:  newLowStockProduct(product),
+		Time:  time.Now(),
+	}
+
+	if n.Event == entity.StockAlertOutOfStock {
+		n.Subject = fmt.Sprintf("Out of stock: %s (%s)", product.Name, product.Sku)
+		n.Text = fmt.Sprintf("%s (SKU %s) is out of stock.", product.Name, product.Sku)
+	} else {
+		n.Subject = fmt.Sprintf("Low stock: %s (%s)", product.Name, product.Sku)
+		n.Text = fmt.Sprintf("%s (SKU %s) has %s in stock, at or below its reorder point of %s.",
+			product.Name, product.Sku, product.FormatQuantity(product.Quantity), product.FormatQuantity(product.ReorderPoint))
+	}
+	if product.ReorderQuantity > 0 {
+		n.Text += fmt.Sprintf("\nReorder quantity: %s.", product.FormatQuantity(product.ReorderQuantity))
+	}
+	return n
+}
+
+// stockCheckQueue holds the products waiting for a stock check. It is
+// shared by the copies of the StockAlertService holding it.
+type stockCheckQueue struct {
+	mu  sync.Mutex
+	all bool
+	ids map[uint32]bool
+}
+
+func (q *stockCheckQueue) add(ids ...uint32) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, id := range ids {
+		q.ids[id] = true
+	}
+}
+
+// take empties the queue and returns the queued ids, and whether every
+// product has to be checked.
+func (q *stockCheckQueue) take() ([]uint32, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	ids := make([]uint32, 0, len(q.ids))
+	for id := range q.ids {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	all := q.all
+	q.all, q.ids = false, make(map[uint32]bool)
+	return ids, all
+}
+
+// mergeIds returns the distinct ids of the given lists in ascending order.
+func mergeIds(lists ...[]uint32) []uint32 {
+	seen := make(map[uint32]bool)
+	for _, ids := range lists {
+		for _, id := range ids {
+			seen[id] = true
+		}
+	}
+
+	merged := make([]uint32, 0, len(seen))
+	for id := range seen {
+		merged = append(merged, id)
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i] < merged[j] })
+	return merged
+}
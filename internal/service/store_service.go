@@ -1,33 +1,64 @@
 package service
 
 import (
-	"encoding/csv"
 	"errors"
 	"fmt"
-	"mime/multipart"
-	"strconv"
+	"strings"
 	"time"
 
 	"github.com/bestetufan/beste-store/internal/domain/entity"
 	"github.com/bestetufan/beste-store/internal/domain/repo"
+	"github.com/bestetufan/beste-store/pkg/barcode"
+	"github.com/bestetufan/beste-store/pkg/measure"
+	"github.com/bestetufan/beste-store/pkg/money"
 )
 
 type StoreService struct {
-	categoryRepo repo.CategoryRepository
-	productRepo  repo.ProductRepository
-	basketRepo   repo.BasketRepository
-	orderRepo    repo.OrderRepository
+	categoryRepo     repo.CategoryRepository
+	attributeRepo    repo.AttributeRepository
+	productRepo      repo.ProductRepository
+	salePriceRepo    repo.SalePriceRepository
+	basketRepo       repo.BasketRepository
+	orderRepo        repo.OrderRepository
+	inventoryService InventoryService
+	exchangeService  ExchangeService
+	downloadService  DownloadService
+	currency         string
 }
 
-func NewStoreService(cr repo.CategoryRepository, pr repo.ProductRepository, br repo.BasketRepository, or repo.OrderRepository) *StoreService {
+func NewStoreService(cr repo.CategoryRepository, ar repo.AttributeRepository, pr repo.ProductRepository,
+	sr repo.SalePriceRepository, br repo.BasketRepository, or repo.OrderRepository, is InventoryService,
+	es ExchangeService, ds DownloadService, currency string) *StoreService {
 	return &StoreService{
-		categoryRepo: cr,
-		productRepo:  pr,
-		basketRepo:   br,
-		orderRepo:    or,
+		categoryRepo:     cr,
+		attributeRepo:    ar,
+		productRepo:      pr,
+		salePriceRepo:    sr,
+		basketRepo:       br,
+		orderRepo:        or,
+		inventoryService: is,
+		exchangeService:  es,
+		downloadService:  ds,
+		currency:         currency,
 	}
 }
 
+// ParsePrice reads a decimal price such as "10.50" in currency, or in the
+// default currency of the store when currency is empty.
+func (s *StoreService) ParsePrice(value string, currency string) (money.Money, error) {
+	if len(currency) == 0 {
+		currency = s.currency
+	}
+	price, err := money.Parse(value, strings.ToUpper(currency))
+	if err != nil {
+		return money.Money{}, err
+	}
+	if price.Amount < 0 {
+		return money.Money{}, errors.New("price can not be negative")
+	}
+	return price, nil
+}
+
 func (s *StoreService) GetAllCategories(pageIndex, pageSize int, onlyActives bool) ([]entity.Category, int) {
 	if onlyActives {
 		return s.categoryRepo.GetAllActives(pageIndex, pageSize)
@@ -53,45 +84,69 @@ func (s *StoreService) CreateCategory(category *entity.Category) error {
 	return nil
 }
 
-func (s *StoreService) CreateBulkCategory(file multipart.File) (int, int, error) {
-	reader := csv.NewReader(file)
+// DeleteCategory moves a category to the trash. Categories that still have
+// products can not be deleted.
+func (s *StoreService) DeleteCategory(categoryId uint32) error {
+	category := s.categoryRepo.GetById(categoryId)
+	if category == nil {
+		return errors.New("category not found")
+	}
 
-	reader.Comma = ';'
-	lines, err := reader.ReadAll()
+	_, count := s.productRepo.GetAll(1, 1, entity.ProductFilter{CategoryID: categoryId})
+	if count > 0 {
+		return errors.New("category has products")
+	}
+
+	err := s.categoryRepo.DeleteById(categoryId)
 	if err != nil {
-		return 0, 0, errors.New("unable to initialize csv reader")
+		return errors.New("an unknown error occurred during operation")
 	}
 
-	addedCount := 0
-	existingCount := 0
+	return nil
+}
 
-	for _, line := range lines[1:] {
-		name := line[0]
-		isActive, _ := strconv.ParseBool(line[1])
+func (s *StoreService) GetCategoryAttributes(categoryId uint32) []entity.Attribute {
+	return s.attributeRepo.GetAllByCategoryId(categoryId)
+}
 
-		category := entity.NewCategory(
-			name,     // Name
-			isActive, // IsActive
-		)
+func (s *StoreService) CreateAttribute(attribute *entity.Attribute) error {
+	category := s.categoryRepo.GetById(attribute.CategoryID)
+	if category == nil {
+		return errors.New("category not found")
+	}
 
-		categoryExists := s.categoryRepo.GetByName(name)
-		if categoryExists != nil {
-			existingCount += 1
-		} else {
-			if err := s.categoryRepo.Create(category); err != nil {
-				return addedCount, existingCount, fmt.Errorf("unable to create category: %s", name)
-			}
-			addedCount += 1
-		}
+	attributeExists := s.attributeRepo.GetByCode(attribute.CategoryID, attribute.Code)
+	if attributeExists != nil {
+		return errors.New("attribute with same code already exist in category")
+	}
+
+	err := s.attributeRepo.Create(attribute)
+	if err != nil {
+		return errors.New("an unknown error occurred during operation")
+	}
+
+	return nil
+}
+
+func (s *StoreService) DeleteAttribute(categoryId uint32, attributeId uint32) error {
+	attribute := s.attributeRepo.GetById(attributeId)
+	if attribute == nil || attribute.CategoryID != categoryId {
+		return errors.New("attribute not found")
+	}
+
+	err := s.attributeRepo.DeleteById(attributeId)
+	if err != nil {
+		return errors.New("an unknown error occurred during operation")
 	}
 
-	return addedCount, existingCount, nil
+	return nil
 }
 
-func (s *StoreService) GetAllProducts(pageIndex, pageSize int) ([]entity.Product, int) {
-	items, count := s.productRepo.GetAll(pageIndex, pageSize)
+func (s *StoreService) GetAllProducts(pageIndex, pageSize int, filter entity.ProductFilter) ([]entity.Product, int, []entity.Facet) {
+	items, count := s.productRepo.GetAll(pageIndex, pageSize, filter)
+	facets := s.productRepo.GetFacets(filter)
 
-	return items, count
+	return items, count, facets
 }
 
 func (s *StoreService) GetProduct(productId uint32) *entity.Product {
@@ -102,38 +157,481 @@ func (s *StoreService) GetProductBySku(sku string) *entity.Product {
 	return s.productRepo.GetBySKU(sku)
 }
 
-func (s *StoreService) SearchProducts(query string) []entity.Product {
-	return s.productRepo.Search(query)
+// GetProductByBarcode returns the product with the scanned EAN-8, UPC-A or
+// EAN-13 code, or nil when there is none.
+func (s *StoreService) GetProductByBarcode(code string) (*entity.Product, error) {
+	gtin, err := barcode.ParseGTIN(code)
+	if err != nil {
+		return nil, err
+	}
+	return s.productRepo.GetByGTIN(gtin), nil
+}
+
+// GetProductLabels returns a barcode label for each of the products, in the
+// given order, with the name, SKU and current price of the product.
+func (s *StoreService) GetProductLabels(productIds []uint32) ([]barcode.Label, error) {
+	if len(productIds) == 0 {
+		return nil, errors.New("no products given")
+	}
+
+	labels := make([]barcode.Label, 0, len(productIds))
+	for _, id := range productIds {
+		product := s.productRepo.GetById(id)
+		if product == nil {
+			return nil, fmt.Errorf("product %d not found", id)
+		}
+		if product.GTIN == nil {
+			return nil, fmt.Errorf("%s has no GTIN", product.Sku)
+		}
+		price := product.EffectivePrice()
+		labels = append(labels, barcode.Label{
+			Title:    product.Name,
+			Subtitle: fmt.Sprintf("%s  %s %s", product.Sku, price.String(), price.Currency),
+			Code:     *product.GTIN,
+		})
+	}
+	return labels, nil
 }
 
-func (s *StoreService) CreateProduct(product *entity.Product) error {
+// checkStockChange checks before anything is saved that AdjustTo can set the
+// stock of product from stock to its quantity, by the category it is saved in.
+func (s *StoreService) checkStockChange(product *entity.Product, stock measure.Quantity) error {
+	tracksLots := false
+	if category := s.categoryRepo.GetById(product.CategoryID); category != nil {
+		tracksLots = category.TracksLots
+	}
+	return checkAdjustTo(product, tracksLots, product.Quantity-stock)
+}
+
+// checkGTIN makes sure that no other product has the GTIN of product,
+// including products in the trash, which would clash once restored.
+func (s *StoreService) checkGTIN(product *entity.Product) error {
+	if product.GTIN == nil {
+		return nil
+	}
+	if other := s.productRepo.GetByGTIN(*product.GTIN); other != nil && other.ID != product.ID {
+		return fmt.Errorf("GTIN %s is already used by %s", *product.GTIN, other.Sku)
+	}
+	if other := s.productRepo.GetDeletedByGTIN(*product.GTIN); other != nil && other.ID != product.ID {
+		return fmt.Errorf("GTIN %s is used by %s in the trash", *product.GTIN, other.Sku)
+	}
+	return nil
+}
+
+// SearchProducts returns the products matching query, limited to the given
+// statuses unless statuses is empty. Names translated in locale match too.
+func (s *StoreService) SearchProducts(query string, statuses []string, locale string) []entity.Product {
+	return s.productRepo.Search(query, statuses, locale)
+}
+
+func (s *StoreService) CreateProduct(product *entity.Product, attributes map[string]string, userName string) error {
+	if err := product.SetReorder(product.ReorderPoint, product.ReorderQuantity); err != nil {
+		return err
+	}
+	if product.IsSerialized() && product.Unit != entity.UnitPiece {
+		return errors.New("serialized products can only be sold by the piece")
+	}
+	if err := product.CheckStockQuantity(product.Quantity); err != nil {
+		return err
+	}
+	if err := s.checkStockChange(product, 0); err != nil {
+		return err
+	}
 	productExists := s.productRepo.GetBySKU(product.Sku)
 	if productExists != nil {
 		return errors.New("product with same sku already exist in database")
 	}
+	if s.productRepo.GetDeletedBySKU(product.Sku) != nil {
+		return errors.New("product with same sku is in the trash")
+	}
+	if err := s.checkGTIN(product); err != nil {
+		return err
+	}
+
+	productAttributes, err := s.buildProductAttributes(product.CategoryID, attributes)
+	if err != nil {
+		return err
+	}
 
-	err := s.productRepo.Create(product)
+	err = s.productRepo.Create(product)
 	if err != nil {
 		return errors.New("an unknown error occurred during operation")
 	}
 
-	return nil
+	if err := s.productRepo.SetAttributes(product.ID, productAttributes); err != nil {
+		return errors.New("unable to save product attributes")
+	}
+	product.Attributes = productAttributes
+
+	if err := s.inventoryService.AdjustTo(product.ID, product.Quantity, "initial stock", userName); err != nil {
+		return err
+	}
+	s.inventoryService.CheckStock(product.ID)
+
+	return recordProductChange(&s.productRepo, userName, entity.RevisionActionCreated, nil, product)
 }
 
-func (s *StoreService) UpdateProduct(product *entity.Product) error {
+// UpdateProduct saves the product. Attribute values are replaced only when
+// attributes is not nil. A changed quantity is posted to the stock ledger as
+// an adjustment. Bundles priced by discount keep the price of their
+// components; those the product is a component of follow a changed price.
+func (s *StoreService) UpdateProduct(product *entity.Product, attributes map[string]string, userName string) error {
+	before := s.productRepo.GetById(product.ID)
+	if before == nil {
+		return errors.New("product not found")
+	}
+	if product.BundlePricing == entity.BundlePricingDiscount {
+		price, err := product.ComponentPrice()
+		if err != nil {
+			return err
+		}
+		product.UnitPrice, product.Currency = price, price.Currency
+	}
+	if product.UnitPrice.Currency != before.UnitPrice.Currency {
+		if s.hasPendingSalePrices(product.ID) {
+			return errors.New("currency can not be changed while sale prices are scheduled")
+		}
+		for _, bundle := range s.productRepo.GetBundles(product.ID) {
+			if bundle.BundlePricing == entity.BundlePricingDiscount {
+				return fmt.Errorf("currency can not be changed while bundle %s is priced by its components", bundle.Sku)
+			}
+		}
+	}
+	if err := product.SetReorder(product.ReorderPoint, product.ReorderQuantity); err != nil {
+		return err
+	}
+	if product.IsSerialized() && product.Unit != entity.UnitPiece {
+		return errors.New("serialized products can only be sold by the piece")
+	}
+	// Units in stock have no serials to be tracked by, or keep serials that
+	// would no longer be.
+	if product.SerialType != before.SerialType && before.Quantity != 0 {
+		return errors.New("serial type can only be changed while the product is out of stock")
+	}
+	if err := product.CheckStockQuantity(product.Quantity); err != nil {
+		return err
+	}
+	if err := s.checkStockChange(product, before.Quantity); err != nil {
+		return err
+	}
+	if err := s.checkGTIN(product); err != nil {
+		return err
+	}
+
+	var productAttributes []*entity.ProductAttribute
+	if attributes != nil {
+		var err error
+		productAttributes, err = s.buildProductAttributes(product.CategoryID, attributes)
+		if err != nil {
+			return err
+		}
+	}
+
 	err := s.productRepo.Update(product)
 	if err != nil {
 		return errors.New("an unknown error occurred during operation")
 	}
 
+	if attributes != nil {
+		if err := s.productRepo.SetAttributes(product.ID, productAttributes); err != nil {
+			return errors.New("unable to save product attributes")
+		}
+		product.Attributes = productAttributes
+	}
+
+	if err := s.inventoryService.AdjustTo(product.ID, product.Quantity, "product update", userName); err != nil {
+		return err
+	}
+	s.inventoryService.CheckStock(product.ID)
+
+	if err := recordProductChange(&s.productRepo, userName, entity.RevisionActionUpdated, before, product); err != nil {
+		return err
+	}
+
+	if product.UnitPrice != before.UnitPrice {
+		return s.repriceBundles(product.ID, userName)
+	}
+	return nil
+}
+
+// repriceBundles updates the price of the bundles priced by discount the
+// product is a component of.
+func (s *StoreService) repriceBundles(productId uint32, userName string) error {
+	for _, b := range s.productRepo.GetBundles(productId) {
+		bundle := b
+		if bundle.BundlePricing != entity.BundlePricingDiscount {
+			continue
+		}
+		price, err := bundle.ComponentPrice()
+		if err != nil {
+			return err
+		}
+		if price == bundle.UnitPrice {
+			continue
+		}
+
+		before := bundle
+		bundle.UnitPrice, bundle.Currency = price, price.Currency
+		if err := s.productRepo.Update(&bundle); err != nil {
+			return errors.New("an unknown error occurred during operation")
+		}
+		if err := recordProductChange(&s.productRepo, userName, entity.RevisionActionUpdated, &before, &bundle); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func (s *StoreService) DeleteProduct(productId uint32) error {
+// BundleLine is a quantity of a product sold as part of a bundle.
+type BundleLine struct {
+	ProductID uint32
+	Quantity  measure.Quantity
+}
+
+// SetBundle makes a product without stock a bundle of the products of lines,
+// or changes the components and pricing of a bundle. Bundles priced by
+// discount cost the list prices of their components less discount percent.
+// Stock baskets reserved for the former components is released; it is
+// reserved again when the baskets are changed or ordered.
+func (s *StoreService) SetBundle(productId uint32, pricing string, discount int, lines []BundleLine,
+	userName string) (*entity.Product, error) {
+	product := s.productRepo.GetById(productId)
+	if product == nil {
+		return nil, errors.New("product not found")
+	}
+	before := *product
+
+	if len(pricing) == 0 {
+		return nil, errors.New("bundle pricing is required")
+	}
+	if bundles := s.productRepo.GetBundles(productId); len(bundles) > 0 {
+		return nil, fmt.Errorf("%s is a component of bundle %s", product.Sku, bundles[0].Sku)
+	}
+	if err := product.SetBundle(pricing, discount); err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, errors.New("a bundle needs at least one component")
+	}
+
+	components := make([]*entity.BundleComponent, 0, len(lines))
+	for _, line := range lines {
+		for _, c := range components {
+			if c.ProductID == line.ProductID {
+				return nil, fmt.Errorf("product %d is listed more than once", line.ProductID)
+			}
+		}
+		component := s.productRepo.GetById(line.ProductID)
+		if component == nil {
+			return nil, fmt.Errorf("product %d not found", line.ProductID)
+		}
+		c, err := entity.NewBundleComponent(product.ID, component, line.Quantity)
+		if err != nil {
+			return nil, err
+		}
+		components = append(components, c)
+	}
+	product.Components = components
+
+	if product.BundlePricing == entity.BundlePricingDiscount {
+		price, err := product.ComponentPrice()
+		if err != nil {
+			return nil, err
+		}
+		if price.Currency != product.UnitPrice.Currency && s.hasPendingSalePrices(product.ID) {
+			return nil, errors.New("currency can not be changed while sale prices are scheduled")
+		}
+		product.UnitPrice, product.Currency = price, price.Currency
+	}
+
+	if err := s.saveBundle(&before, product, userName); err != nil {
+		return nil, err
+	}
+	return s.productRepo.GetById(productId), nil
+}
+
+// RemoveBundle makes a bundle a regular product without stock again.
+func (s *StoreService) RemoveBundle(productId uint32, userName string) (*entity.Product, error) {
+	product := s.productRepo.GetById(productId)
+	if product == nil {
+		return nil, errors.New("product not found")
+	}
+	if !product.IsBundle() {
+		return nil, fmt.Errorf("%s is not a bundle", product.Sku)
+	}
+	before := *product
+
+	if err := product.SetBundle("", 0); err != nil {
+		return nil, err
+	}
+	if err := s.saveBundle(&before, product, userName); err != nil {
+		return nil, err
+	}
+	return s.productRepo.GetById(productId), nil
+}
+
+// saveBundle saves the pricing and components of a bundle after releasing
+// the stock baskets reserved for its former components.
+func (s *StoreService) saveBundle(before, product *entity.Product, userName string) error {
+	if before.IsBundle() {
+		for _, item := range s.basketRepo.GetItemsByProductId(product.ID) {
+			basketItem := item
+			basketItem.Product = before
+			if err := s.unreserve(&basketItem); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := s.productRepo.Update(product); err != nil {
+		return errors.New("an unknown error occurred during operation")
+	}
+	if err := s.productRepo.SetComponents(product.ID, product.Components); err != nil {
+		return errors.New("unable to save bundle components")
+	}
+	s.inventoryService.CheckStock(product.ID)
+
+	return recordProductChange(&s.productRepo, userName, entity.RevisionActionUpdated, before, product)
+}
+
+// buildProductAttributes validates attribute values keyed by attribute code
+// against the schema of the given category.
+func (s *StoreService) buildProductAttributes(categoryId uint32, values map[string]string) ([]*entity.ProductAttribute, error) {
+	schema := s.attributeRepo.GetAllByCategoryId(categoryId)
+	byCode := make(map[string]*entity.Attribute, len(schema))
+	for i := range schema {
+		byCode[schema[i].Code] = &schema[i]
+	}
+
+	var productAttributes []*entity.ProductAttribute
+	for code, value := range values {
+		attribute, ok := byCode[strings.ToLower(code)]
+		if !ok {
+			return nil, fmt.Errorf("unknown attribute for category: %s", code)
+		}
+		item, err := entity.NewProductAttribute(0, attribute, value)
+		if err != nil {
+			return nil, err
+		}
+		productAttributes = append(productAttributes, item)
+		delete(byCode, attribute.Code)
+	}
+
+	for code, attribute := range byCode {
+		if attribute.IsRequired {
+			return nil, fmt.Errorf("attribute is required: %s", code)
+		}
+	}
+
+	return productAttributes, nil
+}
+
+// DeleteProduct moves a product to the trash. It is taken out of baskets and
+// the stock they held is returned so a restored product is complete.
+// Components of bundles can not be deleted.
+func (s *StoreService) DeleteProduct(productId uint32, userName string) error {
+	product := s.productRepo.GetById(productId)
+	if product == nil {
+		return errors.New("product not found")
+	}
+	before := *product
+	if bundles := s.productRepo.GetBundles(productId); len(bundles) > 0 {
+		return fmt.Errorf("product is a component of bundle %s", bundles[0].Sku)
+	}
+
+	if err := s.takeOutOfBaskets(product); err != nil {
+		return err
+	}
+
 	err := s.productRepo.DeleteById(productId)
 	if err != nil {
 		return errors.New("an unknown error occurred during operation")
 	}
+	s.inventoryService.CheckStock(productId)
+
+	return recordProductChange(&s.productRepo, userName, entity.RevisionActionDeleted, &before, product)
+}
+
+// takeOutOfBaskets removes a product from all baskets and releases the stock
+// they reserved.
+func (s *StoreService) takeOutOfBaskets(product *entity.Product) error {
+	items := s.basketRepo.GetItemsByProductId(product.ID)
+	if err := s.basketRepo.DeleteItemsByProductId(product.ID); err != nil {
+		return errors.New("unable to remove product from baskets")
+	}
+
+	for _, item := range items {
+		if product.IsBundle() {
+			basketItem := item
+			basketItem.Product = product
+			if err := s.unreserve(&basketItem); err != nil {
+				return err
+			}
+			continue
+		}
+		released, err := s.inventoryService.Release(product.ID, item.BasketID)
+		if err != nil {
+			return err
+		}
+		product.Reserved -= released
+	}
+	return nil
+}
+
+// hasPendingSalePrices reports whether the product has a sale price that is
+// in effect or scheduled.
+func (s *StoreService) hasPendingSalePrices(productId uint32) bool {
+	now := time.Now()
+	for _, v := range s.salePriceRepo.GetAllByProductId(productId) {
+		if v.EndsAt.After(now) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *StoreService) GetSalePrices(productId uint32) []*entity.SalePrice {
+	return s.salePriceRepo.GetAllByProductId(productId)
+}
+
+// CreateSalePrice schedules a sale price. It must be lower than the list
+// price and must not overlap another sale price of the product.
+func (s *StoreService) CreateSalePrice(salePrice *entity.SalePrice) error {
+	product := s.productRepo.GetById(salePrice.ProductID)
+	if product == nil {
+		return errors.New("product not found")
+	}
+
+	c, err := salePrice.Price.Cmp(product.UnitPrice)
+	if err != nil {
+		return errors.New("sale price must be in the currency of the list price")
+	}
+	if c >= 0 {
+		return errors.New("sale price must be lower than the list price")
+	}
+
+	for _, v := range s.salePriceRepo.GetAllByProductId(salePrice.ProductID) {
+		if v.Overlaps(salePrice) {
+			return fmt.Errorf("sale price overlaps another sale price: %d", v.ID)
+		}
+	}
+
+	if err := s.salePriceRepo.Create(salePrice); err != nil {
+		return errors.New("an unknown error occurred during operation")
+	}
+
+	return nil
+}
+
+func (s *StoreService) DeleteSalePrice(productId uint32, salePriceId uint32) error {
+	salePrice := s.salePriceRepo.GetById(salePriceId)
+	if salePrice == nil || salePrice.ProductID != productId {
+		return errors.New("sale price not found")
+	}
+
+	if err := s.salePriceRepo.DeleteById(salePriceId); err != nil {
+		return errors.New("an unknown error occurred during operation")
+	}
 
 	return nil
 }
@@ -142,10 +640,10 @@ func (s *StoreService) GetBasket(userName string) *entity.Basket {
 	return s.basketRepo.Get(userName)
 }
 
-func (s *StoreService) AddItemToBasket(userName string, productId uint32, quantity int) error {
+func (s *StoreService) AddItemToBasket(userName string, productId uint32, quantity measure.Quantity) error {
 	basket := s.basketRepo.Get(userName)
 	if basket == nil {
-		basket, _ := entity.NewBasket(userName)
+		basket, _ = entity.NewBasket(userName)
 		if err := s.basketRepo.Create(basket); err != nil {
 			return errors.New("unable to create basket")
 		}
@@ -156,24 +654,49 @@ func (s *StoreService) AddItemToBasket(userName string, productId uint32, quanti
 		return errors.New("product not found")
 	}
 
-	if product.Quantity < quantity {
+	if !product.IsPublished() || product.IsDigital && !product.IsDownloadable() {
+		return errors.New("product is not available")
+	}
+
+	if err := product.CheckQuantity(quantity); err != nil {
+		return err
+	}
+
+	if !product.IsDigital && product.Available() < quantity {
 		return errors.New("not enough stock")
 	}
 
-	item, _ := entity.NewBasketItem(basket.ID, productId, quantity)
+	for _, v := range basket.Items {
+		if v.Product != nil && v.Product.UnitPrice.Currency != product.UnitPrice.Currency {
+			return errors.New("basket can only contain products of the same currency")
+		}
+	}
+
+	item, err := entity.NewBasketItem(basket.ID, productId, quantity)
+	if err != nil {
+		return err
+	}
 	if err := s.basketRepo.AddItem(item); err != nil {
 		return errors.New("unable to add item to basket")
 	}
 
-	product.Quantity -= quantity
-	if err := s.productRepo.Update(product); err != nil {
-		return errors.New("unable to update product stock info")
+	item.Product = product
+	basket.Items = append(basket.Items, item)
+	if err := s.reserve(basket, product.StockProductIds()); err != nil {
+		basket.Items = basket.Items[:len(basket.Items)-1]
+		if err := s.reserve(basket, product.StockProductIds()); err != nil {
+			return err
+		}
+		if err := s.basketRepo.DeleteItem(item); err != nil {
+			return errors.New("unable to hard remove item")
+		}
+		return err
 	}
 
 	return nil
 }
 
-func (s *StoreService) UpdateItemInBasket(userName string, productId uint32, quantity int) error {
+func (s *StoreService) UpdateItemInBasket(userName string, productId uint32, quantity measure.Quantity) error {
 	basket := s.basketRepo.Get(userName)
 	if basket == nil {
 		return errors.New("basket not found")
@@ -189,16 +712,20 @@ func (s *StoreService) UpdateItemInBasket(userName string, productId uint32, qua
 		return errors.New("product not found")
 	}
 
-	if product.Quantity < quantity {
-		return errors.New("not enough stock")
+	if err := product.CheckQuantity(quantity); err != nil {
+		return err
 	}
 
-	product.Quantity = product.Quantity - (quantity - item.Quantity)
-	if err := s.productRepo.Update(product); err != nil {
-		return errors.New("unable to update product stock info")
+	previous := item.Quantity
+	item.Quantity = quantity
+	if err := s.reserve(basket, product.StockProductIds()); err != nil {
+		item.Quantity = previous
+		if err := s.reserve(basket, product.StockProductIds()); err != nil {
+			return err
+		}
+		return err
 	}
 
-	item.Quantity = quantity
 	if err := s.basketRepo.UpdateItem(item); err != nil {
 		return errors.New("unable to update item info")
 	}
@@ -217,14 +744,8 @@ func (s *StoreService) RemoveItemFromBasket(userName string, productId uint32) e
 		return errors.New("item not found")
 	}
 
-	product := s.productRepo.GetById(productId)
-	if product == nil {
-		return errors.New("product not found")
-	}
-
-	product.Quantity += item.Quantity
-	if err := s.productRepo.Update(product); err != nil {
-		return errors.New("unable to update product stock info")
+	if err := s.unreserve(item); err != nil {
+		return err
 	}
 
 	if err := s.basketRepo.DeleteItem(item); err != nil {
@@ -234,42 +755,280 @@ func (s *StoreService) RemoveItemFromBasket(userName string, productId uint32) e
 	return nil
 }
 
+// reserve sets the reservations of a basket for the given products to the
+// stock its items take.
+func (s *StoreService) reserve(basket *entity.Basket, productIds []uint32) error {
+	demand := basket.Demand()
+	for _, productId := range productIds {
+		if err := s.inventoryService.SetReservation(productId, basket.ID, demand[productId]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unreserve gives back the stock reserved for a basket item: that of its
+// product, or of the components of a bundle.
+func (s *StoreService) unreserve(item *entity.BasketItem) error {
+	demand := map[uint32]measure.Quantity{item.ProductID: item.Quantity}
+	if item.Product != nil {
+		demand = make(map[uint32]measure.Quantity)
+		item.Product.AddStockDemand(demand, item.Quantity)
+	}
+	for _, productId := range sortedIds(demand) {
+		if err := s.inventoryService.Unreserve(productId, item.BasketID, demand[productId]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (s *StoreService) GetAllOrders(userName string) []entity.Order {
-	return s.orderRepo.GetAll(userName)
+	orders := s.orderRepo.GetAll(userName)
+	for i := range orders {
+		s.setOrderSerials(&orders[i])
+		s.downloadService.SetOrderLinks(&orders[i])
+	}
+	return orders
+}
+
+// GetOrderBySerial returns the order the unit with the given serial number
+// was sold for last, or nil when it was not sold.
+func (s *StoreService) GetOrderBySerial(serial string) *entity.Order {
+	serialNumber := s.inventoryService.GetSoldSerial(serial)
+	if serialNumber == nil || serialNumber.OrderID == nil {
+		return nil
+	}
+
+	order := s.orderRepo.GetById(*serialNumber.OrderID)
+	if order != nil {
+		s.setOrderSerials(order)
+	}
+	return order
+}
+
+// FulfillOrder assigns units of the serialized products of an order to its
+// items, serials by product id, and marks the order fulfilled. Every item of
+// a serialized product, or component of a bundle, needs the serials of all
+// its units.
+func (s *StoreService) FulfillOrder(orderId string, serials map[uint32][]string) (*entity.Order, error) {
+	order := s.orderRepo.GetById(orderId)
+	if order == nil {
+		return nil, errors.New("order not found")
+	}
+	switch order.Status {
+	case entity.OrderStatusCanceled:
+		return nil, errors.New("order is canceled")
+	case entity.OrderStatusFulfilled, entity.OrderStatusDelivered:
+		return nil, errors.New("order is already fulfilled")
+	}
+
+	products := make(map[uint32]*entity.Product)
+	for _, item := range order.Items {
+		if len(item.Components) == 0 {
+			products[item.ProductID] = item.Product
+		}
+		for _, c := range item.Components {
+			products[c.ProductID] = c.Product
+		}
+	}
+	for productId, itemSerials := range serials {
+		product, ok := products[productId]
+		if !ok {
+			return nil, fmt.Errorf("product %d is not in the order", productId)
+		}
+		if len(itemSerials) > 0 && (product == nil || !product.IsSerialized()) {
+			return nil, fmt.Errorf("product %d is not serialized", productId)
+		}
+	}
+	demand := order.Demand()
+	for _, productId := range sortedIds(demand) {
+		product := products[productId]
+		if product != nil && product.IsSerialized() && len(serials[productId]) != demand[productId].Whole() {
+			return nil, fmt.Errorf("%s serial numbers are required for %s", demand[productId], product.Sku)
+		}
+	}
+
+	if err := s.inventoryService.AssignSerials(order.ID, serials); err != nil {
+		return nil, err
+	}
+
+	order.Status = entity.OrderStatusFulfilled
+	if err := s.orderRepo.Update(order); err != nil {
+		return nil, errors.New("unable to update order status")
+	}
+	s.setOrderSerials(order)
+
+	return order, nil
+}
+
+// DeliverOrder marks a fulfilled order delivered, once the customer has
+// received it.
+func (s *StoreService) DeliverOrder(orderId string) (*entity.Order, error) {
+	order := s.orderRepo.GetById(orderId)
+	if order == nil {
+		return nil, errors.New("order not found")
+	}
+	switch order.Status {
+	case entity.OrderStatusDelivered:
+		return nil, errors.New("order is already delivered")
+	case entity.OrderStatusFulfilled:
+	default:
+		return nil, fmt.Errorf("order is %s", order.Status)
+	}
+
+	order.Status = entity.OrderStatusDelivered
+	if err := s.orderRepo.Update(order); err != nil {
+		return nil, errors.New("unable to update order status")
+	}
+	s.setOrderSerials(order)
+
+	return order, nil
+}
+
+// setOrderSerials shares the serials assigned to an order out among its
+// items and the components of its bundles.
+func (s *StoreService) setOrderSerials(order *entity.Order) {
+	serials := s.inventoryService.GetOrderSerials(order.ID)
+	take := func(productId uint32, quantity measure.Quantity) []string {
+		n := quantity.Whole()
+		if n > len(serials[productId]) {
+			n = len(serials[productId])
+		}
+		taken := serials[productId][:n]
+		serials[productId] = serials[productId][n:]
+		return taken
+	}
+	for _, item := range order.Items {
+		if len(item.Components) == 0 {
+			item.Serials = take(item.ProductID, item.Quantity)
+		}
+		for _, c := range item.Components {
+			c.Serials = take(c.ProductID, c.Quantity)
+		}
+	}
 }
 
+// CreateOrder orders the items of the basket. The order is charged in
+// currency, or in the currency of the products when it is empty; the exchange
+// rate used is recorded on the order.
 func (s *StoreService) CreateOrder(userName string, name string, address string, phoneNumber string,
-	cardNumber string, cardExp string, cardCVV int) error {
+	cardNumber string, cardExp string, cardCVV int, currency string) error {
 	order, _ := entity.NewOrder(userName, name, address, phoneNumber, cardNumber, cardExp, cardCVV)
 
 	basket := s.basketRepo.Get(userName)
 	if basket == nil {
 		return errors.New("basket not found")
 	}
+	total, err := basket.Total()
+	if err != nil {
+		return errors.New("basket can only contain products of the same currency")
+	}
+
+	priceCurrency := total.Currency
+	if len(priceCurrency) == 0 {
+		priceCurrency = s.currency
+	}
+	currency = strings.ToUpper(currency)
+	if len(currency) == 0 {
+		currency = priceCurrency
+	}
+	rate, err := s.exchangeService.Rate(priceCurrency, currency)
+	if err != nil {
+		return err
+	}
+	rate = order.SetExchangeRate(currency, priceCurrency, rate)
 
+	names := make(map[uint32]string)
+	var downloads []uint32
+	shipped := false
 	for _, v := range basket.Items {
-		item, _ := entity.NewOrderItem("", v.ProductID)
+		if v.Product == nil {
+			return errors.New("product not found")
+		}
+		if !v.Product.IsPublished() || v.Product.IsDigital && !v.Product.IsDownloadable() {
+			return fmt.Errorf("product is not available: %s", v.Product.Name)
+		}
+		if v.Product.IsDigital {
+			downloads = append(downloads, v.ProductID)
+		} else {
+			shipped = true
+		}
+		names[v.ProductID] = v.Product.Name
+		unitPrice := v.Product.EffectivePrice().Convert(currency, rate)
+		item, _ := entity.NewOrderItem("", v.ProductID, v.Quantity, unitPrice)
+		if v.Product.IsBundle() {
+			for _, c := range v.Product.Components {
+				if c.Product == nil {
+					return fmt.Errorf("product is not available: %s", v.Product.Name)
+				}
+				names[c.ProductID] = c.Product.Name
+			}
+			item.SetComponents(v.Product)
+		}
 		if err := order.AddItem(item); err != nil {
 			return errors.New("unable to add order item")
 		}
 	}
 
-	if err := s.orderRepo.Create(order); err != nil {
-		return errors.New("unable to create order")
+	if shipped && len(strings.TrimSpace(address)) == 0 {
+		return errors.New("address is required for products that are shipped")
 	}
-
-	if err := s.basketRepo.DeleteItemsByBasketId(basket.ID); err != nil {
-		return errors.New("unable to clear basket")
+	// Digital products are delivered as downloads; there is nothing to ship.
+	if len(basket.Items) > 0 && !shipped {
+		order.Status = entity.OrderStatusDelivered
 	}
 
-	return nil
+	// The order is placed in one transaction, so that the stock is not sold
+	// and the basket not cleared when the order can not be created.
+	return s.orderRepo.Transaction(func(tx repo.OrderTx) error {
+		inventoryService, downloadService := s.inventoryService, s.downloadService
+		inventoryService.stockRepo, downloadService.downloadRepo = *tx.Stock, *tx.Downloads
+
+		// Reservations may have expired since the items were added.
+		demand := basket.Demand()
+		for _, productId := range sortedIds(demand) {
+			if err := inventoryService.SetReservation(productId, basket.ID, demand[productId]); err != nil {
+				return fmt.Errorf("%s: %s", err.Error(), names[productId])
+			}
+		}
+
+		if err := tx.Orders.Create(order); err != nil {
+			return errors.New("unable to create order")
+		}
+
+		for _, productId := range sortedIds(demand) {
+			if err := inventoryService.Sell(productId, basket.ID, order.ID, userName); err != nil {
+				return err
+			}
+		}
+
+		if err := downloadService.Grant(order.ID, userName, downloads); err != nil {
+			return err
+		}
+
+		if err := tx.Baskets.DeleteItemsByBasketId(basket.ID); err != nil {
+			return errors.New("unable to clear basket")
+		}
+
+		return nil
+	})
 }
 
+// CancelOrder cancels an order that has not been fulfilled yet, putting its
+// stock back and revoking its downloads. Orders that reached the customer,
+// digital ones included, are not canceled; they are returned.
 func (s *StoreService) CancelOrder(userName string, orderId string) error {
 	order := s.orderRepo.Get(userName, orderId)
 	if order == nil {
 		return errors.New("order not found")
 	}
+	switch order.Status {
+	case entity.OrderStatusCanceled:
+		return errors.New("order is already canceled")
+	case entity.OrderStatusFulfilled, entity.OrderStatusDelivered:
+		return fmt.Errorf("order is %s and can no longer be canceled", order.Status)
+	}
 
 	now := time.Now()
 	orderDate := order.CreatedAt.In(time.UTC)
@@ -278,10 +1037,20 @@ func (s *StoreService) CancelOrder(userName string, orderId string) error {
 		return errors.New("order cancel period ended")
 	}
 
-	order.Status = "canceled"
+	order.Status = entity.OrderStatusCanceled
 	if err := s.orderRepo.Update(order); err != nil {
 		return errors.New("unable to update order status")
 	}
 
+	for _, productId := range sortedIds(order.Demand()) {
+		if err := s.inventoryService.Return(productId, order.ID, userName); err != nil {
+			return err
+		}
+	}
+
+	if err := s.downloadService.Revoke(order.ID); err != nil {
+		return err
+	}
+
 	return nil
 }
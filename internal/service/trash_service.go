@@ -0,0 +1,119 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"github.com/bestetufan/beste-store/internal/domain/repo"
+)
+
+// TrashService lists and restores soft deleted catalog entities and users,
+// and permanently removes them once the retention period is over.
+type TrashService struct {
+	categoryRepo    repo.CategoryRepository
+	productRepo     repo.ProductRepository
+	userRepo        repo.UserRepository
+	mediaService    MediaService
+	downloadService DownloadService
+}
+
+func NewTrashService(cr repo.CategoryRepository, pr repo.ProductRepository, ur repo.UserRepository,
+	ms MediaService, ds DownloadService) *TrashService {
+	return &TrashService{
+		categoryRepo:    cr,
+		productRepo:     pr,
+		userRepo:        ur,
+		mediaService:    ms,
+		downloadService: ds,
+	}
+}
+
+func (s *TrashService) GetDeletedProducts(pageIndex, pageSize int) ([]entity.Product, int) {
+	return s.productRepo.GetAllDeleted(pageIndex, pageSize)
+}
+
+func (s *TrashService) GetDeletedCategories(pageIndex, pageSize int) ([]entity.Category, int) {
+	return s.categoryRepo.GetAllDeleted(pageIndex, pageSize)
+}
+
+func (s *TrashService) GetDeletedUsers(pageIndex, pageSize int) ([]entity.User, int) {
+	return s.userRepo.GetAllDeleted(pageIndex, pageSize)
+}
+
+func (s *TrashService) RestoreProduct(productId uint32, userName string) error {
+	product := s.productRepo.GetDeletedById(productId)
+	if product == nil {
+		return errors.New("product not found in trash")
+	}
+
+	if s.categoryRepo.GetById(product.CategoryID) == nil {
+		return errors.New("category of the product is in the trash")
+	}
+
+	if err := s.productRepo.Restore(productId); err != nil {
+		return errors.New("an unknown error occurred during operation")
+	}
+
+	return recordProductChange(&s.productRepo, userName, entity.RevisionActionRestored, product, product)
+}
+
+func (s *TrashService) RestoreCategory(categoryId uint32) error {
+	if s.categoryRepo.GetDeletedById(categoryId) == nil {
+		return errors.New("category not found in trash")
+	}
+
+	if err := s.categoryRepo.Restore(categoryId); err != nil {
+		return errors.New("an unknown error occurred during operation")
+	}
+
+	return nil
+}
+
+func (s *TrashService) RestoreUser(userId int) error {
+	if s.userRepo.GetDeletedById(userId) == nil {
+		return errors.New("user not found in trash")
+	}
+
+	if err := s.userRepo.Restore(userId); err != nil {
+		return errors.New("an unknown error occurred during operation")
+	}
+
+	return nil
+}
+
+// Purge permanently deletes everything that has been in the trash for longer
+// than retention and returns the number of removed records. Products are
+// purged first so that their categories become removable.
+func (s *TrashService) Purge(retention time.Duration) (int, error) {
+	deletedBefore := time.Now().Add(-retention)
+	purged := 0
+
+	for _, product := range s.productRepo.GetPurgeable(deletedBefore) {
+		if err := s.mediaService.DeleteProductImages(product.ID); err != nil {
+			return purged, fmt.Errorf("product %d: %w", product.ID, err)
+		}
+		if err := s.downloadService.DeleteProductFile(product.ID); err != nil {
+			return purged, fmt.Errorf("product %d: %w", product.ID, err)
+		}
+		if err := s.productRepo.Purge(product.ID); err != nil {
+			return purged, fmt.Errorf("product %d: %w", product.ID, err)
+		}
+		purged++
+	}
+
+	count, err := s.categoryRepo.Purge(deletedBefore)
+	purged += count
+	if err != nil {
+		return purged, fmt.Errorf("categories: %w", err)
+	}
+
+	count, err = s.userRepo.Purge(deletedBefore)
+	purged += count
+	if err != nil {
+		return purged, fmt.Errorf("users: %w", err)
+	}
+
+	return purged, nil
+}
@@ -38,7 +38,7 @@ func (s *UserService) UserHasRole(id int, role string) bool {
 
 func (s *UserService) CreateUser(user *entity.User) error {
 	userExists := s.repo.GetByEmail(user.Email)
-	if userExists != nil {
+	if userExists != nil || s.repo.GetDeletedByEmail(user.Email) != nil {
 		return errors.New("user with same email already exist in database")
 	}
 
@@ -49,3 +49,17 @@ func (s *UserService) CreateUser(user *entity.User) error {
 
 	return nil
 }
+
+func (s *UserService) DeleteUser(id int) error {
+	user := s.repo.GetById(id)
+	if user == nil {
+		return errors.New("user not found")
+	}
+
+	err := s.repo.DeleteById(id)
+	if err != nil {
+		return errors.New("an unknown error occurred during operation")
+	}
+
+	return nil
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bestetufan/beste-store/internal/domain/entity"
+	"github.com/bestetufan/beste-store/internal/domain/repo"
+	"github.com/bestetufan/beste-store/pkg/measure"
+	"github.com/bestetufan/beste-store/pkg/notifier"
+)
+
+// EventBackInStock is the event of notifications telling users a product
+// they waited for is available again.
+const EventBackInStock = "back_in_stock"
+
+// BackInStockProduct is sent as the data of back in stock notifications.
+type BackInStockProduct struct {
+	ProductID uint32           `json:"product_id"`
+	Sku       string           `json:"sku"`
+	Name      string           `json:"name"`
+	Unit      string           `json:"unit"`
+	Available measure.Quantity `json:"available" swaggertype:"number"`
+}
+
+// WishlistService keeps the wishlists of users and their subscriptions to
+// products that are out of stock.
+//
+// A background job runs NotifyBackInStock to check the products users wait
+// for. Once the available quantity of a published product is above zero
+// again, every subscriber is notified and the subscription removed.
+type WishlistService struct {
+	wishlistRepo repo.WishlistRepository
+	productRepo  repo.ProductRepository
+	storeService StoreService
+	notifier     notifier.Notifier
+}
+
+func NewWishlistService(wr repo.WishlistRepository, pr repo.ProductRepository, cs StoreService,
+	n notifier.Notifier) *WishlistService {
+	return &WishlistService{
+		wishlistRepo: wr,
+		productRepo:  pr,
+		storeService: cs,
+		notifier:     n,
+	}
+}
+
+// GetWishlist returns the wishlist of a user, last added first.
+func (s *WishlistService) GetWishlist(userName string) []entity.WishlistItem {
+	return s.wishlistRepo.GetAll(userName)
+}
+
+// AddItem adds a product to the wishlist of a user. Products can be added
+// whether they are in stock or not.
+func (s *WishlistService) AddItem(userName string, productId uint32) error {
+	product := s.productRepo.GetById(productId)
+	if product == nil || !product.IsPublished() {
+		return errors.New("product not found")
+	}
+	if s.wishlistRepo.Get(userName, productId) != nil {
+		return errors.New("product is already in wishlist")
+	}
+
+	item, err := entity.NewWishlistItem(userName, productId)
+	if err != nil {
+		return err
+	}
+	if err := s.wishlistRepo.Create(item); err != nil {
+		return errors.New("unable to add item to wishlist")
+	}
+	return nil
+}
+
+func (s *WishlistService) RemoveItem(userName string, productId uint32) error {
+	item := s.wishlistRepo.Get(userName, productId)
+	if item == nil {
+		return errors.New("product is not in wishlist")
+	}
+	if err := s.wishlistRepo.Delete(item); err != nil {
+		return errors.New("unable to remove item from wishlist")
+	}
+	return nil
+}
+
+// MoveToBasket adds quantity of a product in the wishlist of a user to the
+// basket and removes it from the wishlist. It stays in the wishlist when it
+// can not be added, e.g. when there is not enough stock.
+func (s *WishlistService) MoveToBasket(userName string, productId uint32, quantity measure.Quantity) error {
+	item := s.wishlistRepo.Get(userName, productId)
+	if item == nil {
+		return errors.New("product is not in wishlist")
+	}
+	if err := s.storeService.AddItemToBasket(userName, productId, quantity); err != nil {
+		return err
+	}
+	if err := s.wishlistRepo.Delete(item); err != nil {
+		return errors.New("unable to remove item from wishlist")
+	}
+	return nil
+}
+
+// Subscribe asks for a notification to the user when a product that is out
+// of stock becomes available again.
+func (s *WishlistService) Subscribe(userName string, productId uint32) error {
+	product := s.productRepo.GetById(productId)
+	if product == nil || !product.IsPublished() {
+		return errors.New("product not found")
+	}
+	if product.IsDigital {
+		return errors.New("digital products are never out of stock")
+	}
+	if product.Available() > 0 {
+		return errors.New("product is in stock")
+	}
+	if s.wishlistRepo.GetSubscription(userName, productId) != nil {
+		return errors.New("already subscribed to product")
+	}
+
+	subscription, err := entity.NewStockSubscription(userName, productId)
+	if err != nil {
+		return err
+	}
+	if err := s.wishlistRepo.CreateSubscription(subscription); err != nil {
+		return errors.New("unable to subscribe to product")
+	}
+	return nil
+}
+
+func (s *WishlistService) Unsubscribe(userName string, productId uint32) error {
+	subscription := s.wishlistRepo.GetSubscription(userName, productId)
+	if subscription == nil {
+		return errors.New("not subscribed to product")
+	}
+	if err := s.wishlistRepo.DeleteSubscription(subscription); err != nil {
+		return errors.New("unable to unsubscribe from product")
+	}
+	return nil
+}
+
+// NotifyBackInStock notifies the subscribers of the products available
+// again and returns how many notifications were sent. Subscriptions that
+// could not be notified are tried again on the next run.
+func (s *WishlistService) NotifyBackInStock(ctx context.Context) (int, error) {
+	count := 0
+	for _, product := range s.productRepo.GetByIds(s.wishlistRepo.GetSubscribedProductIds()) {
+		if !product.IsPublished() || product.Available() <= 0 {
+			continue
+		}
+		for _, subscription := range s.wishlistRepo.GetSubscriptions(product.ID) {
+			if err := s.notifier.Notify(ctx, newBackInStockNotification(&product, subscription.UserName)); err != nil {
+				return count, fmt.Errorf("product %d: %w", product.ID, err)
+			}
+			if err := s.wishlistRepo.DeleteSubscription(&subscription); err != nil {
+				return count, fmt.Errorf("product %d: %w", product.ID, err)
+			}
+			count++
+		}
+	}
+	return count, nil
+}
+
+func newBackInStockNotification(product *entity.Product, userName string) notifier.Notification {
+	return notifier.Notification{
+		Event:   EventBackInStock,
+		To:      []string{userName},
+		Subject: fmt.Sprintf("Back in stock: %s", product.Name),
+		Text:    fmt.Sprintf("%s (SKU %s) you were waiting for is back in stock.", product.Name, product.Sku),
+		Data: BackInStockProduct{
+			ProductID: product.ID,
+			Sku:       product.Sku,
+			Name:      product.Name,
+			Unit:      product.Unit,
+			Available: product.Available(),
+		},
+		Time: time.Now(),
+	}
+}
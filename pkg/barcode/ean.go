@@ -0,0 +1,79 @@
+package barcode
+
+// Left-hand odd (L) and even (G) parity and right-hand (R) patterns of each
+// digit, seven modules each.
+var (
+	lCodes = [10]string{"0001101", "0011001", "0010011", "0111101", "0100011", "0110001", "0101111", "0111011", "0110111", "0001011"}
+	gCodes = [10]string{"0100111", "0110011", "0011011", "0100001", "0011101", "0111001", "0000101", "0010001", "0001001", "0010111"}
+	rCodes = [10]string{"1110010", "1100110", "1101100", "1000010", "1011100", "1001110", "1010000", "1000100", "1001000", "1110100"}
+)
+
+// firstDigitParity encodes the first digit of an EAN-13 in the parity of the
+// six left-hand digits.
+var firstDigitParity = [10]string{"LLLLLL", "LLGLGG", "LLGGLG", "LLGGGL", "LGLLGG", "LGGLLG", "LGGGLL", "LGLGLG", "LGLGGL", "LGGLGL"}
+
+const (
+	guard       = "101"
+	centreGuard = "01010"
+)
+
+// Quiet zones an EAN-13 needs on each side, in modules. EAN-8 needs seven on
+// both sides, so these fit either.
+const (
+	QuietLeft  = 11
+	QuietRight = 7
+)
+
+// Symbol is an encoded EAN-13 or EAN-8 barcode.
+type Symbol struct {
+	Code string
+	// Modules holds whether each module is a bar.
+	Modules []bool
+	// Guards holds whether each module belongs to a guard pattern, which is
+	// drawn longer than the digits.
+	Guards []bool
+}
+
+// Encode returns the EAN-13 or EAN-8 symbol of a GTIN. UPC-A codes are
+// encoded as EAN-13, which scanners read back as UPC-A.
+func Encode(code string) (*Symbol, error) {
+	code, err := ParseGTIN(code)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Symbol{Code: code}
+	s.add(guard, true)
+	if len(code) == 13 {
+		parity := firstDigitParity[code[0]-'0']
+		for i := 1; i <= 6; i++ {
+			if parity[i-1] == 'G' {
+				s.add(gCodes[code[i]-'0'], false)
+			} else {
+				s.add(lCodes[code[i]-'0'], false)
+			}
+		}
+		s.add(centreGuard, true)
+		for i := 7; i <= 12; i++ {
+			s.add(rCodes[code[i]-'0'], false)
+		}
+	} else {
+		for i := 0; i < 4; i++ {
+			s.add(lCodes[code[i]-'0'], false)
+		}
+		s.add(centreGuard, true)
+		for i := 4; i < 8; i++ {
+			s.add(rCodes[code[i]-'0'], false)
+		}
+	}
+	s.add(guard, true)
+
+	return s, nil
+}
+
+func (s *Symbol) add(pattern string, isGuard bool) {
+	for i := 0; i < len(pattern); i++ {
+		s.Modules = append(s.Modules, pattern[i] == '1')
+		s.Guards = append(s.Guards, isGuard)
+	}
+}
@@ -0,0 +1,55 @@
+package barcode
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseGTIN checks an EAN-8, UPC-A or EAN-13 code and its check digit.
+// Spaces and hyphens are ignored. UPC-A codes are returned as EAN-13 with a
+// leading zero so that the same item scans to the same GTIN either way.
+func ParseGTIN(code string) (string, error) {
+	code = strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(code))
+	if !digits(code) {
+		return "", fmt.Errorf("GTIN can only contain digits: %q", code)
+	}
+
+	switch len(code) {
+	case 8, 13:
+	case 12:
+		code = "0" + code
+	default:
+		return "", fmt.Errorf("GTIN must have 8, 12 or 13 digits: %q", code)
+	}
+
+	if checkDigit(code[:len(code)-1]) != code[len(code)-1] {
+		return "", fmt.Errorf("invalid GTIN check digit: %q", code)
+	}
+	return code, nil
+}
+
+// checkDigit returns the GS1 check digit of a GTIN without its check digit:
+// digits are weighted 3 and 1 alternately starting from the right.
+func checkDigit(code string) byte {
+	sum := 0
+	for i := 0; i < len(code); i++ {
+		d := int(code[len(code)-1-i] - '0')
+		if i%2 == 0 {
+			d *= 3
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+func digits(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,59 @@
+package barcode
+
+import "testing"
+
+func TestParseGTIN(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    string
+		want    string
+		wantErr bool
+	}{
+		{name: "ean-13", code: "4006381333931", want: "4006381333931"},
+		{name: "ean-13 gs1 example", code: "5901234123457", want: "5901234123457"},
+		{name: "ean-8", code: "96385074", want: "96385074"},
+		{name: "upc-a as ean-13", code: "036000291452", want: "0036000291452"},
+		{name: "upc-a written as ean-13", code: "0036000291452", want: "0036000291452"},
+		{name: "spaces and hyphens", code: " 400-6381 333931 ", want: "4006381333931"},
+		{name: "ean-13 wrong check digit", code: "4006381333932", wantErr: true},
+		{name: "ean-8 wrong check digit", code: "96385075", wantErr: true},
+		{name: "upc-a wrong check digit", code: "036000291453", wantErr: true},
+		{name: "too short", code: "1234567", wantErr: true},
+		{name: "gtin-14", code: "10036000291459", wantErr: true},
+		{name: "letters", code: "40063813339A1", wantErr: true},
+		{name: "empty", code: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseGTIN(tt.code)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseGTIN(%q) error = %v, wantErr %v", tt.code, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseGTIN(%q) = %q, want %q", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckDigit(t *testing.T) {
+	tests := []struct {
+		code string
+		want byte
+	}{
+		{"400638133393", '1'},
+		{"590123412345", '7'},
+		{"9638507", '4'},
+		{"003600029145", '2'},
+		{"000000000000", '0'},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			if got := checkDigit(tt.code); got != tt.want {
+				t.Errorf("checkDigit(%q) = %c, want %c", tt.code, got, tt.want)
+			}
+		})
+	}
+}
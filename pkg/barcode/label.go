@@ -0,0 +1,53 @@
+package barcode
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Label is a printable label: a title and a subtitle above the barcode of a
+// GTIN.
+type Label struct {
+	Title    string
+	Subtitle string
+	Code     string
+}
+
+// digitPositions returns the digits printed under a symbol and the module
+// each one is centred on, counted from the first module of the start guard.
+// The first digit of an EAN-13 is printed in the left quiet zone.
+func digitPositions(s *Symbol) ([]byte, []float64) {
+	code := s.Code
+	var digits []byte
+	var positions []float64
+	if len(code) == 13 {
+		digits = append(digits, code[0])
+		positions = append(positions, -float64(QuietLeft)/2)
+		code = code[1:]
+	}
+
+	half := len(code) / 2
+	for i := 0; i < len(code); i++ {
+		start := len(guard) + i*7
+		if i >= half {
+			start += len(centreGuard)
+		}
+		digits = append(digits, code[i])
+		positions = append(positions, float64(start)+3.5)
+	}
+	return digits, positions
+}
+
+// asciiFold replaces the letters of Turkish that common label fonts lack.
+var asciiFold = strings.NewReplacer(
+	"ğ", "g", "Ğ", "G", "ş", "s", "Ş", "S", "ı", "i", "İ", "I",
+)
+
+// truncate shortens s to at most n characters, marking the cut with dots.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	r := []rune(s)
+	return strings.TrimSpace(string(r[:n-3])) + "..."
+}
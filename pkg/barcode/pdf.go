@@ -0,0 +1,152 @@
+package barcode
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// The PDF labels are laid out on A4 sheets of 3 x 8 labels of 70 x 37 mm,
+// a common self-adhesive label stock. Lengths are in points.
+const (
+	pdfPageWidth   = 595.28
+	pdfPageHeight  = 841.89
+	pdfColumns     = 3
+	pdfRows        = 8
+	pdfLabelWidth  = 70 / 25.4 * 72
+	pdfLabelHeight = 37 / 25.4 * 72
+	pdfPadding     = 10
+	pdfTitleSize   = 9
+	pdfTextSize    = 8
+	pdfModule      = 0.33 / 25.4 * 72
+	pdfBarHeight   = 45
+	pdfGuardLong   = 5 * pdfModule
+	// Helvetica draws every digit 556/1000 em wide.
+	pdfDigitWidth = 0.556
+	pdfTitleChars = 36
+)
+
+// WritePDF writes the labels as a PDF document, 24 to an A4 page.
+func WritePDF(w io.Writer, labels []Label) error {
+	symbols, err := encodeLabels(labels)
+	if err != nil {
+		return err
+	}
+
+	perPage := pdfColumns * pdfRows
+	var pages [][]byte
+	for start := 0; start < len(labels) || start == 0; start += perPage {
+		var content bytes.Buffer
+		for i := start; i < minInt(start+perPage, len(labels)); i++ {
+			n := i - start
+			x := (pdfPageWidth-pdfColumns*pdfLabelWidth)/2 + float64(n%pdfColumns)*pdfLabelWidth
+			top := pdfPageHeight - (pdfPageHeight-pdfRows*pdfLabelHeight)/2 - float64(n/pdfColumns)*pdfLabelHeight
+			writePDFLabel(&content, x, top, labels[i], symbols[i])
+		}
+		pages = append(pages, content.Bytes())
+	}
+
+	return writePDFDocument(w, pages)
+}
+
+func writePDFLabel(b *bytes.Buffer, x, top float64, l Label, s *Symbol) {
+	y := top - pdfPadding - pdfTitleSize
+	writePDFText(b, pdfTitleSize, x+pdfPadding, y, truncate(l.Title, pdfTitleChars))
+	y -= pdfTextSize + 4
+	writePDFText(b, pdfTextSize, x+pdfPadding, y, truncate(l.Subtitle, pdfTitleChars))
+	y -= 6
+
+	left := x + (pdfLabelWidth-float64(len(s.Modules))*pdfModule)/2
+	for m := 0; m < len(s.Modules); m++ {
+		if !s.Modules[m] {
+			continue
+		}
+		// Draw adjacent bar modules of the same height as one rectangle.
+		end := m + 1
+		for end < len(s.Modules) && s.Modules[end] && s.Guards[end] == s.Guards[m] {
+			end++
+		}
+		h := float64(pdfBarHeight)
+		if s.Guards[m] {
+			h += pdfGuardLong
+		}
+		fmt.Fprintf(b, "%s %s %s %s re\n", num(left+float64(m)*pdfModule), num(y-h), num(float64(end-m)*pdfModule), num(h))
+		m = end - 1
+	}
+	b.WriteString("f\n")
+
+	digits, positions := digitPositions(s)
+	y -= pdfBarHeight + pdfTextSize + 1
+	for i, d := range digits {
+		dx := left + positions[i]*pdfModule - pdfDigitWidth*pdfTextSize/2
+		writePDFText(b, pdfTextSize, dx, y, string(d))
+	}
+}
+
+func writePDFText(b *bytes.Buffer, size float64, x, y float64, s string) {
+	if len(s) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "BT /F1 %s Tf %s %s Td (%s) Tj ET\n", num(size), num(x), num(y), pdfString(s))
+}
+
+// pdfString encodes s in WinAnsiEncoding, escaping the characters PDF string
+// literals reserve. Characters the encoding lacks are folded to ASCII where
+// possible and replaced with a question mark otherwise.
+func pdfString(s string) []byte {
+	var b []byte
+	for _, r := range asciiFold.Replace(s) {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b = append(b, '\\', byte(r))
+		case r >= 0x20 && r < 0x7f, r >= 0xa0 && r <= 0xff:
+			b = append(b, byte(r))
+		case r == '€':
+			b = append(b, 0x80)
+		default:
+			b = append(b, '?')
+		}
+	}
+	return b
+}
+
+// writePDFDocument writes a PDF with one page per content stream, all using
+// the standard Helvetica font.
+func writePDFDocument(w io.Writer, pages [][]byte) error {
+	var b bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, b.Len())
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	b.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	kids := make([]byte, 0, len(pages)*8)
+	for i := range pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R ", 4+2*i)...)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [ %s] /Count %d >>", kids, len(pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	for i, content := range pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			num(pdfPageWidth), num(pdfPageHeight), 5+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+func num(f float64) string {
+	return strconv.FormatFloat(f, 'f', 2, 64)
+}
@@ -0,0 +1,139 @@
+package barcode
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+// Dimensions of a PNG label in pixels.
+const (
+	pngModule     = 3
+	pngTextScale  = 2
+	pngPadding    = 12
+	pngBarHeight  = 40 * pngModule
+	pngGuardLong  = 5 * pngModule
+	pngColumns    = 3
+	pngCellWidth  = (QuietLeft + 95 + QuietRight) * pngModule
+	pngLineGap    = 6
+	pngTextLine   = glyphHeight * pngTextScale
+	pngCellHeight = pngPadding + 2*(pngTextLine+pngLineGap) + pngBarHeight + pngGuardLong + pngTextLine + pngPadding
+)
+
+var (
+	pngBackground = color.Gray{Y: 0xff}
+	pngInk        = color.Gray{Y: 0x00}
+	pngCutLine    = color.Gray{Y: 0xd0}
+)
+
+// WritePNG draws the labels on a sheet, three to a row, with light grey cut
+// lines between them.
+func WritePNG(w io.Writer, labels []Label) error {
+	symbols, err := encodeLabels(labels)
+	if err != nil {
+		return err
+	}
+
+	columns := minInt(pngColumns, len(labels))
+	rows := (len(labels) + pngColumns - 1) / pngColumns
+	img := image.NewGray(image.Rect(0, 0, maxInt(1, columns*pngCellWidth), maxInt(1, rows*pngCellHeight)))
+	fill(img, img.Bounds(), pngBackground)
+
+	for i, l := range labels {
+		x0 := (i % pngColumns) * pngCellWidth
+		y0 := (i / pngColumns) * pngCellHeight
+		drawPNGLabel(img, x0, y0, l, symbols[i])
+	}
+
+	return png.Encode(w, img)
+}
+
+func drawPNGLabel(img *image.Gray, x0, y0 int, l Label, s *Symbol) {
+	cell := image.Rect(x0, y0, x0+pngCellWidth, y0+pngCellHeight)
+	fill(img, image.Rect(cell.Max.X-1, cell.Min.Y, cell.Max.X, cell.Max.Y), pngCutLine)
+	fill(img, image.Rect(cell.Min.X, cell.Max.Y-1, cell.Max.X, cell.Max.Y), pngCutLine)
+
+	chars := (pngCellWidth - 2*pngPadding) / (glyphAdvance * pngTextScale)
+	y := y0 + pngPadding
+	drawText(img, x0+pngPadding, y, truncate(l.Title, chars))
+	y += pngTextLine + pngLineGap
+	drawText(img, x0+pngPadding, y, truncate(l.Subtitle, chars))
+	y += pngTextLine + pngLineGap
+
+	// EAN-8 symbols are narrower than EAN-13 ones; centre them in the space
+	// an EAN-13 would take.
+	left := x0 + (QuietLeft+(95-len(s.Modules))/2)*pngModule
+	for m, bar := range s.Modules {
+		if !bar {
+			continue
+		}
+		h := pngBarHeight
+		if s.Guards[m] {
+			h += pngGuardLong
+		}
+		fill(img, image.Rect(left+m*pngModule, y, left+(m+1)*pngModule, y+h), pngInk)
+	}
+
+	digits, positions := digitPositions(s)
+	y += pngBarHeight + pngModule
+	for i, d := range digits {
+		x := left + int(positions[i]*pngModule) - glyphWidth*pngTextScale/2
+		drawGlyph(img, x, y, rune(d))
+	}
+}
+
+func drawText(img *image.Gray, x, y int, s string) {
+	for _, r := range glyphText(s) {
+		drawGlyph(img, x, y, r)
+		x += glyphAdvance * pngTextScale
+	}
+}
+
+func drawGlyph(img *image.Gray, x, y int, r rune) {
+	g := glyphs[r]
+	for row := 0; row < glyphHeight; row++ {
+		for col := 0; col < glyphWidth; col++ {
+			if g[row]&(1<<(glyphWidth-1-col)) == 0 {
+				continue
+			}
+			px, py := x+col*pngTextScale, y+row*pngTextScale
+			fill(img, image.Rect(px, py, px+pngTextScale, py+pngTextScale), pngInk)
+		}
+	}
+}
+
+func fill(img *image.Gray, r image.Rectangle, c color.Gray) {
+	r = r.Intersect(img.Bounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetGray(x, y, c)
+		}
+	}
+}
+
+func encodeLabels(labels []Label) ([]*Symbol, error) {
+	symbols := make([]*Symbol, len(labels))
+	for i, l := range labels {
+		s, err := Encode(l.Code)
+		if err != nil {
+			return nil, err
+		}
+		symbols[i] = s
+	}
+	return symbols, nil
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -2,6 +2,8 @@ package httpserver
 
 import (
 	"context"
+	"errors"
+	"net"
 	"net/http"
 	"time"
 )
@@ -27,6 +29,7 @@ func New(handler http.Handler, opts ...Option) *Server {
 		ReadTimeout:  _defaultReadTimeout,
 		WriteTimeout: _defaultWriteTimeout,
 		Addr:         _defaultAddr,
+		ConnContext:  withConn,
 	}
 
 	s := &Server{
@@ -64,3 +67,33 @@ func (s *Server) Shutdown() error {
 
 	return s.server.Shutdown(ctx)
 }
+
+type connKey struct{}
+
+// ErrNoConn is returned when the connection of a request is unknown, e.g.
+// for requests not served by a Server.
+var ErrNoConn = errors.New("httpserver: connection of request unknown")
+
+func withConn(ctx context.Context, c net.Conn) context.Context {
+	return context.WithValue(ctx, connKey{}, c)
+}
+
+// SetReadDeadline sets the deadline for reading the body of a request,
+// replacing the one set by the read timeout of the server.
+func SetReadDeadline(r *http.Request, deadline time.Time) error {
+	c, ok := r.Context().Value(connKey{}).(net.Conn)
+	if !ok {
+		return ErrNoConn
+	}
+	return c.SetReadDeadline(deadline)
+}
+
+// SetWriteDeadline sets the deadline for writing the response to a request,
+// replacing the one set by the write timeout of the server.
+func SetWriteDeadline(r *http.Request, deadline time.Time) error {
+	c, ok := r.Context().Value(connKey{}).(net.Conn)
+	if !ok {
+		return ErrNoConn
+	}
+	return c.SetWriteDeadline(deadline)
+}
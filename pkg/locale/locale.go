@@ -0,0 +1,141 @@
+package locale
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Parse checks a BCP 47 language tag such as "en" or "pt-BR" and returns it
+// in canonical case: language lowercase, region uppercase and the other
+// subtags lowercase.
+func Parse(tag string) (string, error) {
+	tag = strings.TrimSpace(strings.ReplaceAll(tag, "_", "-"))
+	if len(tag) == 0 || len(tag) > 35 {
+		return "", fmt.Errorf("invalid language tag: %q", tag)
+	}
+
+	subtags := strings.Split(tag, "-")
+	for i, subtag := range subtags {
+		if len(subtag) == 0 || len(subtag) > 8 || !isAlphanumeric(subtag) {
+			return "", fmt.Errorf("invalid language tag: %q", tag)
+		}
+		switch {
+		case i == 0:
+			if len(subtag) < 2 || !isAlpha(subtag) {
+				return "", fmt.Errorf("invalid language tag: %q", tag)
+			}
+			subtags[i] = strings.ToLower(subtag)
+		case len(subtag) == 2 && isAlpha(subtag):
+			subtags[i] = strings.ToUpper(subtag)
+		case len(subtag) == 4 && isAlpha(subtag) && i == 1:
+			subtags[i] = strings.ToUpper(subtag[:1]) + strings.ToLower(subtag[1:])
+		default:
+			subtags[i] = strings.ToLower(subtag)
+		}
+	}
+	return strings.Join(subtags, "-"), nil
+}
+
+// Base returns the language subtag of a tag, e.g. "en" for "en-GB".
+func Base(tag string) string {
+	if i := strings.IndexByte(tag, '-'); i >= 0 {
+		return tag[:i]
+	}
+	return tag
+}
+
+// Negotiate returns the supported locale that best matches an Accept-Language
+// header such as "en-GB,en;q=0.8,tr;q=0.5". Languages are tried by
+// decreasing quality, each first exactly and then by its base language, so
+// "en-GB" matches "en" and "en" matches "en-US". fallback is returned when
+// nothing matches. Invalid entries are ignored.
+func Negotiate(header string, supported []string, fallback string) string {
+	type accepted struct {
+		tag     string
+		quality float64
+	}
+
+	var languages []accepted
+	for _, entry := range strings.Split(header, ",") {
+		parts := strings.Split(entry, ";")
+		quality := 1.0
+		for _, param := range parts[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+			q, err := strconv.ParseFloat(param[2:], 64)
+			if err != nil || q < 0 || q > 1 {
+				quality = 0
+				break
+			}
+			quality = q
+		}
+		if quality == 0 {
+			continue
+		}
+
+		name := strings.TrimSpace(parts[0])
+		if name == "*" {
+			languages = append(languages, accepted{fallback, quality})
+			continue
+		}
+		tag, err := Parse(name)
+		if err != nil {
+			continue
+		}
+		languages = append(languages, accepted{tag, quality})
+	}
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].quality > languages[j].quality
+	})
+
+	for _, language := range languages {
+		if match := find(supported, language.tag); len(match) > 0 {
+			return match
+		}
+	}
+	return fallback
+}
+
+// find returns the supported locale matching tag exactly or else by base
+// language.
+func find(supported []string, tag string) string {
+	for _, s := range supported {
+		if strings.EqualFold(s, tag) {
+			return s
+		}
+	}
+	base := Base(tag)
+	for _, s := range supported {
+		if strings.EqualFold(s, base) {
+			return s
+		}
+	}
+	for _, s := range supported {
+		if strings.EqualFold(Base(s), base) {
+			return s
+		}
+	}
+	return ""
+}
+
+func isAlpha(s string) bool {
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlphanumeric(s string) bool {
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
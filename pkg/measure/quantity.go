@@ -0,0 +1,131 @@
+package measure
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// decimals is the number of decimal places a quantity is kept with.
+const decimals = 3
+
+// Quantity is an amount of a product in thousandths of its unit of measure,
+// e.g. 1500 is 1.5 kg of a product sold by the kilogram and 2000 is two
+// pieces. Keeping quantities as integers keeps stock arithmetic exact.
+//
+// In JSON a Quantity is written as a decimal number (1.5).
+type Quantity int64
+
+// Unit is one whole unit.
+const Unit Quantity = 1000
+
+// Units returns n whole units.
+func Units(n int) Quantity {
+	return Quantity(n) * Unit
+}
+
+// Parse reads a decimal quantity such as "1.5" or "1,5". It fails instead of
+// rounding when the quantity has more than three decimal places.
+func Parse(value string) (Quantity, error) {
+	s := strings.TrimSpace(strings.Replace(value, ",", ".", 1))
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+
+	whole, fraction := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, fraction = s[:i], s[i+1:]
+	}
+	if len(whole) == 0 && len(fraction) == 0 || !digits(whole) || !digits(fraction) {
+		return 0, fmt.Errorf("invalid quantity: %q", value)
+	}
+	if len(strings.TrimRight(fraction, "0")) > decimals {
+		return 0, fmt.Errorf("quantity has more than %d decimal places: %q", decimals, value)
+	}
+	fraction = (fraction + strings.Repeat("0", decimals))[:decimals]
+
+	n, err := strconv.ParseInt("0"+whole+fraction, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid quantity: %q", value)
+	}
+	if negative {
+		n = -n
+	}
+
+	return Quantity(n), nil
+}
+
+func digits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the quantity as a decimal number without trailing zeros,
+// e.g. "1.5" or "2".
+func (q Quantity) String() string {
+	n := int64(q)
+	sign := ""
+	if n < 0 {
+		sign, n = "-", -n
+	}
+
+	s := strconv.FormatInt(n, 10)
+	if len(s) <= decimals {
+		s = strings.Repeat("0", decimals-len(s)+1) + s
+	}
+	whole, fraction := s[:len(s)-decimals], strings.TrimRight(s[len(s)-decimals:], "0")
+	if len(fraction) == 0 {
+		return sign + whole
+	}
+	return sign + whole + "." + fraction
+}
+
+// IsWhole reports whether the quantity is a whole number of units.
+func (q Quantity) IsWhole() bool {
+	return q%Unit == 0
+}
+
+// Whole returns the number of whole units in the quantity.
+func (q Quantity) Whole() int {
+	return int(q / Unit)
+}
+
+// IsMultipleOf reports whether the quantity is a whole number of steps.
+func (q Quantity) IsMultipleOf(step Quantity) bool {
+	return step > 0 && q%step == 0
+}
+
+// Rat returns the quantity in units, e.g. to multiply a unit price with it.
+func (q Quantity) Rat() *big.Rat {
+	return big.NewRat(int64(q), int64(Unit))
+}
+
+func (q Quantity) MarshalJSON() ([]byte, error) {
+	return []byte(q.String()), nil
+}
+
+// UnmarshalJSON reads a decimal number or a string holding one.
+func (q *Quantity) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "null" {
+		return nil
+	}
+	v, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*q = v
+	return nil
+}
+
+// Min returns the smaller of two quantities.
+func Min(a, b Quantity) Quantity {
+	if a < b {
+		return a
+	}
+	return b
+}
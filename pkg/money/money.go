@@ -0,0 +1,226 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// exponents lists the supported ISO 4217 currencies with the number of digits
+// of their minor unit.
+var exponents = map[string]int{
+	"TRY": 2,
+	"USD": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"CHF": 2,
+	"SEK": 2,
+	"NOK": 2,
+	"DKK": 2,
+	"PLN": 2,
+	"RUB": 2,
+	"AZN": 2,
+	"CAD": 2,
+	"AUD": 2,
+	"CNY": 2,
+	"JPY": 0,
+	"KRW": 0,
+	"KWD": 3,
+	"BHD": 3,
+}
+
+// symbols lists the signs prices are displayed with; other currencies are
+// displayed with their code.
+var symbols = map[string]string{
+	"TRY": "₺",
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"JPY": "¥",
+}
+
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+// Money is an amount in the minor unit of a currency, e.g. 1050 TRY is 10.50
+// lira. Columns are stored as <prefix>Amount and <prefix>Currency when the
+// type is embedded with gorm:"embedded;embeddedPrefix:<prefix>".
+//
+// In JSON a Money is written as a decimal number (10.50) so that clients
+// reading prices as numbers keep working; the currency is exposed next to it.
+type Money struct {
+	Amount   int64  `gorm:"not null;default:0"`
+	Currency string `gorm:"size:3;not null;default:''"`
+}
+
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// Valid reports whether currency is a supported ISO 4217 code.
+func Valid(currency string) bool {
+	_, ok := exponents[currency]
+	return ok
+}
+
+// Symbol returns the sign of currency, e.g. "₺" for TRY, or the code itself
+// when it has none.
+func Symbol(currency string) string {
+	if s, ok := symbols[currency]; ok {
+		return s
+	}
+	return currency
+}
+
+// Exponent returns the number of minor unit digits of currency. Unknown
+// currencies use two digits.
+func Exponent(currency string) int {
+	if e, ok := exponents[currency]; ok {
+		return e
+	}
+	return 2
+}
+
+// Parse reads a decimal amount such as "10.5" or "10,50" in currency. It
+// fails instead of rounding when the amount has more digits than the minor
+// unit of the currency.
+func Parse(value string, currency string) (Money, error) {
+	if !Valid(currency) {
+		return Money{}, fmt.Errorf("unknown currency: %q", currency)
+	}
+
+	s := strings.TrimSpace(strings.Replace(value, ",", ".", 1))
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+
+	whole, fraction := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, fraction = s[:i], s[i+1:]
+	}
+	exp := Exponent(currency)
+	if len(whole) == 0 && len(fraction) == 0 || !digits(whole) || !digits(fraction) {
+		return Money{}, fmt.Errorf("invalid amount: %q", value)
+	}
+	if len(strings.TrimRight(fraction, "0")) > exp {
+		return Money{}, fmt.Errorf("amount has more than %d decimal places: %q", exp, value)
+	}
+	fraction = (fraction + strings.Repeat("0", exp))[:exp]
+
+	amount, err := strconv.ParseInt("0"+whole+fraction, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid amount: %q", value)
+	}
+	if negative {
+		amount = -amount
+	}
+
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+func digits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the amount as a decimal number without the currency, e.g.
+// "10.50".
+func (m Money) String() string {
+	exp := Exponent(m.Currency)
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+
+	s := strconv.FormatInt(amount, 10)
+	if exp == 0 {
+		return sign + s
+	}
+	if len(s) <= exp {
+		s = strings.Repeat("0", exp-len(s)+1) + s
+	}
+	return sign + s[:len(s)-exp] + "." + s[len(s)-exp:]
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// Add returns m+o. Amounts in different currencies can not be added; a zero
+// amount without currency takes the currency of the other operand.
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency == "" && m.Amount == 0 {
+		return o, nil
+	}
+	if o.Currency == "" && o.Amount == 0 {
+		return m, nil
+	}
+	if m.Currency != o.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	return Money{Amount: m.Amount + o.Amount, Currency: m.Currency}, nil
+}
+
+// Mul returns m multiplied by n, e.g. the total of n items at price m.
+func (m Money) Mul(n int) Money {
+	return Money{Amount: m.Amount * int64(n), Currency: m.Currency}
+}
+
+// Cmp compares two amounts of the same currency and returns -1, 0 or 1.
+func (m Money) Cmp(o Money) (int, error) {
+	if m.Currency != o.Currency {
+		return 0, ErrCurrencyMismatch
+	}
+	switch {
+	case m.Amount < o.Amount:
+		return -1, nil
+	case m.Amount > o.Amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Convert returns m in currency at rate, the value of one unit of m.Currency
+// in currency. The exact result is rounded half away from zero to the minor
+// unit of currency.
+func (m Money) Convert(currency string, rate *big.Rat) Money {
+	v := new(big.Rat).SetFrac(big.NewInt(m.Amount), pow10(Exponent(m.Currency)))
+	v.Mul(v, rate)
+	v.Mul(v, new(big.Rat).SetInt(pow10(Exponent(currency))))
+
+	return Money{Amount: round(v), Currency: currency}
+}
+
+// MulRat returns m multiplied by r, e.g. the total of 1.5 kg at price m per
+// kilogram, rounded half away from zero to the minor unit.
+func (m Money) MulRat(r *big.Rat) Money {
+	v := new(big.Rat).SetInt64(m.Amount)
+	v.Mul(v, r)
+
+	return Money{Amount: round(v), Currency: m.Currency}
+}
+
+// round rounds v half away from zero to an integer.
+func round(v *big.Rat) int64 {
+	q, r := new(big.Int).QuoRem(new(big.Int).Abs(v.Num()), v.Denom(), new(big.Int))
+	if r.Lsh(r, 1).Cmp(v.Denom()) >= 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	if v.Sign() < 0 {
+		q.Neg(q)
+	}
+	return q.Int64()
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
@@ -0,0 +1,93 @@
+package money
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		num, denom int64
+		want       int64
+	}{
+		{0, 1, 0},
+		{7, 1, 7},
+		{5, 2, 3},
+		{-5, 2, -3},
+		{7, 2, 4},
+		{-7, 2, -4},
+		{249, 100, 2},
+		{250, 100, 3},
+		{251, 100, 3},
+		{-249, 100, -2},
+		{-250, 100, -3},
+		{-251, 100, -3},
+		{1, 3, 0},
+		{2, 3, 1},
+		{-1, 3, 0},
+		{-2, 3, -1},
+		{1, 2, 1},
+		{-1, 2, -1},
+	}
+
+	for _, tt := range tests {
+		v := big.NewRat(tt.num, tt.denom)
+		t.Run(v.String(), func(t *testing.T) {
+			if got := round(v); got != tt.want {
+				t.Errorf("round(%s) = %d, want %d", v, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name     string
+		m        Money
+		currency string
+		rate     *big.Rat
+		want     Money
+	}{
+		{"same currency", New(1050, "TRY"), "TRY", big.NewRat(1, 1), New(1050, "TRY")},
+		{"half cent up", New(1, "USD"), "EUR", big.NewRat(1, 2), New(1, "EUR")},
+		{"below half cent down", New(1, "USD"), "EUR", big.NewRat(49, 100), New(0, "EUR")},
+		{"negative half cent away from zero", New(-1, "USD"), "EUR", big.NewRat(1, 2), New(-1, "EUR")},
+		{"to zero decimals", New(150, "TRY"), "JPY", big.NewRat(1, 1), New(2, "JPY")},
+		{"to zero decimals negative", New(-150, "TRY"), "JPY", big.NewRat(1, 1), New(-2, "JPY")},
+		{"from zero decimals", New(1000, "JPY"), "USD", big.NewRat(67, 10000), New(670, "USD")},
+		{"to three decimals", New(1999, "USD"), "KWD", big.NewRat(3075, 10000), New(6147, "KWD")},
+		{"try to usd", New(10000, "TRY"), "USD", big.NewRat(3125, 100000), New(313, "USD")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.m.Convert(tt.currency, tt.rate); got != tt.want {
+				t.Errorf("%v.Convert(%s, %s) = %v, want %v", tt.m, tt.currency, tt.rate, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMulRat(t *testing.T) {
+	tests := []struct {
+		name string
+		m    Money
+		r    *big.Rat
+		want Money
+	}{
+		{"whole", New(1000, "TRY"), big.NewRat(3, 1), New(3000, "TRY")},
+		{"1.5 kg", New(1999, "TRY"), big.NewRat(3, 2), New(2999, "TRY")},
+		{"0.333 kg", New(1000, "TRY"), big.NewRat(333, 1000), New(333, "TRY")},
+		{"half up", New(1, "TRY"), big.NewRat(1, 2), New(1, "TRY")},
+		{"negative half away from zero", New(-1, "TRY"), big.NewRat(1, 2), New(-1, "TRY")},
+		{"below half", New(1, "TRY"), big.NewRat(49, 100), New(0, "TRY")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.m.MulRat(tt.r); got != tt.want {
+				t.Errorf("%v.MulRat(%s) = %v, want %v", tt.m, tt.r, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Email sends notifications as plain text mails through an SMTP server, to
+// the given recipients unless the notification is addressed to others.
+type Email struct {
+	addr string
+	auth smtp.Auth
+	from string
+	to   []string
+}
+
+// NewEmail -.
+// The server is authenticated with PLAIN auth when username is given.
+func NewEmail(host, port, username, password, from string, to []string) (*Email, error) {
+	if len(host) == 0 {
+		return nil, fmt.Errorf("notifier - NewEmail - host is required")
+	}
+	if len(from) == 0 {
+		return nil, fmt.Errorf("notifier - NewEmail - sender is required")
+	}
+	if len(to) == 0 {
+		return nil, fmt.Errorf("notifier - NewEmail - at least one recipient is required")
+	}
+	if len(port) == 0 {
+		port = "25"
+	}
+
+	var auth smtp.Auth
+	if len(username) > 0 {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &Email{
+		addr: net.JoinHostPort(host, port),
+		auth: auth,
+		from: from,
+		to:   to,
+	}, nil
+}
+
+// Notify -.
+func (e *Email) Notify(ctx context.Context, n Notification) error {
+	to := e.to
+	if len(n.To) > 0 {
+		to = n.To
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", e.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", n.Subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", n.Time.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(n.Text, "\n", "\r\n"))
+	msg.WriteString("\r\n")
+
+	if err := smtp.SendMail(e.addr, e.auth, e.from, to, msg.Bytes()); err != nil {
+		return fmt.Errorf("notifier - Email - Notify: %w", err)
+	}
+	return nil
+}
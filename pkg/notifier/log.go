@@ -0,0 +1,28 @@
+package notifier
+
+import (
+	"context"
+	"strings"
+
+	"github.com/bestetufan/beste-store/pkg/logger"
+)
+
+// Log writes notifications to the application log.
+type Log struct {
+	logger *logger.Logger
+}
+
+// NewLog -.
+func NewLog(l *logger.Logger) *Log {
+	return &Log{logger: l}
+}
+
+// Notify -.
+func (l *Log) Notify(ctx context.Context, n Notification) error {
+	if len(n.To) > 0 {
+		l.logger.Warn("notification - %s - to %s - %s: %s", n.Event, strings.Join(n.To, ", "), n.Subject, n.Text)
+		return nil
+	}
+	l.logger.Warn("notification - %s - %s: %s", n.Event, n.Subject, n.Text)
+	return nil
+}
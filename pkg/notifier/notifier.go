@@ -0,0 +1,39 @@
+package notifier
+
+import (
+	"context"
+	"time"
+)
+
+// Notification is a message about an event in the store, e.g. a product
+// running low on stock. Notifications for particular users, e.g. customers,
+// are addressed To them; the others go to the recipients of the notifier.
+type Notification struct {
+	Event   string      `json:"event"`
+	To      []string    `json:"to,omitempty"`
+	Subject string      `json:"subject"`
+	Text    string      `json:"text"`
+	Data    interface{} `json:"data,omitempty"`
+	Time    time.Time   `json:"time"`
+}
+
+// Notifier -.
+type Notifier interface {
+	// Notify delivers the notification.
+	Notify(ctx context.Context, n Notification) error
+}
+
+// Multi delivers notifications through several notifiers.
+type Multi []Notifier
+
+// Notify delivers the notification through every notifier, even when some
+// of them fail, and returns the first error.
+func (m Multi) Notify(ctx context.Context, n Notification) error {
+	var first error
+	for _, notifier := range m {
+		if err := notifier.Notify(ctx, n); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
@@ -0,0 +1,68 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Webhook posts notifications as JSON to a URL. When a secret is set, the
+// body is signed with HMAC-SHA256 and the hex signature is sent in the
+// X-Signature header as "sha256=<signature>".
+type Webhook struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// NewWebhook -.
+func NewWebhook(rawURL, secret string) (*Webhook, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("notifier - NewWebhook - invalid url: %q", rawURL)
+	}
+
+	return &Webhook{
+		url:    rawURL,
+		secret: secret,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Notify -.
+func (w *Webhook) Notify(ctx context.Context, n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("notifier - Webhook - Notify - json.Marshal: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("notifier - Webhook - Notify - http.NewRequest: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event", n.Event)
+	if len(w.secret) > 0 {
+		mac := hmac.New(sha256.New, []byte(w.secret))
+		mac.Write(body)
+		req.Header.Set("X-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("notifier - Webhook - Notify: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("notifier - Webhook - Notify - unexpected status: %s", resp.Status)
+	}
+	return nil
+}
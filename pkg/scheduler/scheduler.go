@@ -0,0 +1,81 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bestetufan/beste-store/pkg/logger"
+)
+
+// Job is a unit of background work. The context is canceled on Stop.
+type Job func(ctx context.Context) error
+
+type entry struct {
+	name     string
+	interval time.Duration
+	job      Job
+}
+
+// Scheduler runs registered jobs periodically, each in its own goroutine.
+// A job never overlaps with itself; failures are logged and retried on the
+// next tick.
+type Scheduler struct {
+	logger  *logger.Logger
+	entries []entry
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+func New(l *logger.Logger) *Scheduler {
+	return &Scheduler{logger: l}
+}
+
+// Every registers a job run once per interval. Jobs with a non-positive
+// interval are disabled. Every must be called before Start.
+func (s *Scheduler) Every(name string, interval time.Duration, job Job) {
+	if interval <= 0 {
+		s.logger.Info("scheduler - job disabled: " + name)
+		return
+	}
+	s.entries = append(s.entries, entry{name, interval, job})
+}
+
+// Start launches the registered jobs. The first run happens one interval
+// after Start.
+func (s *Scheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	for _, e := range s.entries {
+		s.wg.Add(1)
+		go s.run(ctx, e)
+	}
+}
+
+// Stop cancels running jobs and waits for them to return.
+func (s *Scheduler) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+func (s *Scheduler) run(ctx context.Context, e entry) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := e.job(ctx); err != nil {
+				s.logger.Error(fmt.Errorf("scheduler - %s: %w", e.name, err))
+			}
+		}
+	}
+}
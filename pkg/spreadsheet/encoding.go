@@ -0,0 +1,41 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// DecodeText converts text to UTF-8. A UTF-8 or UTF-16 byte order mark
+// selects the encoding; text without one is kept when it is valid UTF-8 and
+// is otherwise read as Windows-1254, the code page of Turkish Excel exports.
+func DecodeText(data []byte) []byte {
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		return data[len(bomUTF8):]
+	case bytes.HasPrefix(data, bomUTF16LE), bytes.HasPrefix(data, bomUTF16BE):
+		return decode(unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM).NewDecoder(), data)
+	case utf8.Valid(data):
+		return data
+	}
+	return decode(charmap.Windows1254.NewDecoder(), data)
+}
+
+// decode converts data with d. Invalid input is replaced, so it only fails
+// on conditions that do not occur in memory; data is then kept as is.
+func decode(d *encoding.Decoder, data []byte) []byte {
+	text, err := d.Bytes(data)
+	if err != nil {
+		return data
+	}
+	return text
+}
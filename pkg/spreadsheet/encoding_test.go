@@ -0,0 +1,70 @@
+package spreadsheet
+
+import "testing"
+
+func TestDecodeText(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{
+			name: "empty",
+			data: nil,
+			want: "",
+		},
+		{
+			name: "ascii",
+			data: []byte("name;is_active\r\n"),
+			want: "name;is_active\r\n",
+		},
+		{
+			name: "utf-8",
+			data: []byte("Saç Boyası;Çiçek"),
+			want: "Saç Boyası;Çiçek",
+		},
+		{
+			name: "utf-8 with bom",
+			data: []byte("\xEF\xBB\xBFİğne"),
+			want: "İğne",
+		},
+		{
+			name: "windows-1254 turkish letters",
+			data: []byte{0xD0, 0xF0, 0xDD, 0xFD, 0xDE, 0xFE, 0xC7, 0xE7, 0xD6, 0xF6, 0xDC, 0xFC},
+			want: "ĞğİıŞşÇçÖöÜü",
+		},
+		{
+			name: "windows-1254 text",
+			data: []byte("Sa\xE7 Boyas\xFD;Kad\xFDn \xDEampuan\xFD"),
+			want: "Saç Boyası;Kadın Şampuanı",
+		},
+		{
+			name: "windows-1254 punctuation",
+			data: []byte{0x80, 0x20, 0x93, 0x61, 0x94, 0x20, 0x96},
+			want: "€ “a” –",
+		},
+		{
+			name: "utf-16le with bom",
+			data: []byte{0xFF, 0xFE, 0x30, 0x01, 0x31, 0x01, 0x3B, 0x00, 0x5F, 0x01},
+			want: "İı;ş",
+		},
+		{
+			name: "utf-16be with bom",
+			data: []byte{0xFE, 0xFF, 0x01, 0x1E, 0x00, 0x3B, 0x00, 0xC7},
+			want: "Ğ;Ç",
+		},
+		{
+			name: "utf-16le surrogate pair",
+			data: []byte{0xFF, 0xFE, 0x3D, 0xD8, 0x4D, 0xDC},
+			want: "👍",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(DecodeText(tt.data)); got != tt.want {
+				t.Errorf("DecodeText(% X) = %q, want %q", tt.data, got, tt.want)
+			}
+		})
+	}
+}
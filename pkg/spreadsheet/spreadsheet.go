@@ -0,0 +1,134 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Row is a record read from a spreadsheet together with the line (or sheet
+// row) number it starts at.
+type Row struct {
+	Line  int
+	Cells []string
+}
+
+// Get returns the cell at index i, or an empty string when the row is shorter.
+func (r Row) Get(i int) string {
+	if i < 0 || i >= len(r.Cells) {
+		return ""
+	}
+	return strings.TrimSpace(r.Cells[i])
+}
+
+// IsEmpty reports whether all cells of the row are blank.
+func (r Row) IsEmpty() bool {
+	for i := range r.Cells {
+		if len(r.Get(i)) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Read parses a CSV or XLSX file. The format is chosen by the file name
+// extension and, for unknown extensions, by the content.
+func Read(file io.Reader, name string) ([]Row, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("spreadsheet - Read - io.ReadAll: %w", err)
+	}
+
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext == ".xlsx" || (ext != ".csv" && bytes.HasPrefix(data, []byte("PK\x03\x04"))) {
+		return ReadXLSX(data)
+	}
+
+	return ReadCSV(data)
+}
+
+// ReadCSV parses CSV data, detecting its text encoding and delimiter.
+func ReadCSV(data []byte) ([]Row, error) {
+	data = DecodeText(data)
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = DetectDelimiter(data)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	var rows []Row
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("spreadsheet - ReadCSV - csv.Read: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, Row{Line: line, Cells: record})
+	}
+
+	return rows, nil
+}
+
+// DetectDelimiter guesses the delimiter of CSV data from its first lines.
+// A candidate scores when it occurs the same, non-zero number of times
+// outside quotes on every sampled line; ties go to the more frequent one.
+// Comma is returned when no candidate qualifies.
+func DetectDelimiter(data []byte) rune {
+	const sampleLines = 5
+
+	lines := bytes.SplitN(data, []byte("\n"), sampleLines+1)
+	if len(lines) > sampleLines {
+		lines = lines[:sampleLines]
+	}
+
+	delimiter, best := ',', 0
+	for _, candidate := range []rune{',', ';', '\t', '|'} {
+		count := -1
+		for _, line := range lines {
+			if len(bytes.TrimSpace(line)) == 0 {
+				continue
+			}
+			n := countUnquoted(line, byte(candidate))
+			if count == -1 {
+				count = n
+			} else if n != count {
+				count = 0
+				break
+			}
+		}
+		if count > best {
+			delimiter, best = candidate, count
+		}
+	}
+
+	if best == 0 {
+		// Inconsistent lines (e.g. quoted line breaks): fall back to the header.
+		for _, candidate := range []rune{',', ';', '\t', '|'} {
+			if n := countUnquoted(lines[0], byte(candidate)); n > best {
+				delimiter, best = candidate, n
+			}
+		}
+	}
+
+	return delimiter
+}
+
+func countUnquoted(line []byte, c byte) int {
+	n, quoted := 0, false
+	for _, b := range line {
+		switch {
+		case b == '"':
+			quoted = !quoted
+		case b == c && !quoted:
+			n++
+		}
+	}
+	return n
+}
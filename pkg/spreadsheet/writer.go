@@ -0,0 +1,168 @@
+package spreadsheet
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+	FormatXLSX   = "xlsx"
+)
+
+// Writer streams rows in a tabular format. WriteHeader must be called once
+// before the first row and Close after the last one. Values may be strings,
+// integers, floats, booleans, times or nil.
+type Writer interface {
+	WriteHeader(columns []string) error
+	Write(values []interface{}) error
+	// Flush pushes buffered rows to the underlying writer, flushing it too
+	// when it supports that (e.g. an HTTP response).
+	Flush() error
+	Close() error
+	ContentType() string
+	Extension() string
+}
+
+type flusher interface {
+	Flush()
+}
+
+// NewWriter returns a writer for the given format. Nothing is written to out
+// before WriteHeader is called.
+func NewWriter(format string, out io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV, "":
+		return &csvWriter{out: out, csv: csv.NewWriter(out)}, nil
+	case FormatNDJSON, "jsonl":
+		return &ndjsonWriter{out: out, buf: bufio.NewWriter(out)}, nil
+	case FormatXLSX:
+		return newXLSXWriter(out), nil
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+func flushOut(out io.Writer) {
+	if f, ok := out.(flusher); ok {
+		f.Flush()
+	}
+}
+
+type csvWriter struct {
+	out io.Writer
+	csv *csv.Writer
+}
+
+func (w *csvWriter) WriteHeader(columns []string) error {
+	// Excel needs the BOM to read UTF-8 CSV files correctly.
+	if _, err := w.out.Write(bomUTF8); err != nil {
+		return err
+	}
+	return w.csv.Write(columns)
+}
+
+func (w *csvWriter) Write(values []interface{}) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = formatText(v)
+	}
+	return w.csv.Write(record)
+}
+
+func (w *csvWriter) Flush() error {
+	w.csv.Flush()
+	flushOut(w.out)
+	return w.csv.Error()
+}
+
+func (w *csvWriter) Close() error {
+	return w.Flush()
+}
+
+func (w *csvWriter) ContentType() string {
+	return "text/csv; charset=utf-8"
+}
+
+func (w *csvWriter) Extension() string {
+	return ".csv"
+}
+
+type ndjsonWriter struct {
+	out  io.Writer
+	buf  *bufio.Writer
+	keys [][]byte
+}
+
+func (w *ndjsonWriter) WriteHeader(columns []string) error {
+	w.keys = make([][]byte, len(columns))
+	for i, c := range columns {
+		key, err := json.Marshal(c)
+		if err != nil {
+			return err
+		}
+		w.keys[i] = key
+	}
+	return nil
+}
+
+// Write encodes the row as a JSON object keeping the column order.
+func (w *ndjsonWriter) Write(values []interface{}) error {
+	w.buf.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			w.buf.WriteByte(',')
+		}
+		value, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		w.buf.Write(w.keys[i])
+		w.buf.WriteByte(':')
+		w.buf.Write(value)
+	}
+	_, err := w.buf.WriteString("}\n")
+	return err
+}
+
+func (w *ndjsonWriter) Flush() error {
+	err := w.buf.Flush()
+	flushOut(w.out)
+	return err
+}
+
+func (w *ndjsonWriter) Close() error {
+	return w.Flush()
+}
+
+func (w *ndjsonWriter) ContentType() string {
+	return "application/x-ndjson"
+}
+
+func (w *ndjsonWriter) Extension() string {
+	return ".ndjson"
+}
+
+// formatText renders a value for text based formats.
+func formatText(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case time.Time:
+		return t.Format(time.RFC3339)
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(t), 'f', -1, 32)
+	default:
+		return fmt.Sprint(t)
+	}
+}
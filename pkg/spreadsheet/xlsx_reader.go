@@ -0,0 +1,214 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+type (
+	xlsxWorkbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+
+	xlsxRelationships struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+
+	xlsxSharedStrings struct {
+		Items []xlsxRichText `xml:"si"`
+	}
+
+	xlsxRichText struct {
+		Text string `xml:"t"`
+		Runs []struct {
+			Text string `xml:"t"`
+		} `xml:"r"`
+	}
+
+	xlsxWorksheet struct {
+		Rows []struct {
+			Index int `xml:"r,attr"`
+			Cells []struct {
+				Ref    string       `xml:"r,attr"`
+				Type   string       `xml:"t,attr"`
+				Value  string       `xml:"v"`
+				Inline xlsxRichText `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+)
+
+func (t xlsxRichText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var b strings.Builder
+	for _, r := range t.Runs {
+		b.WriteString(r.Text)
+	}
+	return b.String()
+}
+
+// ReadXLSX returns the rows of the first worksheet of an XLSX workbook.
+// Cells are returned as their displayed text for strings and booleans and as
+// the stored value for numbers; formulas yield their cached result.
+func ReadXLSX(data []byte) ([]Row, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("spreadsheet - ReadXLSX - zip.NewReader: %w", err)
+	}
+
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+
+	sheetPath, err := firstSheetPath(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var shared xlsxSharedStrings
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodeXML(f, &shared); err != nil {
+			return nil, err
+		}
+	}
+
+	f, ok := files[sheetPath]
+	if !ok {
+		return nil, fmt.Errorf("spreadsheet - ReadXLSX - missing worksheet: %s", sheetPath)
+	}
+
+	var sheet xlsxWorksheet
+	if err := decodeXML(f, &sheet); err != nil {
+		return nil, err
+	}
+
+	rows := make([]Row, 0, len(sheet.Rows))
+	for i, r := range sheet.Rows {
+		row := Row{Line: r.Index}
+		if row.Line == 0 {
+			row.Line = i + 1
+		}
+
+		for j, c := range r.Cells {
+			col := j
+			if len(c.Ref) > 0 {
+				var err error
+				if col, err = columnIndex(c.Ref); err != nil {
+					return nil, err
+				}
+			}
+			if col >= maxColumns {
+				return nil, fmt.Errorf("spreadsheet - ReadXLSX - too many columns in row %d", row.Line)
+			}
+			for len(row.Cells) <= col {
+				row.Cells = append(row.Cells, "")
+			}
+
+			switch c.Type {
+			case "s":
+				idx, err := strconv.Atoi(c.Value)
+				if err != nil || idx < 0 || idx >= len(shared.Items) {
+					return nil, fmt.Errorf("spreadsheet - ReadXLSX - invalid shared string in %s", c.Ref)
+				}
+				row.Cells[col] = shared.Items[idx].String()
+			case "inlineStr":
+				row.Cells[col] = c.Inline.String()
+			case "b":
+				row.Cells[col] = strconv.FormatBool(c.Value == "1")
+			default:
+				row.Cells[col] = c.Value
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func firstSheetPath(files map[string]*zip.File) (string, error) {
+	const fallback = "xl/worksheets/sheet1.xml"
+
+	wf, ok := files["xl/workbook.xml"]
+	if !ok {
+		return fallback, nil
+	}
+	var workbook xlsxWorkbook
+	if err := decodeXML(wf, &workbook); err != nil {
+		return "", err
+	}
+	if len(workbook.Sheets) == 0 {
+		return "", fmt.Errorf("spreadsheet - ReadXLSX - workbook has no sheets")
+	}
+
+	rf, ok := files["xl/_rels/workbook.xml.rels"]
+	if !ok {
+		return fallback, nil
+	}
+	var rels xlsxRelationships
+	if err := decodeXML(rf, &rels); err != nil {
+		return "", err
+	}
+
+	for _, rel := range rels.Relationships {
+		if rel.ID == workbook.Sheets[0].RID {
+			if strings.HasPrefix(rel.Target, "/") {
+				return strings.TrimPrefix(rel.Target, "/"), nil
+			}
+			return path.Join("xl", rel.Target), nil
+		}
+	}
+
+	return fallback, nil
+}
+
+func decodeXML(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("spreadsheet - ReadXLSX - open %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+
+	if err := xml.NewDecoder(io.LimitReader(rc, 256<<20)).Decode(v); err != nil {
+		return fmt.Errorf("spreadsheet - ReadXLSX - decode %s: %w", f.Name, err)
+	}
+
+	return nil
+}
+
+// maxColumns is the number of columns of a worksheet, up to XFD.
+const maxColumns = 16384
+
+// columnIndex converts the letters of a cell reference such as "AB12" into a
+// zero based column index.
+func columnIndex(ref string) (int, error) {
+	n := 0
+	for _, ch := range ref {
+		if ch < 'A' || ch > 'Z' {
+			break
+		}
+		n = n*26 + int(ch-'A'+1)
+		if n > maxColumns {
+			return 0, fmt.Errorf("spreadsheet - ReadXLSX - column out of range in %q", ref)
+		}
+	}
+	if n == 0 {
+		return 0, fmt.Errorf("spreadsheet - ReadXLSX - invalid cell reference %q", ref)
+	}
+	return n - 1, nil
+}
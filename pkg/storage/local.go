@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Local stores objects in a directory on the local filesystem.
+type Local struct {
+	root    string
+	baseURL string
+}
+
+// NewLocal -.
+func NewLocal(root string, baseURL string) (*Local, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("storage - NewLocal - os.MkdirAll: %w", err)
+	}
+	return &Local{
+		root:    root,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}, nil
+}
+
+// Root returns the directory the objects are stored in.
+func (s *Local) Root() string {
+	return s.root
+}
+
+// Put -.
+func (s *Local) Put(key string, content io.Reader, contentType string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return fmt.Errorf("storage - Local.Put - os.MkdirAll: %w", err)
+	}
+
+	f, err := os.Create(name)
+	if err != nil {
+		return fmt.Errorf("storage - Local.Put - os.Create: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, content); err != nil {
+		return fmt.Errorf("storage - Local.Put - io.Copy: %w", err)
+	}
+
+	return f.Close()
+}
+
+// Get -.
+func (s *Local) Get(key string) (io.ReadCloser, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("storage - Local.Get - os.Open: %w", err)
+	}
+
+	return f, nil
+}
+
+// Delete -.
+func (s *Local) Delete(key string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(name)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("storage - Local.Delete - os.Remove: %w", err)
+	}
+
+	return nil
+}
+
+// URL -.
+func (s *Local) URL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+// path maps a key to a file below root, rejecting keys escaping it.
+func (s *Local) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" {
+		return "", fmt.Errorf("storage - Local - invalid key: %q", key)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(clean)), nil
+}
@@ -0,0 +1,298 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// _emptyPayload is the hex SHA-256 of an empty body.
+var _emptyPayload = sha256Hex(nil)
+
+const (
+	_s3Service = "s3"
+	// _unsignedPayload is signed in place of the hash of streamed bodies.
+	_unsignedPayload = "UNSIGNED-PAYLOAD"
+	// _s3ResponseTimeout limits the wait for the response headers of a
+	// request. Bodies are not limited, so large objects can be streamed.
+	_s3ResponseTimeout = 30 * time.Second
+)
+
+// S3 stores objects in a bucket of an S3 compatible service (AWS S3, MinIO).
+// Requests use path-style addressing and are signed with AWS Signature V4.
+type S3 struct {
+	endpoint  *url.URL
+	bucket    string
+	region    string
+	accessKey string
+	secretKey string
+	publicURL string
+	client    *http.Client
+}
+
+// NewS3 -.
+// publicURL is the address objects are served from; when empty it defaults
+// to the bucket address on the endpoint.
+func NewS3(endpoint, bucket, region, accessKey, secretKey, publicURL string) (*S3, error) {
+	u, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("storage - NewS3 - invalid endpoint: %q", endpoint)
+	}
+	if len(bucket) == 0 {
+		return nil, fmt.Errorf("storage - NewS3 - bucket is required")
+	}
+	if len(region) == 0 {
+		region = "us-east-1"
+	}
+	if len(publicURL) == 0 {
+		publicURL = u.String() + "/" + bucket
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = _s3ResponseTimeout
+
+	return &S3{
+		endpoint:  u,
+		bucket:    bucket,
+		region:    region,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		publicURL: strings.TrimSuffix(publicURL, "/"),
+		client:    &http.Client{Transport: transport},
+	}, nil
+}
+
+// Put -.
+// The content is streamed with an unsigned payload. Its size is taken from
+// seekable contents; others are spooled to a temporary file first, as S3
+// needs the length of the object up front.
+func (s *S3) Put(key string, content io.Reader, contentType string) error {
+	body, size, cleanup, err := sizedContent(content)
+	if err != nil {
+		return fmt.Errorf("storage - S3.Put - sizedContent: %w", err)
+	}
+	defer cleanup()
+
+	req, err := s.newRequest(http.MethodPut, key, body, size)
+	if err != nil {
+		return err
+	}
+	if len(contentType) > 0 {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := s.do(req, _unsignedPayload)
+	if err != nil {
+		return fmt.Errorf("storage - S3.Put: %w", err)
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// Get -.
+func (s *S3) Get(key string) (io.ReadCloser, error) {
+	req, err := s.newRequest(http.MethodGet, key, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(req, _emptyPayload)
+	if err != nil {
+		return nil, fmt.Errorf("storage - S3.Get: %w", err)
+	}
+
+	return resp.Body, nil
+}
+
+// Delete -.
+func (s *S3) Delete(key string) error {
+	req, err := s.newRequest(http.MethodDelete, key, nil, 0)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(req, _emptyPayload)
+	if err == ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("storage - S3.Delete: %w", err)
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// URL -.
+func (s *S3) URL(key string) string {
+	return s.publicURL + "/" + escapePath(key)
+}
+
+func (s *S3) newRequest(method string, key string, body io.Reader, size int64) (*http.Request, error) {
+	u := *s.endpoint
+	u.Path = "/" + s.bucket + "/" + strings.TrimPrefix(key, "/")
+	u.RawPath = "/" + s.bucket + "/" + escapePath(strings.TrimPrefix(key, "/"))
+
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("storage - S3 - http.NewRequest: %w", err)
+	}
+	req.ContentLength = size
+	if body != nil && size == 0 {
+		req.Body = http.NoBody
+	}
+
+	return req, nil
+}
+
+func (s *S3) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	s.sign(req, payloadHash, time.Now().UTC())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, msg)
+	}
+
+	return resp, nil
+}
+
+// sign adds AWS Signature V4 headers to the request. payloadHash is the
+// hex SHA-256 of the body or _unsignedPayload.
+func (s *S3) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+
+	req.Header.Set("Host", req.URL.Host)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzDate,
+	}
+	if ct := req.Header.Get("Content-Type"); len(ct) > 0 {
+		headers["content-type"] = ct
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + s.region + "/" + _s3Service + "/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), day)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, _s3Service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+// sizedContent returns content with its remaining size. Contents that can
+// not seek are copied to a temporary file, removed by cleanup.
+func sizedContent(content io.Reader) (io.Reader, int64, func(), error) {
+	if seeker, ok := content.(io.Seeker); ok {
+		current, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		end, err := seeker.Seek(0, io.SeekEnd)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		if _, err := seeker.Seek(current, io.SeekStart); err != nil {
+			return nil, 0, nil, err
+		}
+		return io.LimitReader(content, end-current), end - current, func() {}, nil
+	}
+
+	f, err := os.CreateTemp("", "s3-put-*")
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	cleanup := func() {
+		f.Close()
+		os.Remove(f.Name())
+	}
+	size, err := io.Copy(f, content)
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		cleanup()
+		return nil, 0, nil, err
+	}
+	return f, size, cleanup, nil
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// escapePath percent-encodes everything but unreserved characters and
+// slashes, as required by the S3 canonical request.
+func escapePath(key string) string {
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
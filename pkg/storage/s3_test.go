@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestS3 connects to the S3 compatible service given by S3_TEST_ENDPOINT,
+// e.g. the MinIO of docker-compose, and creates the test bucket when missing.
+// Tests are skipped when no endpoint is configured.
+func newTestS3(t *testing.T) *S3 {
+	t.Helper()
+
+	endpoint := os.Getenv("S3_TEST_ENDPOINT")
+	if len(endpoint) == 0 {
+		t.Skip("S3_TEST_ENDPOINT is not set")
+	}
+	bucket := os.Getenv("S3_TEST_BUCKET")
+	if len(bucket) == 0 {
+		bucket = "bestestore-test"
+	}
+
+	s, err := NewS3(endpoint, bucket, os.Getenv("S3_TEST_REGION"), os.Getenv("S3_TEST_ACCESS_KEY"),
+		os.Getenv("S3_TEST_SECRET_KEY"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u := *s.endpoint
+	u.Path = "/" + bucket
+	req, err := http.NewRequest(http.MethodPut, u.String(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.sign(req, _emptyPayload, time.Now().UTC())
+	resp, err := s.client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusConflict {
+		t.Fatalf("unable to create bucket %s: status %d", bucket, resp.StatusCode)
+	}
+
+	return s
+}
+
+func TestS3PutGetDelete(t *testing.T) {
+	s := newTestS3(t)
+
+	tests := []struct {
+		name    string
+		key     string
+		content func(data []byte) io.Reader
+		data    []byte
+	}{
+		{
+			name:    "seekable",
+			key:     "test/seekable.txt",
+			content: func(data []byte) io.Reader { return bytes.NewReader(data) },
+			data:    []byte("beste store"),
+		},
+		{
+			name:    "streamed",
+			key:     "test/streamed.bin",
+			content: func(data []byte) io.Reader { return io.MultiReader(bytes.NewReader(data)) },
+			data:    bytes.Repeat([]byte{0, 1, 2, 3, 4, 5, 6, 7}, 1<<17),
+		},
+		{
+			name:    "empty",
+			key:     "test/empty.txt",
+			content: func(data []byte) io.Reader { return bytes.NewReader(data) },
+			data:    []byte{},
+		},
+		{
+			name:    "escaped key",
+			key:     "test/ürün görseli (1).txt",
+			content: func(data []byte) io.Reader { return strings.NewReader(string(data)) },
+			data:    []byte("ç ğ ı ö ş ü"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.Put(tt.key, tt.content(tt.data), "application/octet-stream"); err != nil {
+				t.Fatalf("Put: %v", err)
+			}
+
+			r, err := s.Get(tt.key)
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			got, err := io.ReadAll(r)
+			r.Close()
+			if err != nil {
+				t.Fatalf("Get: read: %v", err)
+			}
+			if !bytes.Equal(got, tt.data) {
+				t.Errorf("Get: got %d bytes, want %d", len(got), len(tt.data))
+			}
+
+			if err := s.Delete(tt.key); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if _, err := s.Get(tt.key); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get after Delete: got %v, want %v", err, ErrNotFound)
+			}
+			if err := s.Delete(tt.key); err != nil {
+				t.Errorf("Delete of missing object: %v", err)
+			}
+		})
+	}
+}
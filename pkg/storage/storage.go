@@ -0,0 +1,21 @@
+package storage
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrNotFound is returned when an object does not exist in the storage.
+var ErrNotFound = errors.New("storage: object not found")
+
+// Storage -.
+type Storage interface {
+	// Put writes the content under key, replacing any existing object.
+	Put(key string, content io.Reader, contentType string) error
+	// Get opens the object stored under key.
+	Get(key string) (io.ReadCloser, error)
+	// Delete removes the object stored under key. Missing objects are ignored.
+	Delete(key string) error
+	// URL returns the public address of the object stored under key.
+	URL(key string) string
+}
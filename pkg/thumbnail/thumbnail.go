@@ -0,0 +1,65 @@
+package thumbnail
+
+import (
+	"image"
+	"image/color"
+)
+
+// Fit returns the dimensions of a w x h rectangle scaled down to fit into a
+// max x max square, keeping the aspect ratio. Smaller rectangles are kept.
+func Fit(w, h, max int) (int, int) {
+	if w <= max && h <= max {
+		return w, h
+	}
+	if w >= h {
+		return max, maxInt(1, h*max/w)
+	}
+	return maxInt(1, w*max/h), max
+}
+
+// Resize scales src down so that it fits into a max x max square. Each
+// destination pixel is the average of the source pixels it covers, which
+// avoids the aliasing of nearest-neighbour sampling on large reductions.
+func Resize(src image.Image, max int) image.Image {
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	dw, dh := Fit(sw, sh, max)
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0 := b.Min.Y + y*sh/dh
+		y1 := b.Min.Y + maxInt((y+1)*sh/dh, y*sh/dh+1)
+		for x := 0; x < dw; x++ {
+			x0 := b.Min.X + x*sw/dw
+			x1 := b.Min.X + maxInt((x+1)*sw/dw, x*sw/dw+1)
+
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := color.NRGBA64Model.Convert(src.At(sx, sy)).(color.NRGBA64)
+					r += uint64(c.R)
+					g += uint64(c.G)
+					bl += uint64(c.B)
+					a += uint64(c.A)
+					n++
+				}
+			}
+
+			dst.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(bl / n >> 8),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+
+	return dst
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}